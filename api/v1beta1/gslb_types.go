@@ -33,6 +33,52 @@ type Strategy struct {
 	DNSTtlSeconds int `json:"dnsTtlSeconds,omitempty"`
 	// Split brain TXT record expiration in seconds
 	SplitBrainThresholdSeconds int `json:"splitBrainThresholdSeconds,omitempty"`
+	// Defines how load balancers exposed by hostname (e.g. AWS ELB) are published; resolve (default)
+	// publishes A/AAAA records with resolved IPs, cname publishes CNAME record pointing to load balancer hostname
+	// +kubebuilder:validation:Enum=resolve;cname
+	HostnameTargets string `json:"hostnameTargets,omitempty"`
+}
+
+// ServiceRef binds Gslb enabled host to the Service type LoadBalancer exposing it
+// +k8s:openapi-gen=true
+type ServiceRef struct {
+	// Host is the GSLB enabled FQDN served by the Service
+	Host string `json:"host"`
+	// ServiceName of the Service type LoadBalancer within the Gslb namespace
+	ServiceName string `json:"serviceName"`
+}
+
+// IngressRef references existing Ingress exposing Gslb hosts
+// +k8s:openapi-gen=true
+type IngressRef struct {
+	// Name of the Ingress within the Gslb namespace
+	Name string `json:"name"`
+}
+
+// HTTPRouteRef references Gateway API HTTPRoute exposing Gslb hosts
+// +k8s:openapi-gen=true
+type HTTPRouteRef struct {
+	// Name of the HTTPRoute within the Gslb namespace
+	Name string `json:"name"`
+}
+
+// GatewayListenerRef references Gateway API Gateway listeners exposing Gslb hosts
+// +k8s:openapi-gen=true
+type GatewayListenerRef struct {
+	// Name of the Gateway within the Gslb namespace
+	Name string `json:"name"`
+	// SectionName of the Gateway listener. When empty, all Gateway listeners are used
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// VirtualServiceRef references Istio VirtualService exposing Gslb hosts
+// +k8s:openapi-gen=true
+type VirtualServiceRef struct {
+	// Name of the VirtualService within the Gslb namespace
+	Name string `json:"name"`
+	// GatewayService is the ingress gateway Service type LoadBalancer in format namespace/name. When empty,
+	// Service is discovered by selectors of Istio Gateways listed in VirtualService gateways
+	GatewayService string `json:"gatewayService,omitempty"`
 }
 
 // GslbSpec defines the desired state of Gslb
@@ -41,8 +87,19 @@ type GslbSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	Ingress  v1beta1.IngressSpec `json:"ingress"`
-	Strategy Strategy            `json:"strategy"`
+	// Ingress is used verbatim to create the Gslb Ingress. Mutually exclusive with other sources
+	Ingress v1beta1.IngressSpec `json:"ingress,omitempty"`
+	// IngressRef exposing Gslb hosts. The Ingress is only read, k8gb never creates or updates it
+	IngressRef *IngressRef `json:"ingressRef,omitempty"`
+	// Services exposing Gslb hosts directly via Service type LoadBalancer without Ingress
+	Services []ServiceRef `json:"services,omitempty"`
+	// HTTPRoute exposing Gslb hosts through Gateway API Gateway
+	HTTPRoute *HTTPRouteRef `json:"httpRoute,omitempty"`
+	// Gateway listener exposing Gslb hosts by its hostname, without HTTPRoute
+	Gateway *GatewayListenerRef `json:"gateway,omitempty"`
+	// VirtualService exposing Gslb hosts through Istio ingress gateway
+	VirtualService *VirtualServiceRef `json:"virtualService,omitempty"`
+	Strategy       Strategy           `json:"strategy"`
 }
 
 // GslbStatus defines the observed state of Gslb
@@ -52,6 +109,8 @@ type GslbStatus struct {
 	ServiceHealth  map[string]string   `json:"serviceHealth"`
 	HealthyRecords map[string][]string `json:"healthyRecords"`
 	GeoTag         string              `json:"geoTag"`
+	// Round-trip time to k8gb clusters in other locations by their geoTags, measured by latency strategy
+	Latency map[string]string `json:"latency,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -24,6 +24,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayListenerRef) DeepCopyInto(out *GatewayListenerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayListenerRef.
+func (in *GatewayListenerRef) DeepCopy() *GatewayListenerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayListenerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Gslb) DeepCopyInto(out *Gslb) {
 	*out = *in
@@ -87,6 +102,31 @@ func (in *GslbList) DeepCopyObject() runtime.Object {
 func (in *GslbSpec) DeepCopyInto(out *GslbSpec) {
 	*out = *in
 	in.Ingress.DeepCopyInto(&out.Ingress)
+	if in.IngressRef != nil {
+		in, out := &in.IngressRef, &out.IngressRef
+		*out = new(IngressRef)
+		**out = **in
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]ServiceRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.HTTPRoute != nil {
+		in, out := &in.HTTPRoute, &out.HTTPRoute
+		*out = new(HTTPRouteRef)
+		**out = **in
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayListenerRef)
+		**out = **in
+	}
+	if in.VirtualService != nil {
+		in, out := &in.VirtualService, &out.VirtualService
+		*out = new(VirtualServiceRef)
+		**out = **in
+	}
 	out.Strategy = in.Strategy
 }
 
@@ -125,6 +165,13 @@ func (in *GslbStatus) DeepCopyInto(out *GslbStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Latency != nil {
+		in, out := &in.Latency, &out.Latency
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GslbStatus.
@@ -137,6 +184,51 @@ func (in *GslbStatus) DeepCopy() *GslbStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRouteRef) DeepCopyInto(out *HTTPRouteRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRouteRef.
+func (in *HTTPRouteRef) DeepCopy() *HTTPRouteRef {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRouteRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressRef) DeepCopyInto(out *IngressRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressRef.
+func (in *IngressRef) DeepCopy() *IngressRef {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceRef) DeepCopyInto(out *ServiceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceRef.
+func (in *ServiceRef) DeepCopy() *ServiceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Strategy) DeepCopyInto(out *Strategy) {
 	*out = *in
@@ -151,3 +243,18 @@ func (in *Strategy) DeepCopy() *Strategy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualServiceRef) DeepCopyInto(out *VirtualServiceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualServiceRef.
+func (in *VirtualServiceRef) DeepCopy() *VirtualServiceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualServiceRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// operatorDeployment is name of k8gb operator Deployment and its container
+const operatorDeployment = "k8gb"
+
+// operatorConfig reads geoTags and DNS zones from environment variables of k8gb operator Deployment. heartbeats is
+// true when the operator is configured with Infoblox, Route53 or NS1, k8gb publishes heartbeat TXT records to them
+func operatorConfig(ctx context.Context, c client.Client, namespace string) (config *depresolver.Config, heartbeats bool, err error) {
+	deployment := &appsv1.Deployment{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: operatorDeployment}, deployment)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading k8gb operator Deployment: %w", err)
+	}
+	var env []corev1.EnvVar
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == operatorDeployment {
+			env = container.Env
+		}
+	}
+	values := make(map[string]string, len(env))
+	for _, v := range env {
+		values[v.Name] = v.Value
+		if v.Name == depresolver.InfobloxGridHostKey {
+			// grid host comes from ConfigMap
+			heartbeats = true
+		}
+	}
+	for _, key := range []string{depresolver.Route53EnabledKey, depresolver.NS1EnabledKey} {
+		if values[key] == "true" {
+			heartbeats = true
+		}
+	}
+	config = &depresolver.Config{
+		ClusterGeoTag: values[depresolver.ClusterGeoTagKey],
+		EdgeDNSServer: values[depresolver.EdgeDNSServerKey],
+		EdgeDNSZone:   values[depresolver.EdgeDNSZoneKey],
+		DNSZone:       values[depresolver.DNSZoneKey],
+	}
+	for _, geoTag := range strings.Split(values[depresolver.ExtClustersGeoTagsKey], ",") {
+		if geoTag = strings.TrimSpace(geoTag); geoTag != "" {
+			config.ExtClustersGeoTags = append(config.ExtClustersGeoTags, geoTag)
+		}
+	}
+	if config.ClusterGeoTag == "" || config.EdgeDNSZone == "" || config.DNSZone == "" {
+		return nil, false, fmt.Errorf("k8gb operator Deployment in %s namespace doesn't set %s, %s and %s", namespace,
+			depresolver.ClusterGeoTagKey, depresolver.EdgeDNSZoneKey, depresolver.DNSZoneKey)
+	}
+	return config, heartbeats, nil
+}
+
+// parseGeoTagValues parses comma separated items in format geoTag=value; e.g. eu=10.0.0.1:53,us=10.1.0.1:53
+func parseGeoTagValues(s string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("%q is not in geoTag=value format", item)
+		}
+		values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return values, nil
+}
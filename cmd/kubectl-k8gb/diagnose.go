@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	"github.com/miekg/dns"
+)
+
+// Results of diagnose checks
+const (
+	resultOK   = "OK"
+	resultWarn = "WARN"
+	resultFail = "FAIL"
+)
+
+// check is result of single diagnose check
+type check struct {
+	name    string
+	subject string
+	result  string
+	detail  string
+}
+
+// diagnose checks zone delegation and glue records at EdgeDNS, heartbeats of all clusters and reachability of name
+// servers of clusters in other locations. Error is returned when any check fails
+func diagnose(ctx context.Context, env *environment, args []string) error {
+	if len(args) > 0 {
+		return errors.New("diagnose accepts no arguments")
+	}
+	checks := append(env.checkDelegation(ctx), env.checkGlueRecords(ctx)...)
+	heartbeats, err := env.checkHeartbeats(ctx)
+	if err != nil {
+		return err
+	}
+	checks = append(checks, heartbeats...)
+	checks = append(checks, env.checkPeers(ctx)...)
+
+	w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSUBJECT\tRESULT\tDETAIL")
+	failed := 0
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.name, c.subject, c.result, c.detail)
+		if c.result == resultFail {
+			failed++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkDelegation checks the zone is delegated at EdgeDNS to name servers of all clusters
+func (e *environment) checkDelegation(ctx context.Context) []check {
+	c := check{name: "delegation", subject: e.config.DNSZone}
+	m, _, err := e.query(ctx, e.edgeDNS, e.config.DNSZone, dns.TypeNS)
+	if err != nil {
+		return []check{c.fail(err.Error())}
+	}
+	// EdgeDNS answers NS records of delegated zone in authority section of referral
+	delegatedTo := recordValues(append(m.Answer, m.Ns...), e.config.DNSZone)
+	var missing []string
+	for _, geoTag := range e.geoTags() {
+		nameServer := gslbdns.NSServerName(geoTag, e.config)
+		if !contains(delegatedTo, nameServer) {
+			missing = append(missing, nameServer)
+		}
+	}
+	switch {
+	case len(delegatedTo) == 0:
+		return []check{c.fail("zone is not delegated")}
+	case len(missing) > 0:
+		return []check{c.fail("not delegated to " + strings.Join(missing, ", "))}
+	case len(delegatedTo) > len(e.geoTags()):
+		return []check{c.warn("delegated to unknown name servers too: " + strings.Join(delegatedTo, ", "))}
+	}
+	return []check{c.ok("delegated to " + strings.Join(delegatedTo, ", "))}
+}
+
+// checkGlueRecords checks EdgeDNS resolves name servers of all clusters
+func (e *environment) checkGlueRecords(ctx context.Context) (checks []check) {
+	for _, geoTag := range e.geoTags() {
+		nameServer := gslbdns.NSServerName(geoTag, e.config)
+		c := check{name: "glue", subject: nameServer}
+		addresses, err := e.lookup(ctx, e.edgeDNS, nameServer)
+		switch {
+		case err != nil:
+			checks = append(checks, c.fail(err.Error()))
+		case len(addresses) == 0:
+			checks = append(checks, c.fail("no A or AAAA record"))
+		default:
+			checks = append(checks, c.ok(list(addresses)))
+		}
+	}
+	return checks
+}
+
+// checkHeartbeats checks age of split brain TXT records of all clusters for every Gslb of the namespace against
+// Gslb splitBrainThresholdSeconds. k8gb publishes no heartbeats without EdgeDNS
+func (e *environment) checkHeartbeats(ctx context.Context) ([]check, error) {
+	if !e.heartbeats {
+		return []check{{name: "heartbeat", subject: "-", result: resultOK, detail: "skipped, heartbeats are published only to EdgeDNS"}}, nil
+	}
+	gslbs, err := e.gslbs(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var checks []check
+	for _, gslb := range gslbs {
+		for _, geoTag := range e.geoTags() {
+			checks = append(checks, e.checkHeartbeat(ctx, gslb, geoTag, time.Now()))
+		}
+	}
+	return checks, nil
+}
+
+func (e *environment) checkHeartbeat(ctx context.Context, gslb k8gbv1beta1.Gslb, geoTag string, now time.Time) check {
+	c := check{name: "heartbeat", subject: gslb.Name + "/" + geoTag}
+	name := gslbdns.HeartbeatTXTName(gslb.Name, geoTag, e.config)
+	m, _, err := e.query(ctx, e.edgeDNS, name, dns.TypeTXT)
+	if err != nil {
+		return c.fail(err.Error())
+	}
+	values := recordValues(m.Answer, name)
+	if len(values) == 0 {
+		return c.fail(name + " not found")
+	}
+	timestamp, err := time.Parse(gslbdns.HeartbeatTimestampLayout, values[0])
+	if err != nil {
+		return c.fail(fmt.Sprintf("%s has invalid value %q", name, values[0]))
+	}
+	age := now.UTC().Sub(timestamp).Round(time.Second)
+	threshold := time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds) * time.Second
+	if age > threshold {
+		return c.fail(fmt.Sprintf("%s old, expired after %s", age, threshold))
+	}
+	return c.ok(fmt.Sprintf("%s old, expires after %s", age, threshold))
+}
+
+// checkPeers checks name servers of clusters in other locations answer for the zone
+func (e *environment) checkPeers(ctx context.Context) (checks []check) {
+	for _, geoTag := range e.config.ExtClustersGeoTags {
+		c := check{name: "peer", subject: geoTag}
+		server, err := e.nameServer(ctx, geoTag)
+		if err != nil {
+			checks = append(checks, c.fail(err.Error()))
+			continue
+		}
+		m, rtt, err := e.query(ctx, server, e.config.DNSZone, dns.TypeSOA)
+		switch {
+		case err != nil:
+			checks = append(checks, c.fail(err.Error()))
+		case m.Rcode != dns.RcodeSuccess && m.Rcode != dns.RcodeNameError:
+			// the same condition marks cluster down in k8gb_peer_up metric
+			checks = append(checks, c.fail(fmt.Sprintf("%s answered %s", server, dns.RcodeToString[m.Rcode])))
+		default:
+			checks = append(checks, c.ok(fmt.Sprintf("%s answered in %s", server, rtt.Round(time.Millisecond))))
+		}
+	}
+	return checks
+}
+
+func (c check) ok(detail string) check {
+	c.result, c.detail = resultOK, detail
+	return c
+}
+
+func (c check) warn(detail string) check {
+	c.result, c.detail = resultWarn, detail
+	return c
+}
+
+func (c check) fail(detail string) check {
+	c.result, c.detail = resultFail, detail
+	return c
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	"github.com/miekg/dns"
+)
+
+// query sends question to DNS server in host:port format and returns the answer with its round-trip time
+func (e *environment) query(ctx context.Context, server, name string, qtype uint16) (*dns.Msg, time.Duration, error) {
+	if server == "" {
+		return nil, 0, errors.New("EdgeDNS server is not configured, set --edge-dns")
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	return (&dns.Client{Timeout: e.timeout}).ExchangeContext(ctx, m, server)
+}
+
+// lookup returns A and AAAA records of name at server. Empty answer and NXDOMAIN result in no addresses
+func (e *environment) lookup(ctx context.Context, server, name string) (addresses []string, err error) {
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		m, _, err := e.query(ctx, server, name, qtype)
+		if err != nil {
+			return nil, err
+		}
+		if m.Rcode != dns.RcodeSuccess && m.Rcode != dns.RcodeNameError {
+			return nil, fmt.Errorf("%s answered %s", server, dns.RcodeToString[m.Rcode])
+		}
+		for _, address := range recordValues(m.Answer, name) {
+			// CNAME is answered for both A and AAAA questions
+			if !contains(addresses, address) {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// nameServer returns address of name server of the cluster with geoTag in host:port format. Address is
+// resolved from glue record at EdgeDNS unless it is set by --peers
+func (e *environment) nameServer(ctx context.Context, geoTag string) (string, error) {
+	if address, found := e.peers[geoTag]; found {
+		return address, nil
+	}
+	name := gslbdns.NSServerName(geoTag, e.config)
+	addresses, err := e.lookup(ctx, e.edgeDNS, name)
+	if err != nil {
+		return "", err
+	}
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("no glue record of %s at EdgeDNS", name)
+	}
+	return net.JoinHostPort(addresses[0], "53"), nil
+}
+
+// recordValues returns values of A, AAAA, CNAME, NS and TXT records of name
+func recordValues(records []dns.RR, name string) (values []string) {
+	for _, rr := range records {
+		if !strings.EqualFold(rr.Header().Name, dns.Fqdn(name)) {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.A:
+			values = append(values, rr.A.String())
+		case *dns.AAAA:
+			values = append(values, rr.AAAA.String())
+		case *dns.CNAME:
+			values = append(values, strings.TrimSuffix(rr.Target, "."))
+		case *dns.NS:
+			values = append(values, strings.TrimSuffix(strings.ToLower(rr.Ns), "."))
+		case *dns.TXT:
+			values = append(values, strings.Join(rr.Txt, ""))
+		}
+	}
+	return values
+}
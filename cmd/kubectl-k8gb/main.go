@@ -0,0 +1,188 @@
+// kubectl-k8gb is kubectl plugin showing state of k8gb across the local cluster and clusters in other locations.
+// Installed to PATH, it runs as kubectl k8gb <command>
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+)
+
+const usage = `kubectl k8gb shows state of k8gb across the local cluster and clusters in other locations
+
+Usage:
+  kubectl k8gb status [gslb] [flags]     health and served targets of Gslb hosts in every cluster
+  kubectl k8gb diagnose [flags]          zone delegation, glue records, heartbeats and peer reachability
+  kubectl k8gb simulate <gslb> [flags]   DNS answers of every cluster in given health scenario
+
+Use kubectl k8gb <command> -h for flags of the command
+`
+
+// command runs subcommand with positional arguments in environment
+type command func(ctx context.Context, env *environment, args []string) error
+
+var commands = map[string]command{
+	"status":   status,
+	"diagnose": diagnose,
+	"simulate": simulate,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	run, found := commands[os.Args[1]]
+	if !found {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	var opts options
+	flags := flag.NewFlagSet("kubectl k8gb "+os.Args[1], flag.ExitOnError)
+	opts.bind(flags)
+	if os.Args[1] == "simulate" {
+		opts.scenario.bind(flags)
+	}
+	args := parseInterspersed(flags, os.Args[2:])
+
+	ctx := context.Background()
+	env, err := opts.environment(ctx, os.Stdout)
+	if err == nil {
+		err = run(ctx, env, args)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// options are flags of all commands
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	k8gbNamespace string
+	edgeDNS       string
+	peers         string
+	timeout       time.Duration
+	scenario      scenario
+}
+
+func (o *options) bind(flags *flag.FlagSet) {
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	flags.StringVar(&o.context, "context", "", "kubeconfig context of the local cluster")
+	flags.StringVar(&o.namespace, "n", "", "namespace of Gslbs; default is namespace of kubeconfig context")
+	flags.StringVar(&o.namespace, "namespace", "", "namespace of Gslbs; default is namespace of kubeconfig context")
+	flags.StringVar(&o.k8gbNamespace, "k8gb-namespace", "k8gb", "namespace of k8gb operator Deployment")
+	flags.StringVar(&o.edgeDNS, "edge-dns", "", "EdgeDNS server in host:port format; default is EDGE_DNS_SERVER of the operator on port 53")
+	flags.StringVar(&o.peers, "peers", "", "name servers of clusters in other locations instead of their glue records; e.g. eu=10.0.0.1:53,us=10.1.0.1:53")
+	flags.DurationVar(&o.timeout, "timeout", 5*time.Second, "timeout of every DNS query")
+}
+
+// parseInterspersed parses flags placed before as well as after positional arguments, which flag package
+// doesn't do on its own; e.g. simulate app --health eu=Unhealthy. Positional arguments are returned
+func parseInterspersed(flags *flag.FlagSet, arguments []string) (args []string) {
+	for {
+		// ExitOnError flag set exits on failure
+		_ = flags.Parse(arguments)
+		arguments = flags.Args()
+		if len(arguments) == 0 {
+			return args
+		}
+		args = append(args, arguments[0])
+		arguments = arguments[1:]
+	}
+}
+
+// environment is k8gb installation inspected by commands
+type environment struct {
+	client    client.Client
+	namespace string
+	config    *depresolver.Config
+	// heartbeats is true when the operator publishes heartbeat TXT records to EdgeDNS
+	heartbeats bool
+	// edgeDNS is EdgeDNS server in host:port format
+	edgeDNS string
+	// peers are name servers of clusters in other locations by geoTags, overriding their glue records
+	peers    map[string]string
+	timeout  time.Duration
+	scenario scenario
+	out      io.Writer
+}
+
+// environment connects to the local cluster and reads configuration of k8gb operator running there
+func (o *options) environment(ctx context.Context, out io.Writer) (*environment, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: o.context})
+	restConfig, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	namespace := o.namespace
+	if namespace == "" {
+		namespace, _, err = kubeConfig.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("loading kubeconfig: %w", err)
+		}
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: newScheme()})
+	if err != nil {
+		return nil, err
+	}
+	config, heartbeats, err := operatorConfig(ctx, c, o.k8gbNamespace)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := parseGeoTagValues(o.peers)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --peers: %w", err)
+	}
+	edgeDNS := o.edgeDNS
+	if edgeDNS == "" && config.EdgeDNSServer != "" {
+		edgeDNS = net.JoinHostPort(config.EdgeDNSServer, "53")
+	}
+	return &environment{
+		client:     c,
+		namespace:  namespace,
+		config:     config,
+		heartbeats: heartbeats,
+		edgeDNS:    edgeDNS,
+		peers:      peers,
+		timeout:    o.timeout,
+		scenario:   o.scenario,
+		out:        out,
+	}, nil
+}
+
+// newScheme returns scheme of Gslb, DNSEndpoint and built-in Kubernetes resources
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = k8gbv1beta1.AddToScheme(s)
+	schemeBuilder := &scheme.Builder{GroupVersion: schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}}
+	schemeBuilder.Register(&externaldns.DNSEndpoint{}, &externaldns.DNSEndpointList{})
+	_ = schemeBuilder.AddToScheme(s)
+	return s
+}
+
+// geoTags returns geoTag of the local cluster followed by geoTags of clusters in other locations
+func (e *environment) geoTags() []string {
+	return append([]string{e.config.ClusterGeoTag}, e.config.ExtClustersGeoTags...)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/fakedns"
+)
+
+const (
+	host         = "app.cloud.example.com"
+	localTargets = "localtargets-app.cloud.example.com"
+	euNameServer = "gslb-ns-cloud-example-com-eu.example.com"
+	usNameServer = "gslb-ns-cloud-example-com-us.example.com"
+)
+
+// testEnvironment is eu cluster with Gslb of failover strategy and us cluster in other location. Edge DNS and name
+// server of us cluster are fake DNS servers
+type testEnvironment struct {
+	*environment
+	edgeDNS *fakedns.Server
+	us      *fakedns.Server
+	out     *bytes.Buffer
+}
+
+func provideEnvironment(t *testing.T, objects ...runtime.Object) testEnvironment {
+	t.Helper()
+	edgeDNS, err := fakedns.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = edgeDNS.Close() })
+	us, err := fakedns.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = us.Close() })
+	gslb := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-gslb"},
+		Spec: k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{
+			Type:                       depresolver.FailoverStrategy,
+			PrimaryGeoTag:              "eu",
+			SplitBrainThresholdSeconds: 300,
+		}},
+		Status: k8gbv1beta1.GslbStatus{ServiceHealth: map[string]string{host: "Healthy"}},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-gslb"},
+		Spec: externaldns.DNSEndpointSpec{Endpoints: []*externaldns.Endpoint{
+			{DNSName: localTargets, RecordType: "A", Targets: externaldns.Targets{"10.0.0.1"}},
+			{DNSName: host, RecordType: "A", Targets: externaldns.Targets{"10.0.0.1"}},
+		}},
+	}
+	out := new(bytes.Buffer)
+	return testEnvironment{
+		environment: &environment{
+			client:    fake.NewFakeClientWithScheme(newScheme(), append([]runtime.Object{gslb, dnsEndpoint}, objects...)...),
+			namespace: "test-gslb",
+			config: &depresolver.Config{
+				ClusterGeoTag:      "eu",
+				ExtClustersGeoTags: []string{"us"},
+				EdgeDNSZone:        "example.com",
+				DNSZone:            "cloud.example.com",
+			},
+			heartbeats: true,
+			edgeDNS:    edgeDNS.Address(),
+			peers:      map[string]string{"us": us.Address()},
+			timeout:    time.Second,
+			out:        out,
+		},
+		edgeDNS: edgeDNS,
+		us:      us,
+		out:     out,
+	}
+}
+
+// healthyEdgeDNS publishes zone delegation, glue records and fresh heartbeats of both clusters
+func (e testEnvironment) healthyEdgeDNS(t *testing.T) {
+	t.Helper()
+	heartbeat := time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15:04:05")
+	require.NoError(t, e.edgeDNS.Set("cloud.example.com", dns.TypeNS, euNameServer+".", usNameServer+"."))
+	require.NoError(t, e.edgeDNS.Set(euNameServer, dns.TypeA, "10.0.0.1"))
+	require.NoError(t, e.edgeDNS.Set(usNameServer, dns.TypeA, "10.1.0.1"))
+	require.NoError(t, e.edgeDNS.Set("app-heartbeat-eu.example.com", dns.TypeTXT, heartbeat))
+	require.NoError(t, e.edgeDNS.Set("app-heartbeat-us.example.com", dns.TypeTXT, heartbeat))
+}
+
+// rows returns table rows of output split to columns, without header
+func (e testEnvironment) rows() (rows [][]string) {
+	lines := strings.Split(strings.TrimSpace(e.out.String()), "\n")
+	for _, line := range lines[1:] {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+func TestDiagnosePassesWithHealthyEdgeDNSAndPeers(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	env.healthyEdgeDNS(t)
+	// act
+	err := diagnose(context.TODO(), env.environment, nil)
+	// assert
+	require.NoError(t, err, env.out.String())
+	var checks []string
+	for _, row := range env.rows() {
+		assert.Equal(t, resultOK, row[2], row)
+		checks = append(checks, row[0]+" "+row[1])
+	}
+	assert.Equal(t, []string{
+		"delegation cloud.example.com",
+		"glue " + euNameServer,
+		"glue " + usNameServer,
+		"heartbeat app/eu",
+		"heartbeat app/us",
+		"peer us",
+	}, checks)
+}
+
+func TestDiagnoseFailsOnBrokenDelegationStaleHeartbeatAndUnreachablePeer(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	env.healthyEdgeDNS(t)
+	require.NoError(t, env.edgeDNS.Set("cloud.example.com", dns.TypeNS, euNameServer+"."))
+	env.edgeDNS.Remove(usNameServer, dns.TypeA)
+	require.NoError(t, env.edgeDNS.Set("app-heartbeat-us.example.com", dns.TypeTXT,
+		time.Now().UTC().Add(-10*time.Minute).Format("2006-01-02T15:04:05")))
+	env.us.SetFailure(dns.RcodeServerFailure)
+	// act
+	err := diagnose(context.TODO(), env.environment, nil)
+	// assert
+	require.EqualError(t, err, "4 of 6 checks failed")
+	results := make(map[string]string)
+	for _, row := range env.rows() {
+		results[row[0]+" "+row[1]] = row[2]
+	}
+	assert.Equal(t, resultFail, results["delegation cloud.example.com"])
+	assert.Equal(t, resultOK, results["glue "+euNameServer])
+	assert.Equal(t, resultFail, results["glue "+usNameServer])
+	assert.Equal(t, resultOK, results["heartbeat app/eu"])
+	assert.Equal(t, resultFail, results["heartbeat app/us"])
+	assert.Equal(t, resultFail, results["peer us"])
+}
+
+func TestDiagnoseSkipsHeartbeatsWithoutEdgeDNS(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	env.healthyEdgeDNS(t)
+	env.edgeDNS.Remove("app-heartbeat-us.example.com", dns.TypeTXT)
+	env.heartbeats = false
+	// act
+	err := diagnose(context.TODO(), env.environment, nil)
+	// assert
+	require.NoError(t, err, env.out.String())
+	assert.NotContains(t, env.out.String(), "app/us")
+}
+
+func TestStatusShowsHostInAllClusters(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	require.NoError(t, env.us.Set(localTargets, dns.TypeA, "10.1.0.1"))
+	require.NoError(t, env.us.Set(host, dns.TypeA, "10.0.0.1"))
+	// act
+	err := status(context.TODO(), env.environment, []string{"app"})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"app", host, "eu", "(local)", "Healthy", "10.0.0.1", "10.0.0.1"},
+		{"app", host, "us", "Healthy", "10.1.0.1", "10.0.0.1"},
+	}, env.rows())
+}
+
+func TestStatusShowsUnreachablePeer(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	env.us.SetFailure(dns.RcodeRefused)
+	// act
+	err := status(context.TODO(), env.environment, nil)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", host, "us", "Unreachable", "-", "-"}, env.rows()[1])
+}
+
+func TestSimulateFailoverOfPrimary(t *testing.T) {
+	// arrange
+	env := provideEnvironment(t)
+	require.NoError(t, env.us.Set(localTargets, dns.TypeA, "10.1.0.1"))
+	env.scenario = scenario{health: "eu=unhealthy"}
+	// act
+	err := simulate(context.TODO(), env.environment, []string{"app"})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{host, "eu", "Unhealthy", "10.1.0.1", "us"},
+		{host, "us", "Healthy", "10.1.0.1", "us"},
+	}, env.rows())
+}
+
+func TestSimulateRejectsInvalidScenario(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario scenario
+		err      string
+	}{
+		{"unknown geoTag", scenario{health: "za=Healthy"}, "--health sets unknown geoTag za, clusters are eu, us"},
+		{"invalid health", scenario{health: "eu=Down"}, "--health of eu must be one of Healthy, Unhealthy, NotFound"},
+		{"invalid format", scenario{targets: "eu"}, `parsing --targets: "eu" is not in geoTag=value format`},
+		{"invalid latency", scenario{latency: "us=fast"}, `--latency of us: time: invalid duration "fast"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			env := provideEnvironment(t)
+			env.scenario = test.scenario
+			// act
+			err := simulate(context.TODO(), env.environment, []string{"app"})
+			// assert
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestSimulatedAnswers(t *testing.T) {
+	eu := simulatedCluster{geoTag: "eu", health: "Healthy", targets: []string{"10.0.0.1"}}
+	us := simulatedCluster{geoTag: "us", health: "Healthy", targets: []string{"10.1.0.1"}}
+	za := simulatedCluster{geoTag: "za", health: "Healthy", targets: []string{"10.2.0.1"}}
+	unhealthy := func(c simulatedCluster) simulatedCluster {
+		c.health = "Unhealthy"
+		return c
+	}
+	tests := []struct {
+		name      string
+		strategy  k8gbv1beta1.Strategy
+		clusters  []simulatedCluster
+		latencies map[string]time.Duration
+		targets   [][]string
+		serving   [][]string
+	}{
+		{"round robin", k8gbv1beta1.Strategy{Type: depresolver.RoundRobinStrategy}, []simulatedCluster{eu, unhealthy(us), za}, nil,
+			[][]string{{"10.0.0.1", "10.2.0.1"}, {"10.0.0.1", "10.2.0.1"}, {"10.2.0.1", "10.0.0.1"}}, [][]string{{"eu", "za"}, {"eu", "za"}, {"za", "eu"}}},
+		{"failover to secondary", k8gbv1beta1.Strategy{Type: depresolver.FailoverStrategy, PrimaryGeoTag: "eu"}, []simulatedCluster{unhealthy(eu), us}, nil,
+			[][]string{{"10.1.0.1"}, {"10.1.0.1"}}, [][]string{{"us"}, {"us"}}},
+		{"healthy primary", k8gbv1beta1.Strategy{Type: depresolver.FailoverStrategy, PrimaryGeoTag: "eu"}, []simulatedCluster{eu, us}, nil,
+			[][]string{{"10.0.0.1"}, {"10.0.0.1"}}, [][]string{{"eu"}, {"eu"}}},
+		{"secondaries of healthy primary", k8gbv1beta1.Strategy{Type: depresolver.FailoverStrategy, PrimaryGeoTag: "eu"}, []simulatedCluster{eu, us, za}, nil,
+			[][]string{{"10.0.0.1"}, {"10.0.0.1", "10.2.0.1"}, {"10.0.0.1", "10.1.0.1"}}, [][]string{{"eu"}, {"eu", "za"}, {"eu", "us"}}},
+		{"latency to the closest", k8gbv1beta1.Strategy{Type: depresolver.LatencyStrategy}, []simulatedCluster{unhealthy(eu), us, za},
+			map[string]time.Duration{"us": 90 * time.Millisecond, "za": 20 * time.Millisecond},
+			[][]string{{"10.2.0.1"}, {"10.1.0.1"}, {"10.2.0.1"}}, [][]string{{"za"}, {"us"}, {"za"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// act
+			got := answers(test.strategy, test.clusters, test.latencies)
+			// assert
+			require.Len(t, got, len(test.clusters))
+			for i, answer := range got {
+				assert.Equal(t, test.targets[i], answer.Targets, test.clusters[i].geoTag)
+				assert.Equal(t, test.serving[i], answer.Serving, test.clusters[i].geoTag)
+			}
+		})
+	}
+}
+
+func TestOperatorConfigIsReadFromDeployment(t *testing.T) {
+	// arrange
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8gb", Namespace: "k8gb"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "k8gb",
+			Env: []corev1.EnvVar{
+				{Name: depresolver.ClusterGeoTagKey, Value: "eu"},
+				{Name: depresolver.ExtClustersGeoTagsKey, Value: "us, za"},
+				{Name: depresolver.EdgeDNSServerKey, Value: "1.1.1.1"},
+				{Name: depresolver.EdgeDNSZoneKey, Value: "example.com"},
+				{Name: depresolver.DNSZoneKey, Value: "cloud.example.com"},
+				{Name: depresolver.InfobloxGridHostKey, ValueFrom: &corev1.EnvVarSource{}},
+			},
+		}}}}},
+	}
+	c := fake.NewFakeClientWithScheme(newScheme(), deployment)
+	// act
+	config, heartbeats, err := operatorConfig(context.TODO(), c, "k8gb")
+	// assert
+	require.NoError(t, err)
+	assert.True(t, heartbeats)
+	assert.Equal(t, &depresolver.Config{
+		ClusterGeoTag:      "eu",
+		ExtClustersGeoTags: []string{"us", "za"},
+		EdgeDNSServer:      "1.1.1.1",
+		EdgeDNSZone:        "example.com",
+		DNSZone:            "cloud.example.com",
+	}, config)
+}
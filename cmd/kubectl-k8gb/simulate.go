@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+)
+
+// healthStates are health values of Gslb host reported in Gslb status
+var healthStates = []string{"Healthy", "Unhealthy", "NotFound"}
+
+// scenario changes health, targets and latency of clusters for simulate
+type scenario struct {
+	health  string
+	targets string
+	latency string
+}
+
+func (s *scenario) bind(flags *flag.FlagSet) {
+	flags.StringVar(&s.health, "health", "", "health of Gslb hosts by cluster geoTags; e.g. eu=Unhealthy,us=Healthy. Clusters not listed keep their current health")
+	flags.StringVar(&s.targets, "targets", "", "targets of clusters separated by semicolon instead of their current targets; e.g. 'eu=10.0.0.1;10.0.0.2,us=10.1.0.1'")
+	flags.StringVar(&s.latency, "latency", "", "round-trip times to clusters used by latency strategy; e.g. eu=20ms,us=90ms")
+}
+
+// simulatedCluster is state of Gslb host in one cluster
+type simulatedCluster struct {
+	geoTag  string
+	health  string
+	targets []string
+}
+
+// simulate prints DNS answers every cluster would serve for Gslb hosts in the scenario. Clusters start from their
+// current health and targets. Targets of cluster which is not Healthy now are unknown and shown as <geoTag>,
+// unless they are set by --targets
+func simulate(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 1 {
+		return errors.New("simulate requires Gslb name")
+	}
+	health, err := env.scenarioValues(env.scenario.health, "--health")
+	if err != nil {
+		return err
+	}
+	for geoTag, h := range health {
+		if health[geoTag] = healthState(h); health[geoTag] == "" {
+			return fmt.Errorf("--health of %s must be one of %s", geoTag, strings.Join(healthStates, ", "))
+		}
+	}
+	targets, err := env.scenarioValues(env.scenario.targets, "--targets")
+	if err != nil {
+		return err
+	}
+	latency, err := env.scenarioValues(env.scenario.latency, "--latency")
+	if err != nil {
+		return err
+	}
+	latencies := make(map[string]time.Duration, len(latency))
+	for geoTag, rtt := range latency {
+		latencies[geoTag], err = time.ParseDuration(rtt)
+		if err != nil {
+			return fmt.Errorf("--latency of %s: %w", geoTag, err)
+		}
+	}
+
+	gslbs, err := env.gslbs(ctx, args)
+	if err != nil {
+		return err
+	}
+	gslb := gslbs[0]
+	current, err := env.currentClusters(ctx, gslb)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "HOST\tCLUSTER\tHEALTH\tANSWER\tSERVING\n")
+	for _, host := range hosts(gslb) {
+		clusters := current[host]
+		for i := range clusters {
+			if h, found := health[clusters[i].geoTag]; found {
+				clusters[i].health = h
+			}
+			if t, found := targets[clusters[i].geoTag]; found {
+				clusters[i].targets = strings.Split(t, ";")
+			}
+			if len(clusters[i].targets) == 0 {
+				clusters[i].targets = []string{"<" + clusters[i].geoTag + ">"}
+			}
+		}
+		for i, answer := range answers(gslb.Spec.Strategy, clusters, latencies) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", host, clusters[i].geoTag, clusters[i].health, list(answer.Targets), list(answer.Serving))
+		}
+	}
+	return w.Flush()
+}
+
+// answers returns answer of every cluster for Gslb host. Each cluster is assumed to list the other clusters in
+// EXT_GSLB_CLUSTERS_GEO_TAGS in the order of clusters
+func answers(strategy k8gbv1beta1.Strategy, clusters []simulatedCluster, latencies map[string]time.Duration) []gslbdns.HostAnswer {
+	result := make([]gslbdns.HostAnswer, len(clusters))
+	for i, cluster := range clusters {
+		var extGeoTags []string
+		externalTargets := make(map[string][]string)
+		for j, other := range clusters {
+			if i == j {
+				continue
+			}
+			extGeoTags = append(extGeoTags, other.geoTag)
+			// clusters publish their targets only while the host is Healthy there
+			if other.health == "Healthy" {
+				externalTargets[other.geoTag] = other.targets
+			}
+		}
+		result[i] = gslbdns.AnswerHost(strategy, cluster.geoTag, extGeoTags, cluster.health, cluster.targets,
+			externalTargets, latencies)
+	}
+	return result
+}
+
+// currentClusters returns current health and targets of Gslb hosts in all clusters, the local cluster first.
+// Unreachable cluster is Unhealthy
+func (e *environment) currentClusters(ctx context.Context, gslb k8gbv1beta1.Gslb) (map[string][]simulatedCluster, error) {
+	records, err := e.localRecords(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	servers, serverErrs := e.nameServers(ctx)
+	clusters := make(map[string][]simulatedCluster)
+	for _, host := range hosts(gslb) {
+		clusters[host] = append(clusters[host], simulatedCluster{
+			geoTag:  e.config.ClusterGeoTag,
+			health:  gslb.Status.ServiceHealth[host],
+			targets: records[gslbdns.LocalTargetsName(host)],
+		})
+		for _, geoTag := range e.config.ExtClustersGeoTags {
+			cluster := simulatedCluster{geoTag: geoTag, health: "Unhealthy"}
+			if serverErrs[geoTag] == nil {
+				if peer, err := e.peerHost(ctx, servers[geoTag], host); err == nil {
+					cluster.health, cluster.targets = peer.health, peer.targets
+				}
+			}
+			clusters[host] = append(clusters[host], cluster)
+		}
+	}
+	return clusters, nil
+}
+
+// scenarioValues parses scenario flag and checks it sets only known geoTags
+func (e *environment) scenarioValues(s, name string) (map[string]string, error) {
+	values, err := parseGeoTagValues(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	for geoTag := range values {
+		if !contains(e.geoTags(), geoTag) {
+			return nil, fmt.Errorf("%s sets unknown geoTag %s, clusters are %s", name, geoTag, strings.Join(e.geoTags(), ", "))
+		}
+	}
+	return values, nil
+}
+
+// healthState returns health value case-insensitively matching h, or empty string when there is none
+func healthState(h string) string {
+	for _, state := range healthStates {
+		if strings.EqualFold(state, h) {
+			return state
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// status prints health and targets of Gslb hosts in the local cluster and in clusters in other locations, together
+// with the answer every cluster serves for the host. Clusters in other locations are queried at their name servers
+func status(ctx context.Context, env *environment, args []string) error {
+	if len(args) > 1 {
+		return errors.New("status accepts at most one Gslb name")
+	}
+	gslbs, err := env.gslbs(ctx, args)
+	if err != nil {
+		return err
+	}
+	servers, serverErrs := env.nameServers(ctx)
+	w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GSLB\tHOST\tCLUSTER\tHEALTH\tTARGETS\tANSWER")
+	for _, gslb := range gslbs {
+		records, err := env.localRecords(ctx, gslb)
+		if err != nil {
+			return err
+		}
+		for _, host := range hosts(gslb) {
+			fmt.Fprintf(w, "%s\t%s\t%s (local)\t%s\t%s\t%s\n", gslb.Name, host, env.config.ClusterGeoTag,
+				gslb.Status.ServiceHealth[host], list(records[gslbdns.LocalTargetsName(host)]), list(records[host]))
+			for _, geoTag := range env.config.ExtClustersGeoTags {
+				health, targets, answer := "Unreachable", "-", "-"
+				if serverErrs[geoTag] == nil {
+					peer, err := env.peerHost(ctx, servers[geoTag], host)
+					if err == nil {
+						health, targets, answer = peer.health, list(peer.targets), list(peer.answer)
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", gslb.Name, host, geoTag, health, targets, answer)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, geoTag := range env.config.ExtClustersGeoTags {
+		if serverErrs[geoTag] != nil {
+			fmt.Fprintf(env.out, "\n%s is unreachable: %v\n", geoTag, serverErrs[geoTag])
+		}
+	}
+	return nil
+}
+
+// peerHost is Gslb host as seen by the cluster in other location
+type peerHost struct {
+	health string
+	// targets are healthy targets of the cluster itself
+	targets []string
+	// answer are targets the cluster answers for the host
+	answer []string
+}
+
+// peerHost queries name server of the cluster in other location for host. The cluster publishes its own targets
+// only while the host is Healthy there
+func (e *environment) peerHost(ctx context.Context, server, host string) (peer peerHost, err error) {
+	peer.targets, err = e.lookup(ctx, server, gslbdns.LocalTargetsName(host))
+	if err != nil {
+		return peer, err
+	}
+	peer.answer, err = e.lookup(ctx, server, host)
+	if err != nil {
+		return peer, err
+	}
+	peer.health = "Unhealthy"
+	if len(peer.targets) > 0 {
+		peer.health = "Healthy"
+	}
+	return peer, nil
+}
+
+// nameServers returns addresses of name servers of clusters in other locations by geoTags, or errors of clusters
+// whose name server can't be resolved
+func (e *environment) nameServers(ctx context.Context) (servers map[string]string, errs map[string]error) {
+	servers = make(map[string]string)
+	errs = make(map[string]error)
+	for _, geoTag := range e.config.ExtClustersGeoTags {
+		servers[geoTag], errs[geoTag] = e.nameServer(ctx, geoTag)
+	}
+	return servers, errs
+}
+
+// gslbs returns Gslb named by args, or all Gslbs of the namespace when args are empty
+func (e *environment) gslbs(ctx context.Context, args []string) ([]k8gbv1beta1.Gslb, error) {
+	if len(args) > 0 {
+		gslb := k8gbv1beta1.Gslb{}
+		err := e.client.Get(ctx, types.NamespacedName{Namespace: e.namespace, Name: args[0]}, &gslb)
+		if err != nil {
+			return nil, err
+		}
+		return []k8gbv1beta1.Gslb{gslb}, nil
+	}
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := e.client.List(ctx, gslbList, client.InNamespace(e.namespace))
+	if err != nil {
+		return nil, err
+	}
+	if len(gslbList.Items) == 0 {
+		return nil, fmt.Errorf("no Gslb found in %s namespace", e.namespace)
+	}
+	return gslbList.Items, nil
+}
+
+// localRecords returns targets of records published by the local cluster for Gslb by record names. Gslb which
+// was not reconciled yet has no records
+func (e *environment) localRecords(ctx context.Context, gslb k8gbv1beta1.Gslb) (map[string][]string, error) {
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err := e.client.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, dnsEndpoint)
+	if apierrors.IsNotFound(err) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string][]string)
+	for _, endpoint := range dnsEndpoint.Spec.Endpoints {
+		switch endpoint.RecordType {
+		case "A", "AAAA", "CNAME":
+			records[endpoint.DNSName] = append(records[endpoint.DNSName], endpoint.Targets...)
+		}
+	}
+	return records, nil
+}
+
+// hosts returns sorted hosts of Gslb
+func hosts(gslb k8gbv1beta1.Gslb) []string {
+	hosts := make([]string, 0, len(gslb.Status.ServiceHealth))
+	for host := range gslb.Status.ServiceHealth {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// list formats values for table cell
+func list(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -39,16 +39,76 @@ type Infoblox struct {
 	Username string
 	// Password
 	Password string
+	// CredentialsSecret is name of Secret in k8gb namespace holding Username and Password; when set, the Secret
+	// takes precedence over Username and Password and is re-read on change
+	CredentialsSecret string
+	// SSLVerify verifies WAPI certificate; disabling it is explicit opt-in to insecure connection
+	SSLVerify bool
+	// CAFile is path to PEM bundle of CAs verifying WAPI certificate; system CAs are used when empty
+	CAFile string
+	// HTTPRequestTimeout in seconds
+	HTTPRequestTimeout int
+	// HTTPPoolConnections is maximum of idle connections kept to WAPI
+	HTTPPoolConnections int
 }
 
 // Override configuration
 type Override struct {
-	// FakeDNSEnabled; default=false
+	// FakeDNSEnabled sends edge DNS and external cluster queries to FakeDNSAddress; default=false
 	FakeDNSEnabled bool
+	// FakeDNSAddress of fake DNS server in host:port format; default=127.0.0.1:7753
+	FakeDNSAddress string
+	// FakeDNSPeers are addresses of fake DNS servers of external clusters by geoTags. Clusters not listed are
+	// queried at FakeDNSAddress
+	FakeDNSPeers map[string]string
 	// FakeInfobloxEnabled if true than Infoblox connection FQDN=`fakezone.example.com`; default = false
 	FakeInfobloxEnabled bool
 }
 
+// EmbeddedDNS configuration
+type EmbeddedDNS struct {
+	// Enabled serves DNSZone by authoritative DNS server running in operator; default = false
+	Enabled bool
+	// Address the server listens on, both UDP and TCP; default = :5353
+	Address string
+}
+
+// GeoIP configuration
+type GeoIP struct {
+	// Database path to MaxMind-format database file; e.g. GeoLite2-Country.mmdb. Embedded DNS answers
+	// geo strategy by client location when set
+	Database string
+	// Mapping of ISO country codes or continent codes to geoTags in order of preference
+	Mapping map[string][]string
+}
+
+// IngressPropagation configures which Gslb metadata is copied to the Gslb Ingress
+type IngressPropagation struct {
+	// Annotations keys allowed to propagate; entry ending with * matches key prefix, e.g. nginx.ingress.kubernetes.io/*.
+	// All annotations propagate by default
+	Annotations []string
+	// Labels keys allowed to propagate, in the same format as Annotations
+	Labels []string
+}
+
+// Tracing configuration
+type Tracing struct {
+	// Enabled exports OpenTelemetry spans of reconciliation to OTLP collector; default = false
+	Enabled bool
+	// Endpoint of OTLP gRPC collector in host:port format; default = localhost:4317
+	Endpoint string
+	// SamplingPercent of reconciliations which are traced; default = 100
+	SamplingPercent int
+}
+
+// Log configuration
+type Log struct {
+	// Level is one of error, info or debug; debug logs details of every DNS record and peer answer; default = info
+	Level string
+	// Format is json or console; default = json
+	Format string
+}
+
 // Config is operator configuration returned by depResolver
 type Config struct {
 	// Reschedule of Reconcile loop to pickup external Gslb targets
@@ -77,6 +137,19 @@ type Config struct {
 	ns1Enabled bool
 	// CoreDNSExposed flag
 	CoreDNSExposed bool
+	// EmbeddedDNS configuration
+	EmbeddedDNS EmbeddedDNS
+	// GeoIP configuration
+	GeoIP GeoIP
+	// LatencyProbeTargets addresses in host:port format by ExtClustersGeoTags. Latency strategy measures TCP connect
+	// time to the address instead of round-trip time of DNS query to gslb-ns server of the cluster
+	LatencyProbeTargets map[string]string
+	// IngressPropagation allowlist of Gslb annotations and labels copied to the Gslb Ingress
+	IngressPropagation IngressPropagation
+	// Tracing configuration
+	Tracing Tracing
+	// Log configuration
+	Log Log
 }
 
 // DependencyResolver resolves configuration for GSLB
@@ -2,6 +2,7 @@ package depresolver
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AbsaOSS/gopkg/env"
 )
@@ -21,11 +22,30 @@ const (
 	InfobloxPortKey            = "INFOBLOX_WAPI_PORT"
 	InfobloxUsernameKey        = "EXTERNAL_DNS_INFOBLOX_WAPI_USERNAME"
 	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
-	InfobloxPasswordKey     = "EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD"
-	OverrideWithFakeDNSKey  = "OVERRIDE_WITH_FAKE_EXT_DNS"
-	OverrideFakeInfobloxKey = "FAKE_INFOBLOX"
-	K8gbNamespaceKey        = "POD_NAMESPACE"
-	CoreDNSExposedKey       = "COREDNS_EXPOSED"
+	InfobloxPasswordKey      = "EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD"
+	OverrideWithFakeDNSKey   = "OVERRIDE_WITH_FAKE_EXT_DNS"
+	OverrideFakeInfobloxKey  = "FAKE_INFOBLOX"
+	K8gbNamespaceKey         = "POD_NAMESPACE"
+	CoreDNSExposedKey        = "COREDNS_EXPOSED"
+	EmbeddedDNSEnabledKey    = "EMBEDDED_DNS_ENABLED"
+	EmbeddedDNSAddressKey    = "EMBEDDED_DNS_ADDRESS"
+	GeoIPDatabaseKey         = "GEOIP_DATABASE"
+	GeoIPMappingKey          = "GEOIP_MAPPING"
+	LatencyProbeTargetsKey   = "LATENCY_PROBE_TARGETS"
+	InfobloxSecretKey        = "INFOBLOX_CREDENTIALS_SECRET"
+	InfobloxSSLVerifyKey     = "INFOBLOX_SSL_VERIFY"
+	InfobloxCAFileKey        = "INFOBLOX_CA_FILE"
+	InfobloxHTTPTimeoutKey   = "INFOBLOX_HTTP_REQUEST_TIMEOUT"
+	InfobloxHTTPPoolKey      = "INFOBLOX_HTTP_POOL_CONNECTIONS"
+	FakeDNSAddressKey        = "FAKE_DNS_ADDRESS"
+	FakeDNSPeersKey          = "FAKE_DNS_PEERS"
+	PropagatedAnnotationsKey = "INGRESS_PROPAGATED_ANNOTATIONS"
+	PropagatedLabelsKey      = "INGRESS_PROPAGATED_LABELS"
+	TracingEnabledKey        = "TRACING_ENABLED"
+	TracingEndpointKey       = "TRACING_OTLP_ENDPOINT"
+	TracingSamplingKey       = "TRACING_SAMPLING_PERCENT"
+	LogLevelKey              = "LOG_LEVEL"
+	LogFormatKey             = "LOG_FORMAT"
 )
 
 // ResolveOperatorConfig executes once. It reads operator's configuration
@@ -48,8 +68,27 @@ func (dr *DependencyResolver) ResolveOperatorConfig() (*Config, error) {
 		dr.config.Infoblox.Port, _ = env.GetEnvAsIntOrFallback(InfobloxPortKey, 0)
 		dr.config.Infoblox.Username = env.GetEnvAsStringOrFallback(InfobloxUsernameKey, "")
 		dr.config.Infoblox.Password = env.GetEnvAsStringOrFallback(InfobloxPasswordKey, "")
+		dr.config.Infoblox.CredentialsSecret = env.GetEnvAsStringOrFallback(InfobloxSecretKey, "")
+		dr.config.Infoblox.SSLVerify = env.GetEnvAsBoolOrFallback(InfobloxSSLVerifyKey, true)
+		dr.config.Infoblox.CAFile = env.GetEnvAsStringOrFallback(InfobloxCAFileKey, "")
+		dr.config.Infoblox.HTTPRequestTimeout, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPTimeoutKey, 20)
+		dr.config.Infoblox.HTTPPoolConnections, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPPoolKey, 10)
 		dr.config.Override.FakeDNSEnabled = env.GetEnvAsBoolOrFallback(OverrideWithFakeDNSKey, false)
+		dr.config.Override.FakeDNSAddress = env.GetEnvAsStringOrFallback(FakeDNSAddressKey, "127.0.0.1:7753")
+		dr.config.Override.FakeDNSPeers = parseGeoTagAddresses(env.GetEnvAsArrayOfStringsOrFallback(FakeDNSPeersKey, []string{}))
 		dr.config.Override.FakeInfobloxEnabled = env.GetEnvAsBoolOrFallback(OverrideFakeInfobloxKey, false)
+		dr.config.EmbeddedDNS.Enabled = env.GetEnvAsBoolOrFallback(EmbeddedDNSEnabledKey, false)
+		dr.config.EmbeddedDNS.Address = env.GetEnvAsStringOrFallback(EmbeddedDNSAddressKey, ":5353")
+		dr.config.GeoIP.Database = env.GetEnvAsStringOrFallback(GeoIPDatabaseKey, "")
+		dr.config.GeoIP.Mapping = parseGeoIPMapping(env.GetEnvAsArrayOfStringsOrFallback(GeoIPMappingKey, []string{}))
+		dr.config.LatencyProbeTargets = parseGeoTagAddresses(env.GetEnvAsArrayOfStringsOrFallback(LatencyProbeTargetsKey, []string{}))
+		dr.config.IngressPropagation.Annotations = env.GetEnvAsArrayOfStringsOrFallback(PropagatedAnnotationsKey, []string{"*"})
+		dr.config.IngressPropagation.Labels = env.GetEnvAsArrayOfStringsOrFallback(PropagatedLabelsKey, []string{})
+		dr.config.Tracing.Enabled = env.GetEnvAsBoolOrFallback(TracingEnabledKey, false)
+		dr.config.Tracing.Endpoint = env.GetEnvAsStringOrFallback(TracingEndpointKey, "localhost:4317")
+		dr.config.Tracing.SamplingPercent, _ = env.GetEnvAsIntOrFallback(TracingSamplingKey, 100)
+		dr.config.Log.Level = env.GetEnvAsStringOrFallback(LogLevelKey, "info")
+		dr.config.Log.Format = env.GetEnvAsStringOrFallback(LogFormatKey, "json")
 		dr.errorConfig = dr.validateConfig(dr.config)
 		dr.config.EdgeDNSType = getEdgeDNSType(dr.config)
 	})
@@ -91,6 +130,74 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 	if err != nil {
 		return err
 	}
+	if config.EmbeddedDNS.Enabled {
+		err = field("EmbeddedDNSAddress", config.EmbeddedDNS.Address).isNotEmpty().matchRegexp(listenAddressRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	err = field("logLevel", config.Log.Level).isOneOf("error", "info", "debug").err
+	if err != nil {
+		return err
+	}
+	err = field("logFormat", config.Log.Format).isOneOf("json", "console").err
+	if err != nil {
+		return err
+	}
+	if config.Tracing.Enabled {
+		err = field("tracingEndpoint", config.Tracing.Endpoint).isNotEmpty().matchRegexp(hostPortRegex).err
+		if err != nil {
+			return err
+		}
+		err = field("tracingSamplingPercent", config.Tracing.SamplingPercent).isHigherOrEqualToZero().isLessOrEqualTo(100).err
+		if err != nil {
+			return err
+		}
+	}
+	for geoTag, address := range config.LatencyProbeTargets {
+		err = field("latencyProbeTargets", geoTag).isNotEmpty().isOneOf(config.ExtClustersGeoTags...).err
+		if err != nil {
+			return err
+		}
+		err = field(fmt.Sprintf("latencyProbeTargets[%s]", geoTag), address).isNotEmpty().matchRegexp(hostPortRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	for i, key := range config.IngressPropagation.Annotations {
+		err = field(fmt.Sprintf("ingressPropagatedAnnotations[%v]", i), key).isNotEmpty().matchRegexp(metadataKeyRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	for i, key := range config.IngressPropagation.Labels {
+		err = field(fmt.Sprintf("ingressPropagatedLabels[%v]", i), key).isNotEmpty().matchRegexp(metadataKeyRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	if config.Override.FakeDNSEnabled {
+		err = field("fakeDNSAddress", config.Override.FakeDNSAddress).isNotEmpty().matchRegexp(hostPortRegex).err
+		if err != nil {
+			return err
+		}
+		for geoTag, address := range config.Override.FakeDNSPeers {
+			err = field("fakeDNSPeers", geoTag).isNotEmpty().isOneOf(config.ExtClustersGeoTags...).err
+			if err != nil {
+				return err
+			}
+			err = field(fmt.Sprintf("fakeDNSPeers[%s]", geoTag), address).isNotEmpty().matchRegexp(hostPortRegex).err
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if isNotEmpty(config.GeoIP.Database) {
+		err = validateGeoIPMapping(config)
+		if err != nil {
+			return err
+		}
+	}
 	// do full Infoblox validation only in case that Host exists
 	if isNotEmpty(config.Infoblox.Host) {
 		err = field("InfobloxGridHost", config.Infoblox.Host).matchRegexps(hostNameRegex, ipAddressRegex).err
@@ -105,6 +212,21 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 		if err != nil {
 			return err
 		}
+		err = field("InfobloxHTTPRequestTimeout", config.Infoblox.HTTPRequestTimeout).isHigherThanZero().err
+		if err != nil {
+			return err
+		}
+		err = field("InfobloxHTTPPoolConnections", config.Infoblox.HTTPPoolConnections).isHigherThanZero().err
+		if err != nil {
+			return err
+		}
+		if isNotEmpty(config.Infoblox.CAFile) && !config.Infoblox.SSLVerify {
+			return fmt.Errorf("InfobloxCAFile can't be used when InfobloxSSLVerify is disabled")
+		}
+		// credentials are read from the Secret at runtime
+		if isNotEmpty(config.Infoblox.CredentialsSecret) {
+			return field("InfobloxCredentialsSecret", config.Infoblox.CredentialsSecret).matchRegexp(k8sNameRegex).err
+		}
 		err = field("InfobloxUsername", config.Infoblox.Username).isNotEmpty().err
 		if err != nil {
 			return err
@@ -117,6 +239,71 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 	return nil
 }
 
+// validateGeoIPMapping checks that locations are mapped to known geoTags only
+func validateGeoIPMapping(config *Config) (err error) {
+	locations := make([]string, 0, len(config.GeoIP.Mapping))
+	for location := range config.GeoIP.Mapping {
+		locations = append(locations, location)
+	}
+	err = field("geoIPMapping", locations).hasItems().err
+	if err != nil {
+		return err
+	}
+	geoTags := append([]string{config.ClusterGeoTag}, config.ExtClustersGeoTags...)
+	for location, preferred := range config.GeoIP.Mapping {
+		err = field("geoIPMapping", location).isNotEmpty().matchRegexp(locationCodeRegex).err
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("geoIPMapping[%s]", location)
+		err = field(name, preferred).hasItems().hasUniqueItems().err
+		if err != nil {
+			return err
+		}
+		for i, geoTag := range preferred {
+			err = field(fmt.Sprintf("%s[%v]", name, i), geoTag).isOneOf(geoTags...).err
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseGeoIPMapping parses items in format location:geoTag;geoTag; e.g. GB:uk;eu. Location without geoTags is kept,
+// so validation can report it
+func parseGeoIPMapping(items []string) map[string][]string {
+	mapping := make(map[string][]string, len(items))
+	for _, item := range items {
+		kv := strings.SplitN(item, ":", 2)
+		location := strings.ToUpper(strings.TrimSpace(kv[0]))
+		mapping[location] = nil
+		if len(kv) == 1 {
+			continue
+		}
+		for _, geoTag := range strings.Split(kv[1], ";") {
+			if geoTag = strings.TrimSpace(geoTag); geoTag != "" {
+				mapping[location] = append(mapping[location], geoTag)
+			}
+		}
+	}
+	return mapping
+}
+
+// parseGeoTagAddresses parses items in format geoTag=host:port; e.g. eu=10.1.0.1:443
+func parseGeoTagAddresses(items []string) map[string]string {
+	targets := make(map[string]string, len(items))
+	for _, item := range items {
+		kv := strings.SplitN(item, "=", 2)
+		geoTag := strings.TrimSpace(kv[0])
+		targets[geoTag] = ""
+		if len(kv) == 2 {
+			targets[geoTag] = strings.TrimSpace(kv[1])
+		}
+	}
+	return targets
+}
+
 // getEdgeDNSType contains logic retrieving EdgeDNSType
 func getEdgeDNSType(config *Config) EdgeDNSType {
 	var t = DNSTypeNoEdgeDNS
@@ -2,13 +2,40 @@ package depresolver
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 )
 
+const (
+	// HostnameTargetsResolve publishes IP addresses of load balancer hostnames, resolved on every reconciliation
+	HostnameTargetsResolve = "resolve"
+	// HostnameTargetsCNAME publishes load balancer hostname as CNAME target
+	HostnameTargetsCNAME = "cname"
+)
+
+// Gslb strategy types
+const (
+	RoundRobinStrategy = "roundRobin"
+	FailoverStrategy   = "failover"
+	GeoStrategy        = "geo"
+	LatencyStrategy    = "latency"
+)
+
+// Ingress annotations configuring strategy of Gslb generated from the Ingress
+const (
+	StrategyAnnotation                   = "k8gb.io/strategy"
+	PrimaryGeoTagAnnotation              = "k8gb.io/primary-geotag"
+	DNSTTLSecondsAnnotation              = "k8gb.io/dns-ttl-seconds"
+	SplitBrainThresholdSecondsAnnotation = "k8gb.io/splitbrain-threshold-seconds"
+	HostnameTargetsAnnotation            = "k8gb.io/hostname-targets"
+)
+
 var predefinedStrategy = k8gbv1beta1.Strategy{
 	DNSTtlSeconds:              30,
 	SplitBrainThresholdSeconds: 300,
+	HostnameTargets:            HostnameTargetsResolve,
 }
 
 // ResolveGslbSpec executes once during reconciliation. At first cycle it reads
@@ -24,6 +51,9 @@ func (dr *DependencyResolver) ResolveGslbSpec(ctx context.Context, gslb *k8gbv1b
 		if strategy.SplitBrainThresholdSeconds == 0 {
 			strategy.SplitBrainThresholdSeconds = predefinedStrategy.SplitBrainThresholdSeconds
 		}
+		if strategy.HostnameTargets == "" {
+			strategy.HostnameTargets = predefinedStrategy.HostnameTargets
+		}
 		dr.errorSpec = dr.validateSpec(strategy)
 		if dr.errorSpec == nil {
 			dr.errorSpec = dr.client.Update(ctx, gslb)
@@ -41,5 +71,73 @@ func (dr *DependencyResolver) validateSpec(strategy *k8gbv1beta1.Strategy) (err
 	if err != nil {
 		return
 	}
+	err = field("HostnameTargets", strategy.HostnameTargets).isOneOf(HostnameTargetsResolve, HostnameTargetsCNAME).err
+	if err != nil {
+		return
+	}
 	return
 }
+
+// ValidateStrategy returns error when Gslb strategy can't be served with the operator configuration. Regional
+// endpoints of geo strategy are answered only by embedded DNS server, without it geo would silently behave
+// as roundRobin
+func ValidateStrategy(strategy k8gbv1beta1.Strategy, config *Config) error {
+	if strategy.Type == GeoStrategy && !config.EmbeddedDNS.Enabled {
+		return fmt.Errorf("strategy %s requires embedded DNS server, set %s=true", GeoStrategy, EmbeddedDNSEnabledKey)
+	}
+	return nil
+}
+
+// ResolveStrategyAnnotations returns strategy configured by Ingress annotations. Annotations which are not set
+// get predefined values, the same as omitted Gslb spec properties. Returns error if any annotation is invalid
+func ResolveStrategyAnnotations(annotations map[string]string) (strategy k8gbv1beta1.Strategy, err error) {
+	strategy = predefinedStrategy
+	strategy.Type = annotations[StrategyAnnotation]
+	err = field(StrategyAnnotation, strategy.Type).isOneOf(RoundRobinStrategy, FailoverStrategy, GeoStrategy, LatencyStrategy).err
+	if err != nil {
+		return
+	}
+	if strategy.Type == FailoverStrategy {
+		strategy.PrimaryGeoTag = annotations[PrimaryGeoTagAnnotation]
+		err = field(PrimaryGeoTagAnnotation, strategy.PrimaryGeoTag).isNotEmpty().matchRegexp(geoTagRegex).err
+		if err != nil {
+			return
+		}
+	}
+	if value, found := annotations[DNSTTLSecondsAnnotation]; found {
+		strategy.DNSTtlSeconds, err = parseIntAnnotation(DNSTTLSecondsAnnotation, value)
+		if err != nil {
+			return
+		}
+		err = field(DNSTTLSecondsAnnotation, strategy.DNSTtlSeconds).isHigherThanZero().err
+		if err != nil {
+			return
+		}
+	}
+	if value, found := annotations[SplitBrainThresholdSecondsAnnotation]; found {
+		strategy.SplitBrainThresholdSeconds, err = parseIntAnnotation(SplitBrainThresholdSecondsAnnotation, value)
+		if err != nil {
+			return
+		}
+		err = field(SplitBrainThresholdSecondsAnnotation, strategy.SplitBrainThresholdSeconds).isHigherThanZero().err
+		if err != nil {
+			return
+		}
+	}
+	if value, found := annotations[HostnameTargetsAnnotation]; found {
+		strategy.HostnameTargets = value
+		err = field(HostnameTargetsAnnotation, strategy.HostnameTargets).isOneOf(HostnameTargetsResolve, HostnameTargetsCNAME).err
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func parseIntAnnotation(name, value string) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf(`"%s" must be a number, got "%s"`, name, value)
+	}
+	return i, nil
+}
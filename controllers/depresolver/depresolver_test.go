@@ -35,11 +35,40 @@ var predefinedConfig = Config{
 		443,
 		"Infoblox",
 		"secret",
+		"",
+		true,
+		"",
+		20,
+		10,
 	},
 	Override: Override{
 		false,
+		"127.0.0.1:7753",
+		map[string]string{},
 		false,
 	},
+	EmbeddedDNS: EmbeddedDNS{
+		false,
+		":5353",
+	},
+	GeoIP: GeoIP{
+		"",
+		map[string][]string{},
+	},
+	LatencyProbeTargets: map[string]string{},
+	IngressPropagation: IngressPropagation{
+		[]string{"*"},
+		[]string{},
+	},
+	Tracing: Tracing{
+		false,
+		"localhost:4317",
+		100,
+	},
+	Log: Log{
+		"info",
+		"json",
+	},
 }
 
 func TestResolveSpecWithFilledFields(t *testing.T) {
@@ -52,6 +81,7 @@ func TestResolveSpecWithFilledFields(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 35, gslb.Spec.Strategy.DNSTtlSeconds)
 	assert.Equal(t, 305, gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+	assert.Equal(t, HostnameTargetsCNAME, gslb.Spec.Strategy.HostnameTargets)
 }
 
 func TestResolveSpecWithoutFields(t *testing.T) {
@@ -64,6 +94,7 @@ func TestResolveSpecWithoutFields(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, predefinedStrategy.DNSTtlSeconds, gslb.Spec.Strategy.DNSTtlSeconds)
 	assert.Equal(t, predefinedStrategy.SplitBrainThresholdSeconds, gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+	assert.Equal(t, predefinedStrategy.HostnameTargets, gslb.Spec.Strategy.HostnameTargets)
 }
 
 func TestResolveSpecWithZeroSplitBrain(t *testing.T) {
@@ -100,6 +131,69 @@ func TestResolveSpecWithNegativeFields(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestResolveSpecWithInvalidHostnameTargets(t *testing.T) {
+	// arrange
+	cl, gslb := getTestContext("./testdata/invalid_hostname_targets.yaml")
+	resolver := NewDependencyResolver(cl)
+	// act
+	err := resolver.ResolveGslbSpec(context.TODO(), gslb)
+	// assert
+	assert.Error(t, err)
+}
+
+func TestResolveStrategyAnnotationsWithoutOptionalAnnotations(t *testing.T) {
+	// arrange
+	annotations := map[string]string{StrategyAnnotation: RoundRobinStrategy}
+	// act
+	strategy, err := ResolveStrategyAnnotations(annotations)
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, k8gbv1beta1.Strategy{Type: RoundRobinStrategy, DNSTtlSeconds: predefinedStrategy.DNSTtlSeconds,
+		SplitBrainThresholdSeconds: predefinedStrategy.SplitBrainThresholdSeconds,
+		HostnameTargets:            predefinedStrategy.HostnameTargets}, strategy)
+}
+
+func TestResolveStrategyAnnotationsWithAllAnnotations(t *testing.T) {
+	// arrange
+	annotations := map[string]string{
+		StrategyAnnotation:                   FailoverStrategy,
+		PrimaryGeoTagAnnotation:              "eu",
+		DNSTTLSecondsAnnotation:              "60",
+		SplitBrainThresholdSecondsAnnotation: "600",
+		HostnameTargetsAnnotation:            HostnameTargetsCNAME,
+	}
+	// act
+	strategy, err := ResolveStrategyAnnotations(annotations)
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, k8gbv1beta1.Strategy{Type: FailoverStrategy, PrimaryGeoTag: "eu", DNSTtlSeconds: 60,
+		SplitBrainThresholdSeconds: 600, HostnameTargets: HostnameTargetsCNAME}, strategy)
+}
+
+func TestResolveStrategyAnnotationsWithInvalidAnnotations(t *testing.T) {
+	var tests = []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{"missing strategy", map[string]string{}},
+		{"unknown strategy", map[string]string{StrategyAnnotation: "random"}},
+		{"failover without primary geoTag", map[string]string{StrategyAnnotation: FailoverStrategy}},
+		{"invalid primary geoTag", map[string]string{StrategyAnnotation: FailoverStrategy, PrimaryGeoTagAnnotation: "e u"}},
+		{"ttl is not a number", map[string]string{StrategyAnnotation: GeoStrategy, DNSTTLSecondsAnnotation: "30s"}},
+		{"zero ttl", map[string]string{StrategyAnnotation: GeoStrategy, DNSTTLSecondsAnnotation: "0"}},
+		{"negative splitbrain threshold", map[string]string{StrategyAnnotation: GeoStrategy, SplitBrainThresholdSecondsAnnotation: "-1"}},
+		{"unknown hostname targets", map[string]string{StrategyAnnotation: GeoStrategy, HostnameTargetsAnnotation: "alias"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// act
+			_, err := ResolveStrategyAnnotations(test.annotations)
+			// assert
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestSpecRunOnce(t *testing.T) {
 	// arrange
 	cl, gslb := getTestContext("./testdata/filled_omitempty.yaml")
@@ -132,6 +226,20 @@ func TestResolveConfigWithoutEnvVarsSet(t *testing.T) {
 	defaultConfig.ReconcileRequeueSeconds = 30
 	defaultConfig.EdgeDNSType = DNSTypeNoEdgeDNS
 	defaultConfig.ExtClustersGeoTags = []string{}
+	defaultConfig.EmbeddedDNS.Address = ":5353"
+	defaultConfig.GeoIP.Mapping = map[string][]string{}
+	defaultConfig.LatencyProbeTargets = map[string]string{}
+	defaultConfig.IngressPropagation.Annotations = []string{"*"}
+	defaultConfig.IngressPropagation.Labels = []string{}
+	defaultConfig.Tracing.Endpoint = "localhost:4317"
+	defaultConfig.Tracing.SamplingPercent = 100
+	defaultConfig.Log.Level = "info"
+	defaultConfig.Log.Format = "json"
+	defaultConfig.Infoblox.SSLVerify = true
+	defaultConfig.Override.FakeDNSAddress = "127.0.0.1:7753"
+	defaultConfig.Override.FakeDNSPeers = map[string]string{}
+	defaultConfig.Infoblox.HTTPRequestTimeout = 20
+	defaultConfig.Infoblox.HTTPPoolConnections = 10
 	cl, _ := getTestContext("./testdata/filled_omitempty.yaml")
 	resolver := NewDependencyResolver(cl)
 	// act
@@ -697,6 +805,102 @@ func TestInfobloxGridHostIsNotEmptyButInfobloxPropsAreEmpty(t *testing.T) {
 	arrangeVariablesAndAssert(t, expected, assert.Error)
 }
 
+func TestInfobloxCredentialsSecretReplacesUsernameAndPassword(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	expected.Infoblox.Port = 443
+	expected.Infoblox.Username = ""
+	expected.Infoblox.Password = ""
+	expected.Infoblox.CredentialsSecret = "infoblox"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestInfobloxCredentialsSecretIsInvalid(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	expected.Infoblox.Port = 443
+	for _, secret := range []string{"Infoblox", "infoblox/creds", "-infoblox", "infoblox.", "info_blox"} {
+		expected.Infoblox.CredentialsSecret = secret
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
+func TestInfobloxTransportDefaults(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	// act,assert
+	// TLS verification is on unless disabled explicitly
+	arrangeVariablesAndAssert(t, expected, assert.NoError, InfobloxSSLVerifyKey, InfobloxCAFileKey, InfobloxHTTPTimeoutKey, InfobloxHTTPPoolKey)
+}
+
+func TestInfobloxInsecureOptIn(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	expected.Infoblox.SSLVerify = false
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestInfobloxCAFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	expected.Infoblox.CAFile = "/infoblox/ca.crt"
+	expected.Infoblox.HTTPRequestTimeout = 5
+	expected.Infoblox.HTTPPoolConnections = 2
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestInfobloxCAFileWithInsecureConnection(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	expected.Infoblox.CAFile = "/infoblox/ca.crt"
+	expected.Infoblox.SSLVerify = false
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestInfobloxInvalidHTTPSettings(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeInfoblox
+	expected.Infoblox.Host = "test.domain"
+	expected.Infoblox.Version = "0.0.1"
+	for _, settings := range [][]int{{0, 10}, {-1, 10}, {20, 0}, {20, -5}} {
+		expected.Infoblox.HTTPRequestTimeout = settings[0]
+		expected.Infoblox.HTTPPoolConnections = settings[1]
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
 func TestInfobloxGridHostIsEmptyButInfobloxPropsAreFilled(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -929,6 +1133,60 @@ func TestResolveConfigEnableFakeDNSAsUnsetEnvironmentVariable(t *testing.T) {
 	arrangeVariablesAndAssert(t, predefinedConfig, assert.NoError, OverrideWithFakeDNSKey)
 }
 
+func TestResolveConfigFakeDNSServers(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Override.FakeDNSEnabled = true
+	expected.Override.FakeDNSAddress = "127.0.0.1:5300"
+	expected.Override.FakeDNSPeers = map[string]string{"uk": "127.0.0.1:5301", "eu": "localhost:5302"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigFakeDNSAddressDefault(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Override.FakeDNSEnabled = true
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, FakeDNSAddressKey)
+}
+
+func TestResolveConfigInvalidFakeDNSServers(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Override.FakeDNSEnabled = true
+	var tests = []struct {
+		address string
+		peers   map[string]string
+	}{
+		{"127.0.0.1", map[string]string{}},
+		{"127.0.0.1:0", map[string]string{}},
+		{"127.0.0.1:5300", map[string]string{"us": "127.0.0.1:5301"}},
+		{"127.0.0.1:5300", map[string]string{"eu": ""}},
+		{"127.0.0.1:5300", map[string]string{"eu": "127.0.0.1"}},
+	}
+	for _, test := range tests {
+		expected.Override.FakeDNSAddress = test.address
+		expected.Override.FakeDNSPeers = test.peers
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
+func TestResolveConfigFakeDNSServersAreIgnoredWhenDisabled(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Override.FakeDNSEnabled = false
+	expected.Override.FakeDNSAddress = "invalid"
+	expected.Override.FakeDNSPeers = map[string]string{"us": "invalid"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
 func TestResolveConfigEnableFakeInfobloxAsTrue(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -969,6 +1227,251 @@ func TestResolveConfigEnableFakeInfobloxAsUnsetEnvironmentVariable(t *testing.T)
 	arrangeVariablesAndAssert(t, predefinedConfig, assert.NoError, OverrideFakeInfobloxKey)
 }
 
+func TestResolveConfigWithEmbeddedDNSEnabled(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EmbeddedDNS.Enabled = true
+	expected.EmbeddedDNS.Address = "0.0.0.0:53"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithEmbeddedDNSDefaultAddress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EmbeddedDNS.Enabled = true
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, EmbeddedDNSAddressKey)
+}
+
+func TestResolveConfigWithEmbeddedDNSInvalidAddress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EmbeddedDNS.Enabled = true
+	expected.EmbeddedDNS.Address = "5353"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithEmbeddedDNSEmptyAddress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EmbeddedDNS.Enabled = true
+	configureEnvVar(expected)
+	_ = os.Setenv(EmbeddedDNSAddressKey, "")
+	cl, _ := getTestContext("./testdata/filled_omitempty.yaml")
+	resolver := NewDependencyResolver(cl)
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, ":5353", config.EmbeddedDNS.Address)
+}
+
+func TestResolveConfigWithGeoIP(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GeoIP.Database = "/geoip/GeoLite2-Country.mmdb"
+	expected.GeoIP.Mapping = map[string][]string{"GB": {"uk", "eu"}, "EU": {"eu", "uk"}, "NA": {"us"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithGeoIPMappingInLowerCase(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GeoIP.Database = "/geoip/GeoLite2-Country.mmdb"
+	expected.GeoIP.Mapping = map[string][]string{"GB": {"uk", "eu"}, "NA": {"us"}}
+	configureEnvVar(expected)
+	_ = os.Setenv(GeoIPMappingKey, "gb: uk;eu , na:us")
+	cl, _ := getTestContext("./testdata/filled_omitempty.yaml")
+	resolver := NewDependencyResolver(cl)
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *config)
+}
+
+func TestResolveConfigWithGeoIPWithoutMapping(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GeoIP.Database = "/geoip/GeoLite2-Country.mmdb"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithGeoIPMappingToUnknownGeoTag(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GeoIP.Database = "/geoip/GeoLite2-Country.mmdb"
+	expected.GeoIP.Mapping = map[string][]string{"GB": {"uk", "za"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithInvalidGeoIPMapping(t *testing.T) {
+	for _, mapping := range []map[string][]string{
+		{"GB": nil},
+		{"GBR": {"uk"}},
+		{"GB": {"uk", "uk"}},
+	} {
+		t.Run(fmt.Sprint(mapping), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.GeoIP.Database = "/geoip/GeoLite2-Country.mmdb"
+			expected.GeoIP.Mapping = mapping
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+	}
+}
+
+func TestResolveConfigIgnoresGeoIPMappingWithoutDatabase(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GeoIP.Mapping = map[string][]string{"GB": {"za"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithLatencyProbeTargets(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.LatencyProbeTargets = map[string]string{"uk": "10.1.0.1:443", "eu": "probe.eu.example.com:80"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidLatencyProbeTargets(t *testing.T) {
+	for _, targets := range []map[string]string{
+		{"za": "10.1.0.1:443"},
+		{"us": "10.1.0.1:443"},
+		{"uk": ""},
+		{"uk": "10.1.0.1"},
+		{"uk": ":443"},
+		{"": "10.1.0.1:443"},
+	} {
+		t.Run(fmt.Sprint(targets), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.LatencyProbeTargets = targets
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+	}
+}
+
+func TestResolveConfigWithIngressPropagation(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.IngressPropagation.Annotations = []string{"cert-manager.io/cluster-issuer", "nginx.ingress.kubernetes.io/*"}
+	expected.IngressPropagation.Labels = []string{"app", "team-*", "example.com/*"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidIngressPropagation(t *testing.T) {
+	for _, keys := range [][]string{
+		{"cert-manager.io/"},
+		{"/cluster-issuer"},
+		{"nginx.ingress.kubernetes.io/*/rewrite"},
+		{"*.example.com/team"},
+		{"team=label"},
+	} {
+		t.Run(fmt.Sprint(keys), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.IngressPropagation.Annotations = keys
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+		t.Run(fmt.Sprint("labels ", keys), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.IngressPropagation.Labels = keys
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+	}
+}
+
+func TestResolveConfigWithTracing(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Tracing = Tracing{Enabled: true, Endpoint: "otel-collector.monitoring:4317", SamplingPercent: 10}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidTracing(t *testing.T) {
+	for _, tracing := range []Tracing{
+		{Enabled: true, Endpoint: "otel-collector", SamplingPercent: 100},
+		{Enabled: true, Endpoint: "otel-collector:4317", SamplingPercent: -1},
+		{Enabled: true, Endpoint: "otel-collector:4317", SamplingPercent: 101},
+	} {
+		t.Run(fmt.Sprint(tracing), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.Tracing = tracing
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+	}
+}
+
+func TestResolveConfigWithDisabledTracingIsNotValidated(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Tracing = Tracing{Enabled: false, Endpoint: "otel-collector", SamplingPercent: 101}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithLog(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Log = Log{Level: "debug", Format: "console"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidLog(t *testing.T) {
+	for _, log := range []Log{
+		{Level: "trace", Format: "json"},
+		{Level: "INFO", Format: "json"},
+		{Level: "info", Format: "text"},
+	} {
+		t.Run(fmt.Sprint(log), func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			expected := predefinedConfig
+			expected.Log = log
+			// act,assert
+			arrangeVariablesAndAssert(t, expected, assert.Error)
+		})
+	}
+}
+
 // arrangeVariablesAndAssert sets string environment variables and asserts `expected` argument with
 // ResolveOperatorConfig() output. The last parameter unsets the values
 func arrangeVariablesAndAssert(t *testing.T, expected Config,
@@ -992,7 +1495,9 @@ func arrangeVariablesAndAssert(t *testing.T, expected Config,
 func cleanup() {
 	for _, s := range []string{ReconcileRequeueSecondsKey, ClusterGeoTagKey, ExtClustersGeoTagsKey, EdgeDNSZoneKey, DNSZoneKey, EdgeDNSServerKey,
 		Route53EnabledKey, NS1EnabledKey, InfobloxGridHostKey, InfobloxVersionKey, InfobloxPortKey, InfobloxUsernameKey, InfobloxPasswordKey,
-		OverrideWithFakeDNSKey, OverrideFakeInfobloxKey, K8gbNamespaceKey, CoreDNSExposedKey} {
+		InfobloxSecretKey, InfobloxSSLVerifyKey, InfobloxCAFileKey, InfobloxHTTPTimeoutKey, InfobloxHTTPPoolKey, OverrideWithFakeDNSKey, FakeDNSAddressKey, FakeDNSPeersKey, OverrideFakeInfobloxKey, K8gbNamespaceKey, CoreDNSExposedKey, EmbeddedDNSEnabledKey, EmbeddedDNSAddressKey,
+		GeoIPDatabaseKey, GeoIPMappingKey, LatencyProbeTargetsKey, PropagatedAnnotationsKey, PropagatedLabelsKey,
+		TracingEnabledKey, TracingEndpointKey, TracingSamplingKey, LogLevelKey, LogFormatKey} {
 		if os.Unsetenv(s) != nil {
 			panic(fmt.Errorf("cleanup %s", s))
 		}
@@ -1015,8 +1520,39 @@ func configureEnvVar(config Config) {
 	_ = os.Setenv(InfobloxPortKey, strconv.Itoa(config.Infoblox.Port))
 	_ = os.Setenv(InfobloxUsernameKey, config.Infoblox.Username)
 	_ = os.Setenv(InfobloxPasswordKey, config.Infoblox.Password)
+	_ = os.Setenv(InfobloxSecretKey, config.Infoblox.CredentialsSecret)
+	_ = os.Setenv(InfobloxSSLVerifyKey, strconv.FormatBool(config.Infoblox.SSLVerify))
+	_ = os.Setenv(InfobloxCAFileKey, config.Infoblox.CAFile)
+	_ = os.Setenv(InfobloxHTTPTimeoutKey, strconv.Itoa(config.Infoblox.HTTPRequestTimeout))
+	_ = os.Setenv(InfobloxHTTPPoolKey, strconv.Itoa(config.Infoblox.HTTPPoolConnections))
 	_ = os.Setenv(OverrideWithFakeDNSKey, strconv.FormatBool(config.Override.FakeDNSEnabled))
+	_ = os.Setenv(FakeDNSAddressKey, config.Override.FakeDNSAddress)
+	var fakeDNSPeers []string
+	for geoTag, address := range config.Override.FakeDNSPeers {
+		fakeDNSPeers = append(fakeDNSPeers, geoTag+"="+address)
+	}
+	_ = os.Setenv(FakeDNSPeersKey, strings.Join(fakeDNSPeers, ","))
 	_ = os.Setenv(OverrideFakeInfobloxKey, strconv.FormatBool(config.Override.FakeInfobloxEnabled))
+	_ = os.Setenv(EmbeddedDNSEnabledKey, strconv.FormatBool(config.EmbeddedDNS.Enabled))
+	_ = os.Setenv(EmbeddedDNSAddressKey, config.EmbeddedDNS.Address)
+	_ = os.Setenv(GeoIPDatabaseKey, config.GeoIP.Database)
+	var mapping []string
+	for location, geoTags := range config.GeoIP.Mapping {
+		mapping = append(mapping, location+":"+strings.Join(geoTags, ";"))
+	}
+	_ = os.Setenv(GeoIPMappingKey, strings.Join(mapping, ","))
+	var probeTargets []string
+	for geoTag, address := range config.LatencyProbeTargets {
+		probeTargets = append(probeTargets, geoTag+"="+address)
+	}
+	_ = os.Setenv(LatencyProbeTargetsKey, strings.Join(probeTargets, ","))
+	_ = os.Setenv(PropagatedAnnotationsKey, strings.Join(config.IngressPropagation.Annotations, ","))
+	_ = os.Setenv(PropagatedLabelsKey, strings.Join(config.IngressPropagation.Labels, ","))
+	_ = os.Setenv(TracingEnabledKey, strconv.FormatBool(config.Tracing.Enabled))
+	_ = os.Setenv(TracingEndpointKey, config.Tracing.Endpoint)
+	_ = os.Setenv(TracingSamplingKey, strconv.Itoa(config.Tracing.SamplingPercent))
+	_ = os.Setenv(LogLevelKey, config.Log.Level)
+	_ = os.Setenv(LogFormatKey, config.Log.Format)
 }
 
 func getTestContext(testData string) (client.Client, *k8gbv1beta1.Gslb) {
@@ -15,8 +15,19 @@ const (
 	ipAddressRegex = "^(([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])\\.){3}([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])$"
 	// versionNumberRegex matches version in formats 0.1.2, v0.1.2, v0.1.2-alpha
 	versionNumberRegex = "^(v){0,1}(0|(?:[1-9]\\d*))(?:\\.(0|(?:[1-9]\\d*))(?:\\.(0|(?:[1-9]\\d*)))?(?:\\-([\\w][\\w\\.\\-_]*))?)?$"
+	// listenAddressRegex matches host:port or :port listen addresses; e.g. :5353, 0.0.0.0:53
+	listenAddressRegex = "^[a-zA-Z0-9\\.\\-]*:([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$"
+	// hostPortRegex matches host:port addresses; e.g. 10.0.0.1:443, gslb-ns-eu.example.com:53
+	hostPortRegex = "^[a-zA-Z0-9\\.\\-]+:([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$"
+	// locationCodeRegex matches ISO 3166 country codes and continent codes; e.g. GB, EU
+	locationCodeRegex = "^[A-Z]{2}$"
 	// k8sNamespaceRegex matches valid kubernetes namespace
 	k8sNamespaceRegex = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// k8sNameRegex matches valid kubernetes object name (DNS subdomain); e.g. infoblox, k8gb.infoblox
+	k8sNameRegex = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	// metadataKeyRegex matches annotation or label key optionally ending with * wildcard; e.g. app, example.com/team,
+	// nginx.ingress.kubernetes.io/*
+	metadataKeyRegex = "^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?|[A-Za-z0-9][-A-Za-z0-9_.]*\\*|\\*)$"
 )
 
 // validator wrapper against field to be verified
@@ -132,6 +143,19 @@ func (v *validator) isNotEqualTo(value string) *validator {
 	return v
 }
 
+func (v *validator) isOneOf(values ...string) *validator {
+	if v.err != nil {
+		return v
+	}
+	for _, value := range values {
+		if v.strValue == value {
+			return v
+		}
+	}
+	v.err = fmt.Errorf(`"%s" must be one of %v, got "%s"`, v.name, values, v.strValue)
+	return v
+}
+
 func (v *validator) hasItems() *validator {
 	if v.err != nil {
 		return v
@@ -0,0 +1,417 @@
+// Package dnsserver is authoritative DNS server for the Gslb zone. It serves records of DNSEndpoints written by Gslb
+// reconciler directly, without waiting for external-dns synchronization
+package dnsserver
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	"github.com/miekg/dns"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+var log = logf.Log.WithName("dnsserver")
+
+// GeoTagLabel marks endpoint served only to clients located in the geoTag region. Regional endpoints
+// take precedence over endpoints without the label for the same name
+const GeoTagLabel = "k8gb.absa.oss/geo-tag"
+
+// GeoTagResolver returns geoTags closest to the client IP in order of preference
+type GeoTagResolver interface {
+	GeoTags(ip net.IP) []string
+}
+
+// Config of authoritative DNS server
+type Config struct {
+	// Address to listen on, both UDP and TCP; e.g. :5353
+	Address string
+	// Zone served by the server; e.g. cloud.example.com
+	Zone string
+	// NameServers authoritative for the zone. The first one is primary name server of the zone SOA record
+	NameServers []string
+	// TTL of SOA and NS records and of endpoints without TTL. It is also used as negative caching TTL
+	TTL uint32
+	// GeoTags locates clients for regional answers. Regional endpoints are not served when nil
+	GeoTags GeoTagResolver
+}
+
+// Server answers queries for the zone from endpoints set by SetEndpoints
+type Server struct {
+	config Config
+	zone   string
+	mu     sync.RWMutex
+	// endpoints keeps endpoints per owner; e.g. Gslb namespace/name
+	endpoints map[string][]*externaldns.Endpoint
+	// records index of resource records per lowercase FQDN and type
+	records map[string]map[uint16][]dns.RR
+	// geoRecords index of regional resource records per geoTag, lowercase FQDN and type
+	geoRecords map[string]map[string]map[uint16][]dns.RR
+	serial     uint32
+	// loaded is set when all endpoints were set for the first time, the zone is incomplete until then
+	loaded bool
+}
+
+// NewServer creates server. SOA serial starts at current unix time so it grows across restarts
+func NewServer(config Config) *Server {
+	s := &Server{
+		config:    config,
+		zone:      dns.CanonicalName(config.Zone),
+		endpoints: make(map[string][]*externaldns.Endpoint),
+		serial:    uint32(time.Now().Unix()),
+	}
+	s.records, s.geoRecords = s.index()
+	return s
+}
+
+// SetEndpoints replaces endpoints of the owner. Zone serial is incremented when records change
+func (s *Server) SetEndpoints(owner string, endpoints []*externaldns.Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reflect.DeepEqual(s.endpoints[owner], endpoints) {
+		return
+	}
+	s.endpoints[owner] = endpoints
+	s.serial++
+	s.records, s.geoRecords = s.index()
+}
+
+// RemoveEndpoints removes endpoints of the owner. Zone serial is incremented when the owner had endpoints
+func (s *Server) RemoveEndpoints(owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.endpoints[owner]; !found {
+		return
+	}
+	delete(s.endpoints, owner)
+	s.serial++
+	s.records, s.geoRecords = s.index()
+}
+
+// SetLoaded marks the zone complete. Queries for the zone are answered SERVFAIL until then, so resolvers try
+// another name server instead of caching NXDOMAIN of a record which wasn't loaded yet
+func (s *Server) SetLoaded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		log.Info("Zone loaded", "zone", s.zone, "serial", s.serial)
+	}
+	s.loaded = true
+}
+
+// Serial returns current zone serial
+func (s *Server) Serial() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.serial
+}
+
+// NeedLeaderElection implements controller-runtime LeaderElectionRunnable. Every replica of the operator serves
+// the zone, not only the leader
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves UDP and TCP until stop is closed. Server implements controller-runtime Runnable,
+// so it is started by manager
+func (s *Server) Start(stop <-chan struct{}) error {
+	udpConn, err := net.ListenPacket("udp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("can't listen udp %s: %w", s.config.Address, err)
+	}
+	tcpListener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		_ = udpConn.Close()
+		return fmt.Errorf("can't listen tcp %s: %w", s.config.Address, err)
+	}
+	servers := []*dns.Server{
+		{PacketConn: udpConn, Handler: s},
+		{Listener: tcpListener, Handler: s},
+	}
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *dns.Server) {
+			errs <- server.ActivateAndServe()
+		}(server)
+	}
+	log.Info("Serving zone", "zone", s.zone, "address", s.config.Address)
+
+	select {
+	case <-stop:
+		err = nil
+	case err = <-errs:
+	}
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(); shutdownErr != nil {
+			log.Error(shutdownErr, "Failed to shutdown DNS server")
+		}
+	}
+	return err
+}
+
+// ServeDNS answers single question queries for the zone. Other zones are refused
+func (s *Server) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	client, ecs := clientAddress(w, req)
+	m, regional := s.answer(req, client)
+	if opt := req.IsEdns0(); opt != nil {
+		m.SetEdns0(opt.UDPSize(), false)
+		if ecs != nil {
+			// scope tells resolvers whether the answer may be cached for the whole client subnet, see RFC 7871
+			var scope uint8
+			if regional {
+				scope = ecs.SourceNetmask
+			}
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{
+				Code:          dns.EDNS0SUBNET,
+				Family:        ecs.Family,
+				SourceNetmask: ecs.SourceNetmask,
+				SourceScope:   scope,
+				Address:       ecs.Address,
+			})
+		}
+	}
+	if _, udp := w.RemoteAddr().(*net.UDPAddr); udp {
+		m.Truncate(udpSize(req))
+	}
+	if err := w.WriteMsg(m); err != nil {
+		log.V(logging.DebugLevel).Info("Failed to write DNS response", "client", w.RemoteAddr().String(), "error", err.Error())
+	}
+}
+
+// answer returns response for the client and whether it contains regional records
+func (s *Server) answer(req *dns.Msg, client net.IP) (m *dns.Msg, regional bool) {
+	m = new(dns.Msg)
+	m.SetReply(req)
+	m.Compress = true
+	if req.Opcode != dns.OpcodeQuery {
+		m.SetRcode(req, dns.RcodeNotImplemented)
+		return m, false
+	}
+	if len(req.Question) != 1 {
+		m.SetRcode(req, dns.RcodeFormatError)
+		return m, false
+	}
+	q := req.Question[0]
+	name := dns.CanonicalName(q.Name)
+	if !dns.IsSubDomain(s.zone, name) {
+		m.SetRcode(req, dns.RcodeRefused)
+		return m, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loaded {
+		m.SetRcode(req, dns.RcodeServerFailure)
+		return m, false
+	}
+	m.Authoritative = true
+
+	var geoTags []string
+	if s.config.GeoTags != nil && client != nil && len(s.geoRecords) > 0 {
+		geoTags = s.config.GeoTags.GeoTags(client)
+	}
+
+	// follow CNAME chain within the zone, the limit protects against loops
+	for i := 0; i < 8; i++ {
+		rrs, found := s.records[name]
+		if closest, ok := s.closestRegion(name, geoTags); ok {
+			rrs, found, regional = closest, true, true
+		}
+		if cname := rrs[dns.TypeCNAME]; len(cname) > 0 && q.Qtype != dns.TypeCNAME {
+			m.Answer = append(m.Answer, cname...)
+			name = dns.CanonicalName(cname[0].(*dns.CNAME).Target)
+			if !dns.IsSubDomain(s.zone, name) {
+				return m, regional
+			}
+			continue
+		}
+		answer := rrs[q.Qtype]
+		if q.Qtype == dns.TypeANY {
+			answer = nil
+			for _, typed := range rrs {
+				answer = append(answer, typed...)
+			}
+		}
+		switch {
+		case len(answer) > 0:
+			m.Answer = append(m.Answer, answer...)
+			if q.Qtype != dns.TypeNS || name != s.zone {
+				m.Ns = append(m.Ns, s.records[s.zone][dns.TypeNS]...)
+			}
+		case found || s.isEmptyNonTerminal(name):
+			// NODATA
+			m.Ns = append(m.Ns, s.negativeSOA())
+		default:
+			m.Rcode = dns.RcodeNameError
+			m.Ns = append(m.Ns, s.negativeSOA())
+		}
+		return m, regional
+	}
+	m.Rcode = dns.RcodeServerFailure
+	return m, regional
+}
+
+// closestRegion returns records of the first geoTag in order of preference which has records for the name.
+// When none has, records of all healthy regions are served instead
+func (s *Server) closestRegion(name string, geoTags []string) (map[uint16][]dns.RR, bool) {
+	for _, geoTag := range geoTags {
+		if rrs, found := s.geoRecords[geoTag][name]; found {
+			return rrs, true
+		}
+	}
+	return nil, false
+}
+
+// index builds records from SOA, NS and all endpoints, regional endpoints are indexed separately.
+// Endpoints outside of the zone are ignored
+func (s *Server) index() (map[string]map[uint16][]dns.RR, map[string]map[string]map[uint16][]dns.RR) {
+	records := make(map[string]map[uint16][]dns.RR)
+	geoRecords := make(map[string]map[string]map[uint16][]dns.RR)
+	add := func(records map[string]map[uint16][]dns.RR, rr dns.RR) {
+		name := dns.CanonicalName(rr.Header().Name)
+		if records[name] == nil {
+			records[name] = make(map[uint16][]dns.RR)
+		}
+		for _, existing := range records[name][rr.Header().Rrtype] {
+			if dns.IsDuplicate(existing, rr) {
+				return
+			}
+		}
+		records[name][rr.Header().Rrtype] = append(records[name][rr.Header().Rrtype], rr)
+	}
+
+	add(records, s.soa())
+	for _, ns := range s.config.NameServers {
+		add(records, &dns.NS{Hdr: s.header(s.zone, dns.TypeNS, s.config.TTL), Ns: dns.Fqdn(ns)})
+	}
+	for _, endpoints := range s.endpoints {
+		for _, ep := range endpoints {
+			name := dns.CanonicalName(ep.DNSName)
+			if !dns.IsSubDomain(s.zone, name) || name == s.zone {
+				continue
+			}
+			ttl := s.config.TTL
+			if ep.RecordTTL.IsConfigured() {
+				ttl = uint32(ep.RecordTTL)
+			}
+			for _, target := range ep.Targets {
+				rr, err := s.resourceRecord(name, ep.RecordType, ttl, target)
+				if err != nil {
+					log.Info("Skipping invalid record", "name", ep.DNSName, "type", ep.RecordType, "target", target, "error", err.Error())
+					continue
+				}
+				geoTag := ep.Labels[GeoTagLabel]
+				if geoTag == "" {
+					add(records, rr)
+					continue
+				}
+				if geoRecords[geoTag] == nil {
+					geoRecords[geoTag] = make(map[string]map[uint16][]dns.RR)
+				}
+				add(geoRecords[geoTag], rr)
+			}
+		}
+	}
+	return records, geoRecords
+}
+
+func (s *Server) resourceRecord(name, recordType string, ttl uint32, target string) (dns.RR, error) {
+	switch recordType {
+	case "A":
+		ip := net.ParseIP(target).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %s", target)
+		}
+		return &dns.A{Hdr: s.header(name, dns.TypeA, ttl), A: ip}, nil
+	case "AAAA":
+		ip := net.ParseIP(target)
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid IPv6 address %s", target)
+		}
+		return &dns.AAAA{Hdr: s.header(name, dns.TypeAAAA, ttl), AAAA: ip}, nil
+	case "CNAME":
+		return &dns.CNAME{Hdr: s.header(name, dns.TypeCNAME, ttl), Target: dns.Fqdn(target)}, nil
+	case "NS":
+		return &dns.NS{Hdr: s.header(name, dns.TypeNS, ttl), Ns: dns.Fqdn(target)}, nil
+	case "TXT":
+		return &dns.TXT{Hdr: s.header(name, dns.TypeTXT, ttl), Txt: []string{strings.Trim(target, `"`)}}, nil
+	}
+	return nil, fmt.Errorf("unsupported record type")
+}
+
+func (s *Server) soa() *dns.SOA {
+	primary := "ns." + s.zone
+	if len(s.config.NameServers) > 0 {
+		primary = dns.Fqdn(s.config.NameServers[0])
+	}
+	return &dns.SOA{
+		Hdr:     s.header(s.zone, dns.TypeSOA, s.config.TTL),
+		Ns:      primary,
+		Mbox:    "hostmaster." + s.zone,
+		Serial:  s.serial,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  s.config.TTL,
+	}
+}
+
+// negativeSOA returns SOA for authority section of negative answers, see RFC 2308
+func (s *Server) negativeSOA() dns.RR {
+	soa := s.soa()
+	soa.Hdr.Ttl = soa.Minttl
+	return soa
+}
+
+// isEmptyNonTerminal returns true if name has no records but some names below it have
+func (s *Server) isEmptyNonTerminal(name string) bool {
+	for existing := range s.records {
+		if existing != name && dns.IsSubDomain(name, existing) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) header(name string, rrtype uint16, ttl uint32) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+}
+
+// clientAddress returns EDNS Client Subnet address when the client sent one, otherwise the source
+// address of the query. ECS with zero source prefix asks not to use client subnet, see RFC 7871
+func clientAddress(w dns.ResponseWriter, req *dns.Msg) (net.IP, *dns.EDNS0_SUBNET) {
+	if opt := req.IsEdns0(); opt != nil {
+		for _, option := range opt.Option {
+			if ecs, ok := option.(*dns.EDNS0_SUBNET); ok {
+				if ecs.SourceNetmask > 0 {
+					return ecs.Address, ecs
+				}
+				return remoteIP(w), ecs
+			}
+		}
+	}
+	return remoteIP(w), nil
+}
+
+func remoteIP(w dns.ResponseWriter) net.IP {
+	switch addr := w.RemoteAddr().(type) {
+	case *net.UDPAddr:
+		return addr.IP
+	case *net.TCPAddr:
+		return addr.IP
+	}
+	return nil
+}
+
+func udpSize(req *dns.Msg) int {
+	if opt := req.IsEdns0(); opt != nil && opt.UDPSize() > dns.MinMsgSize {
+		return int(opt.UDPSize())
+	}
+	return dns.MinMsgSize
+}
@@ -0,0 +1,383 @@
+package dnsserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+var predefinedEndpoints = []*externaldns.Endpoint{
+	{
+		DNSName:    "roundrobin.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "A",
+		Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2"},
+	},
+	{
+		DNSName:    "roundrobin.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "AAAA",
+		Targets:    externaldns.Targets{"2001:db8::1"},
+	},
+	{
+		DNSName:    "elb.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "CNAME",
+		Targets:    externaldns.Targets{"roundrobin.cloud.example.com"},
+	},
+	{
+		DNSName:    "info.txt.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "TXT",
+		Targets:    externaldns.Targets{"latency=10ms"},
+	},
+}
+
+func TestServerAnswersAuthoritatively(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "roundrobin.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.True(t, r.Authoritative)
+	assert.Equal(t, dns.RcodeSuccess, r.Rcode)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, answerTargets(r))
+	assert.ElementsMatch(t, []string{"gslb-ns-eu.example.com.", "gslb-ns-za.example.com."}, authorityTargets(r))
+}
+
+func TestServerAnswersOverTCP(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "tcp", addr, "roundrobin.cloud.example.com.", dns.TypeAAAA)
+	// assert
+	assert.Equal(t, dns.RcodeSuccess, r.Rcode)
+	assert.Equal(t, []string{"2001:db8::1"}, answerTargets(r))
+}
+
+func TestServerAnswersZoneApex(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	soa := exchange(t, "udp", addr, "cloud.example.com.", dns.TypeSOA)
+	ns := exchange(t, "udp", addr, "cloud.example.com.", dns.TypeNS)
+	// assert
+	require.Len(t, soa.Answer, 1)
+	assert.Equal(t, "gslb-ns-eu.example.com.", soa.Answer[0].(*dns.SOA).Ns)
+	assert.Equal(t, "hostmaster.cloud.example.com.", soa.Answer[0].(*dns.SOA).Mbox)
+	assert.ElementsMatch(t, []string{"gslb-ns-eu.example.com.", "gslb-ns-za.example.com."}, answerTargets(ns))
+	assert.Empty(t, ns.Ns)
+}
+
+func TestServerReturnsNXDomainWithSOA(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "notfound.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, dns.RcodeNameError, r.Rcode)
+	assert.Empty(t, r.Answer)
+	require.Len(t, r.Ns, 1)
+	assert.Equal(t, dns.TypeSOA, r.Ns[0].Header().Rrtype)
+	assert.Equal(t, uint32(30), r.Ns[0].Header().Ttl)
+}
+
+func TestServerReturnsNoDataWithSOA(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	noType := exchange(t, "udp", addr, "roundrobin.cloud.example.com.", dns.TypeTXT)
+	emptyNonTerminal := exchange(t, "udp", addr, "txt.cloud.example.com.", dns.TypeA)
+	// assert
+	for _, r := range []*dns.Msg{noType, emptyNonTerminal} {
+		assert.Equal(t, dns.RcodeSuccess, r.Rcode)
+		assert.Empty(t, r.Answer)
+		require.Len(t, r.Ns, 1)
+		assert.Equal(t, dns.TypeSOA, r.Ns[0].Header().Rrtype)
+	}
+}
+
+func TestServerFollowsCNAMEWithinZone(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "elb.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, []string{"roundrobin.cloud.example.com.", "10.0.0.1", "10.0.0.2"}, answerTargets(r))
+}
+
+func TestServerAnswersTXT(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "info.txt.cloud.example.com.", dns.TypeTXT)
+	// assert
+	assert.Equal(t, []string{"latency=10ms"}, answerTargets(r))
+}
+
+func TestServerRefusesOtherZones(t *testing.T) {
+	// arrange
+	addr, stop := startServer(t)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "example.org.", dns.TypeA)
+	// assert
+	assert.Equal(t, dns.RcodeRefused, r.Rcode)
+	assert.False(t, r.Authoritative)
+}
+
+func TestServerIncrementsSerialOnlyWhenRecordsChange(t *testing.T) {
+	// arrange
+	server := NewServer(Config{Zone: "cloud.example.com", TTL: 30})
+	initial := server.Serial()
+	// act
+	server.SetEndpoints("test-gslb/test-gslb", predefinedEndpoints)
+	changed := server.Serial()
+	server.SetEndpoints("test-gslb/test-gslb", predefinedEndpoints)
+	unchanged := server.Serial()
+	server.RemoveEndpoints("test-gslb/test-gslb")
+	removed := server.Serial()
+	server.RemoveEndpoints("test-gslb/test-gslb")
+	// assert
+	assert.Equal(t, initial+1, changed)
+	assert.Equal(t, changed, unchanged)
+	assert.Equal(t, changed+1, removed)
+	assert.Equal(t, removed, server.Serial())
+}
+
+func TestServerSkipsInvalidEndpoints(t *testing.T) {
+	// arrange
+	server := NewServer(Config{Zone: "cloud.example.com", TTL: 30})
+	// act
+	server.SetEndpoints("test-gslb/test-gslb", []*externaldns.Endpoint{
+		{DNSName: "invalid.cloud.example.com", RecordType: "A", Targets: externaldns.Targets{"2001:db8::1", "not-an-ip"}},
+		{DNSName: "outside.example.org", RecordType: "A", Targets: externaldns.Targets{"10.0.0.1"}},
+		{DNSName: "srv.cloud.example.com", RecordType: "SRV", Targets: externaldns.Targets{"0 0 53 ns.example.com"}},
+	})
+	// assert
+	assert.NotContains(t, server.records, "invalid.cloud.example.com.")
+	assert.NotContains(t, server.records, "outside.example.org.")
+	assert.NotContains(t, server.records, "srv.cloud.example.com.")
+}
+
+func TestServerAnswersClosestRegionByClientSubnet(t *testing.T) {
+	// arrange
+	addr, stop := startServerWithGeoTags(t, predefinedGeoTags, predefinedGeoEndpoints)
+	defer stop()
+	// act
+	eu := exchangeWithSubnet(t, addr, "geo.cloud.example.com.", "81.2.69.0", 24)
+	za := exchangeWithSubnet(t, addr, "geo.cloud.example.com.", "41.1.2.0", 24)
+	// assert
+	assert.Equal(t, []string{"10.0.0.1"}, answerTargets(eu))
+	assert.Equal(t, []string{"10.1.0.1"}, answerTargets(za))
+	assert.Equal(t, uint8(24), subnet(t, eu).SourceScope)
+	assert.Equal(t, "81.2.69.0", subnet(t, eu).Address.String())
+}
+
+func TestServerFallsBackToNextRegion(t *testing.T) {
+	// arrange
+	endpoints := []*externaldns.Endpoint{predefinedGeoEndpoints[0], predefinedGeoEndpoints[2]}
+	addr, stop := startServerWithGeoTags(t, predefinedGeoTags, endpoints)
+	defer stop()
+	// act
+	// eu region is down, UK clients prefer eu then za
+	uk := exchangeWithSubnet(t, addr, "geo.cloud.example.com.", "81.2.69.0", 24)
+	// assert
+	assert.Equal(t, []string{"10.1.0.1"}, answerTargets(uk))
+	assert.Equal(t, uint8(24), subnet(t, uk).SourceScope)
+}
+
+func TestServerAnswersAllRegionsToUnknownClients(t *testing.T) {
+	// arrange
+	addr, stop := startServerWithGeoTags(t, predefinedGeoTags, predefinedGeoEndpoints)
+	defer stop()
+	// act
+	unknown := exchangeWithSubnet(t, addr, "geo.cloud.example.com.", "12.0.0.0", 8)
+	// query source 127.0.0.1 is not mapped either
+	noSubnet := exchange(t, "udp", addr, "geo.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.1.0.1"}, answerTargets(unknown))
+	assert.Equal(t, uint8(0), subnet(t, unknown).SourceScope)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.1.0.1"}, answerTargets(noSubnet))
+}
+
+func TestServerUsesSourceAddressWithoutClientSubnet(t *testing.T) {
+	// arrange
+	geoTags := fakeGeoTags{"127.0.0.1": {"za"}}
+	addr, stop := startServerWithGeoTags(t, geoTags, predefinedGeoEndpoints)
+	defer stop()
+	// act
+	r := exchange(t, "udp", addr, "geo.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, []string{"10.1.0.1"}, answerTargets(r))
+}
+
+func TestServerFailsUntilZoneIsLoaded(t *testing.T) {
+	// arrange
+	addr := freeAddress(t)
+	server := NewServer(Config{Address: addr, Zone: "cloud.example.com", TTL: 30})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = server.Start(stop)
+	}()
+	var r *dns.Msg
+	require.Eventually(t, func() bool {
+		var err error
+		r, _, err = new(dns.Client).Exchange(new(dns.Msg).SetQuestion("roundrobin.cloud.example.com.", dns.TypeA), addr)
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	// act
+	server.SetEndpoints("test-gslb/test-gslb", predefinedEndpoints)
+	server.SetLoaded()
+	loaded := exchange(t, "udp", addr, "roundrobin.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, dns.RcodeServerFailure, r.Rcode)
+	assert.False(t, r.Authoritative)
+	assert.Equal(t, dns.RcodeSuccess, loaded.Rcode)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, answerTargets(loaded))
+	assert.False(t, server.NeedLeaderElection())
+}
+
+// fakeGeoTags maps client IP to geoTags
+type fakeGeoTags map[string][]string
+
+func (f fakeGeoTags) GeoTags(ip net.IP) []string {
+	return f[ip.String()]
+}
+
+var predefinedGeoTags = fakeGeoTags{
+	"81.2.69.0": {"eu", "za"},
+	"41.1.2.0":  {"za", "eu"},
+}
+
+var predefinedGeoEndpoints = []*externaldns.Endpoint{
+	{
+		DNSName:    "geo.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "A",
+		Targets:    externaldns.Targets{"10.0.0.1", "10.1.0.1"},
+	},
+	{
+		DNSName:    "geo.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "A",
+		Targets:    externaldns.Targets{"10.0.0.1"},
+		Labels:     externaldns.Labels{GeoTagLabel: "eu"},
+	},
+	{
+		DNSName:    "geo.cloud.example.com",
+		RecordTTL:  30,
+		RecordType: "A",
+		Targets:    externaldns.Targets{"10.1.0.1"},
+		Labels:     externaldns.Labels{GeoTagLabel: "za"},
+	},
+}
+
+func startServer(t *testing.T) (string, func()) {
+	return startServerWithGeoTags(t, nil, predefinedEndpoints)
+}
+
+func startServerWithGeoTags(t *testing.T, geoTags GeoTagResolver, endpoints []*externaldns.Endpoint) (string, func()) {
+	addr := freeAddress(t)
+	server := NewServer(Config{
+		Address:     addr,
+		Zone:        "cloud.example.com",
+		NameServers: []string{"gslb-ns-eu.example.com", "gslb-ns-za.example.com"},
+		TTL:         30,
+		GeoTags:     geoTags,
+	})
+	server.SetEndpoints("test-gslb/test-gslb", endpoints)
+	server.SetLoaded()
+	stop := make(chan struct{})
+	go func() {
+		_ = server.Start(stop)
+	}()
+	// wait until both listeners accept queries
+	for _, network := range []string{"udp", "tcp"} {
+		require.Eventually(t, func() bool {
+			c := &dns.Client{Net: network, Timeout: 100 * time.Millisecond}
+			_, _, err := c.Exchange(new(dns.Msg).SetQuestion("cloud.example.com.", dns.TypeSOA), addr)
+			return err == nil
+		}, 2*time.Second, 20*time.Millisecond)
+	}
+	return addr, func() { close(stop) }
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func exchange(t *testing.T, network, addr, name string, qtype uint16) *dns.Msg {
+	c := &dns.Client{Net: network, Timeout: time.Second}
+	r, _, err := c.Exchange(new(dns.Msg).SetQuestion(name, qtype), addr)
+	require.NoError(t, err)
+	return r
+}
+
+func exchangeWithSubnet(t *testing.T, addr, name, subnet string, netmask uint8) *dns.Msg {
+	m := new(dns.Msg).SetQuestion(name, dns.TypeA)
+	m.SetEdns0(dns.DefaultMsgSize, false)
+	m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: netmask,
+		Address:       net.ParseIP(subnet).To4(),
+	})
+	c := &dns.Client{Net: "udp", Timeout: time.Second}
+	r, _, err := c.Exchange(m, addr)
+	require.NoError(t, err)
+	return r
+}
+
+func subnet(t *testing.T, r *dns.Msg) *dns.EDNS0_SUBNET {
+	require.NotNil(t, r.IsEdns0())
+	for _, option := range r.IsEdns0().Option {
+		if ecs, ok := option.(*dns.EDNS0_SUBNET); ok {
+			return ecs
+		}
+	}
+	require.Fail(t, "response without client subnet")
+	return nil
+}
+
+func answerTargets(r *dns.Msg) []string {
+	return targets(r.Answer)
+}
+
+func authorityTargets(r *dns.Msg) []string {
+	return targets(r.Ns)
+}
+
+func targets(rrs []dns.RR) (targets []string) {
+	for _, rr := range rrs {
+		switch record := rr.(type) {
+		case *dns.A:
+			targets = append(targets, record.A.String())
+		case *dns.AAAA:
+			targets = append(targets, record.AAAA.String())
+		case *dns.CNAME:
+			targets = append(targets, record.Target)
+		case *dns.NS:
+			targets = append(targets, record.Ns)
+		case *dns.TXT:
+			targets = append(targets, record.Txt...)
+		}
+	}
+	return targets
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/dnsserver"
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	"github.com/AbsaOSS/k8gb/controllers/metrics"
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
 
 	coreerrors "errors"
 
@@ -19,6 +23,7 @@ import (
 	"github.com/miekg/dns"
 	corev1 "k8s.io/api/core/v1"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -29,7 +34,7 @@ import (
 
 const coreDNSExtServiceName = "k8gb-coredns-lb"
 
-func (r *GslbReconciler) getGslbIngressIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+func (r *GslbReconciler) getGslbIngressTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) ([]string, error) {
 	nn := types.NamespacedName{
 		Name:      gslb.Name,
 		Namespace: gslb.Namespace,
@@ -37,151 +42,227 @@ func (r *GslbReconciler) getGslbIngressIPs(gslb *k8gbv1beta1.Gslb) ([]string, er
 
 	gslbIngress := &v1beta1.Ingress{}
 
-	err := r.Get(context.TODO(), nn, gslbIngress)
+	err := r.Get(ctx, nn, gslbIngress)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			log.Info(fmt.Sprintf("Can't find gslb Ingress: %s", gslb.Name))
+			logging.FromContext(ctx).Info("Can't find gslb Ingress")
 		}
 		return nil, err
 	}
 
-	var gslbIngressIPs []string
-
-	for _, ip := range gslbIngress.Status.LoadBalancer.Ingress {
-		if len(ip.IP) > 0 {
-			gslbIngressIPs = append(gslbIngressIPs, ip.IP)
-		}
-		if len(ip.Hostname) > 0 {
-			IPs, err := utils.Dig(r.Config.EdgeDNSServer, ip.Hostname)
-			if err != nil {
-				log.Info("Dig error: %s", err)
-				return nil, err
-			}
-			gslbIngressIPs = append(gslbIngressIPs, IPs...)
-		}
-	}
-
-	return gslbIngressIPs, nil
+	return loadBalancerTargets(gslbIngress.Status.LoadBalancer.Ingress), nil
 }
 
 func getExternalClusterHeartbeatFQDNs(gslb *k8gbv1beta1.Gslb, config *depresolver.Config) (extGslbClusters []string) {
 	for _, geoTag := range config.ExtClustersGeoTags {
-		extGslbClusters = append(extGslbClusters, fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, geoTag, config.EdgeDNSZone))
+		extGslbClusters = append(extGslbClusters, gslbdns.HeartbeatTXTName(gslb.Name, geoTag, config))
 	}
 	return
 }
 
-func (r *GslbReconciler) getExternalTargets(host string) ([]string, error) {
+func (r *GslbReconciler) getExternalTargets(ctx context.Context, host string) ([]string, error) {
+	targetsByGeoTag, err := r.getExternalTargetsByGeoTag(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return gslbdns.MergeTargets(r.Config.ExtClustersGeoTags, targetsByGeoTag), nil
+}
+
+// getExternalTargetsByGeoTag returns targets of external clusters keyed by their geoTags. Clusters without
+// targets are omitted
+func (r *GslbReconciler) getExternalTargetsByGeoTag(ctx context.Context, host string) (map[string][]string, error) {
+	log := logging.FromContext(ctx).WithValues(logging.HostKey, host)
 
 	extGslbClusters := r.nsServerNameExt()
 
-	var targets []string
+	targets := make(map[string][]string)
 
-	for _, cluster := range extGslbClusters {
-		log.Info(fmt.Sprintf("Adding external Gslb targets from %s cluster...", cluster))
-		g := new(dns.Msg)
-		host = fmt.Sprintf("localtargets-%s.", host) // Convert to true FQDN with dot at the end. Otherwise dns lib freaks out
-		g.SetQuestion(host, dns.TypeA)
+	// Convert to true FQDN with dot at the end. Otherwise dns lib freaks out
+	fqdn := dns.Fqdn(gslbdns.LocalTargetsName(host))
 
-		ns := overrideWithFakeDNS(r.Config.Override.FakeDNSEnabled, cluster)
+	for i, cluster := range extGslbClusters {
+		geoTag := r.Config.ExtClustersGeoTags[i]
+		log := log.WithValues(logging.PeerKey, geoTag)
+		log.V(logging.DebugLevel).Info("Querying external Gslb targets", "nameserver", cluster)
+		ns := overrideWithFakeDNS(r.Config.Override, cluster, geoTag)
+		_, span := tracing.Start(ctx, "peer.QueryLocalTargets", tracing.HostKey.String(host), tracing.PeerKey.String(geoTag))
 
-		a, err := dns.Exchange(g, ns)
-		if err != nil {
-			log.Info(fmt.Sprintf("Error contacting external Gslb cluster(%s) : (%v)", cluster, err))
-			return nil, nil
-		}
 		var clusterTargets []string
-
-		for _, A := range a.Answer {
-			IP := strings.Split(A.String(), "\t")[4]
-			clusterTargets = append(clusterTargets, IP)
+		var queryErr error
+		// cluster is up when it answers authoritatively, empty answer or NXDOMAIN included
+		up := true
+
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			g := new(dns.Msg)
+			g.SetQuestion(fqdn, qtype)
+			a, exchangeRTT, err := new(dns.Client).Exchange(g, ns)
+			if err != nil {
+				// the cluster is skipped, remaining clusters are still queried
+				log.Info("Can't contact external Gslb cluster", "nameserver", cluster, "error", err.Error())
+				up, clusterTargets, queryErr = false, nil, err
+				break
+			}
+			r.Metrics.ObservePeerDNSQueryDuration(geoTag, exchangeRTT)
+			if a.Rcode != dns.RcodeSuccess && a.Rcode != dns.RcodeNameError {
+				up = false
+			}
+			clusterTargets = appendAnswerTargets(clusterTargets, fqdn, a.Answer)
 		}
+		r.Metrics.UpdatePeerUpMetric(geoTag, up)
+		tracing.End(span, queryErr)
 		if len(clusterTargets) > 0 {
-			targets = append(targets, clusterTargets...)
-			log.Info(fmt.Sprintf("Added external %s Gslb targets from %s cluster", clusterTargets, cluster))
+			targets[geoTag] = clusterTargets
+			log.V(logging.DebugLevel).Info("Added external Gslb targets", "targets", clusterTargets)
 		}
 	}
 
 	return targets, nil
 }
 
-func (r *GslbReconciler) gslbDNSEndpoint(gslb *k8gbv1beta1.Gslb) (*externaldns.DNSEndpoint, error) {
+// appendAnswerTargets appends targets of localtargets answer. External cluster in cname mode answers
+// with CNAME record; its target hostname is used instead of addresses the CNAME resolves to
+func appendAnswerTargets(targets []string, fqdn string, answer []dns.RR) []string {
+	for _, rr := range answer {
+		if record, ok := rr.(*dns.CNAME); ok && record.Hdr.Name == fqdn {
+			hostname := strings.TrimSuffix(record.Target, ".")
+			for _, target := range targets {
+				if target == hostname {
+					return targets
+				}
+			}
+			return append(targets, hostname)
+		}
+	}
+	for _, rr := range answer {
+		switch record := rr.(type) {
+		case *dns.A:
+			targets = append(targets, record.A.String())
+		case *dns.AAAA:
+			targets = append(targets, record.AAAA.String())
+		}
+	}
+	return targets
+}
+
+// addressEndpoints creates A record for IPv4 and AAAA record for IPv6 targets. A record is created
+// even without targets when alwaysA is set
+func addressEndpoints(name string, ttl externaldns.TTL, targets []string, alwaysA bool) (endpoints []*externaldns.Endpoint) {
+	ipv4, ipv6 := utils.SplitByAddressFamily(targets)
+	if len(ipv4) > 0 || alwaysA {
+		endpoints = append(endpoints, &externaldns.Endpoint{
+			DNSName:    name,
+			RecordTTL:  ttl,
+			RecordType: "A",
+			Targets:    ipv4,
+		})
+	}
+	if len(ipv6) > 0 {
+		endpoints = append(endpoints, &externaldns.Endpoint{
+			DNSName:    name,
+			RecordTTL:  ttl,
+			RecordType: "AAAA",
+			Targets:    ipv6,
+		})
+	}
+	return endpoints
+}
+
+// gslbDNSEndpoint returns DNSEndpoint CR and regional endpoints of geo strategy. Regional endpoints are served
+// by embedded DNS only, DNSEndpoint contains targets of all healthy regions
+func (r *GslbReconciler) gslbDNSEndpoint(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*externaldns.DNSEndpoint, []*externaldns.Endpoint, error) {
 	var gslbHosts []*externaldns.Endpoint
+	var geoEndpoints []*externaldns.Endpoint
 	var ttl = externaldns.TTL(gslb.Spec.Strategy.DNSTtlSeconds)
+	log := logging.FromContext(ctx)
 
-	serviceHealth, err := r.getServiceHealthStatus(gslb)
+	serviceHealth, err := r.getServiceHealthStatus(ctx, gslb)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	localTargets, err := r.getGslbIngressIPs(gslb)
+	localTargets, err := r.getLocalTargets(ctx, gslb)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// hosts and targets are ordered deterministically, so DNSEndpoint is updated only when records change
+	hosts := make([]string, 0, len(serviceHealth))
+	for host := range serviceHealth {
+		hosts = append(hosts, host)
 	}
+	sort.Strings(hosts)
 
-	for host, health := range serviceHealth {
-		var finalTargets []string
+	serving := make(map[string][]string, len(hosts))
+	for _, host := range hosts {
+		health := serviceHealth[host]
+		log := log.WithValues(logging.HostKey, host)
 
 		if !strings.Contains(host, r.Config.EdgeDNSZone) {
-			return nil, fmt.Errorf("ingress host %s does not match delegated zone %s", host, r.Config.EdgeDNSZone)
+			return nil, nil, fmt.Errorf("ingress host %s does not match delegated zone %s", host, r.Config.EdgeDNSZone)
 		}
 
 		if health == "Healthy" {
-			finalTargets = append(finalTargets, localTargets...)
-			localTargetsHost := fmt.Sprintf("localtargets-%s", host)
-			dnsRecord := &externaldns.Endpoint{
-				DNSName:    localTargetsHost,
-				RecordTTL:  ttl,
-				RecordType: "A",
-				Targets:    localTargets,
+			localEndpoints, err := r.targetEndpoints(ctx, gslb, gslbdns.LocalTargetsName(host), ttl, localTargets[host], true)
+			if err != nil {
+				return nil, nil, err
 			}
-			gslbHosts = append(gslbHosts, dnsRecord)
+			gslbHosts = append(gslbHosts, localEndpoints...)
 		}
 
 		// Check if host is alive on external Gslb
-		externalTargets, err := r.getExternalTargets(host)
+		externalTargetsByGeoTag, err := r.getExternalTargetsByGeoTag(ctx, host)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		if len(externalTargets) > 0 {
+		answer := gslbdns.AnswerHost(gslb.Spec.Strategy, r.Config.ClusterGeoTag, r.Config.ExtClustersGeoTags, health, localTargets[host],
+			externalTargetsByGeoTag, r.latencies.measurements())
+		if len(externalTargetsByGeoTag) > 0 {
 			switch gslb.Spec.Strategy.Type {
-			case roundRobinStrategy:
-				finalTargets = append(finalTargets, externalTargets...)
+			case latencyStrategy:
+				if health != "Healthy" && len(answer.Serving) > 0 {
+					log.Info("Executing latency strategy, local workload is unhealthy",
+						"closest", answer.Serving[0], "latency", r.latencyStatus()[answer.Serving[0]], "targets", answer.Targets)
+				}
 			case failoverStrategy:
-				// If cluster is Primary
-				if gslb.Spec.Strategy.PrimaryGeoTag == r.Config.ClusterGeoTag {
-					// If cluster is Primary and Healthy return only own targets
-					// If cluster is Primary and Unhealthy return Secondary external targets
-					if health != "Healthy" {
-						finalTargets = externalTargets
-						log.Info(fmt.Sprintf("Executing failover strategy for %s Gslb on Primary. Workload on primary %s cluster is unhealthy, targets are %v",
-							gslb.Name, gslb.Spec.Strategy.PrimaryGeoTag, finalTargets))
-					}
-				} else {
-					// If cluster is Secondary and Primary external cluster is Healthy
-					// then return Primary external targets.
-					// Return own targets by default.
-					finalTargets = externalTargets
-					log.Info(fmt.Sprintf("Executing failover strategy for %s Gslb on Secondary. Workload on primary %s cluster is healthy, targets are %v",
-						gslb.Name, gslb.Spec.Strategy.PrimaryGeoTag, finalTargets))
+				if gslb.Spec.Strategy.PrimaryGeoTag != r.Config.ClusterGeoTag {
+					log.V(logging.DebugLevel).Info("Executing failover strategy on secondary",
+						"primary", gslb.Spec.Strategy.PrimaryGeoTag, "targets", answer.Targets)
+				} else if health != "Healthy" {
+					log.Info("Executing failover strategy on primary, local workload is unhealthy",
+						"primary", gslb.Spec.Strategy.PrimaryGeoTag, "targets", answer.Targets)
 				}
 			}
 		} else {
-			log.Info(fmt.Sprintf("No external targets have been found for host %s", host))
+			log.V(logging.DebugLevel).Info("No external targets found")
 		}
+		log.V(logging.DebugLevel).Info("Final targets", "health", health, "serving", answer.Serving, "targets", answer.Targets)
+		serving[host] = answer.Serving
 
-		log.Info(fmt.Sprintf("Final target list for %s Gslb: %v", gslb.Name, finalTargets))
+		hostEndpoints, err := r.targetEndpoints(ctx, gslb, host, ttl, answer.Targets, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		gslbHosts = append(gslbHosts, hostEndpoints...)
 
-		if len(finalTargets) > 0 {
-			dnsRecord := &externaldns.Endpoint{
-				DNSName:    host,
-				RecordTTL:  ttl,
-				RecordType: "A",
-				Targets:    finalTargets,
+		if gslb.Spec.Strategy.Type == geoStrategy {
+			regionalTargets := make(map[string][]string, len(externalTargetsByGeoTag)+1)
+			for geoTag, targets := range externalTargetsByGeoTag {
+				regionalTargets[geoTag] = targets
+			}
+			if health == "Healthy" {
+				regionalTargets[r.Config.ClusterGeoTag] = localTargets[host]
+			}
+			regionalEndpoints, err := r.regionalEndpoints(ctx, gslb, host, ttl, regionalTargets)
+			if err != nil {
+				return nil, nil, err
 			}
-			gslbHosts = append(gslbHosts, dnsRecord)
+			geoEndpoints = append(geoEndpoints, regionalEndpoints...)
 		}
 	}
+	r.updateServing(ctx, gslb, serving)
+	for _, endpoint := range gslbHosts {
+		sort.Strings(endpoint.Targets)
+	}
 	dnsEndpointSpec := externaldns.DNSEndpointSpec{
 		Endpoints: gslbHosts,
 	}
@@ -197,146 +278,167 @@ func (r *GslbReconciler) gslbDNSEndpoint(gslb *k8gbv1beta1.Gslb) (*externaldns.D
 
 	err = controllerutil.SetControllerReference(gslb, dnsEndpoint, r.Scheme)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return dnsEndpoint, err
-}
-
-func (r *GslbReconciler) nsServerName() string {
-	dnsZoneIntoNS := strings.ReplaceAll(r.Config.DNSZone, ".", "-")
-	return fmt.Sprintf("gslb-ns-%s-%s.%s",
-		dnsZoneIntoNS,
-		r.Config.ClusterGeoTag,
-		r.Config.EdgeDNSZone)
+	return dnsEndpoint, geoEndpoints, err
 }
 
-func (r *GslbReconciler) nsServerNameExt() []string {
-
-	dnsZoneIntoNS := strings.ReplaceAll(r.Config.DNSZone, ".", "-")
-	var extNSServers []string
-	for _, clusterGeoTag := range r.Config.ExtClustersGeoTags {
-		extNSServers = append(extNSServers,
-			fmt.Sprintf("gslb-ns-%s-%s.%s",
-				dnsZoneIntoNS,
-				clusterGeoTag,
-				r.Config.EdgeDNSZone))
+// ensureGeoDNSEndpoint writes regional endpoints of geo strategy to <gslb>-geo DNSEndpoint served only by embedded
+// DNS server. They are kept apart from the Gslb DNSEndpoint, which Gslb status and external-dns read. The DNSEndpoint
+// is deleted when Gslb doesn't use geo strategy anymore
+func (r *GslbReconciler) ensureGeoDNSEndpoint(ctx context.Context, gslb *k8gbv1beta1.Gslb, endpoints []*externaldns.Endpoint) (*reconcile.Result, error) {
+	name := fmt.Sprintf("%s-geo", gslb.Name)
+	if gslb.Spec.Strategy.Type != geoStrategy {
+		found := &externaldns.DNSEndpoint{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: name}, found)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return &reconcile.Result{}, err
+		}
+		if !metav1.IsControlledBy(found, gslb) {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Info("Deleting geo DNSEndpoint", "dnsEndpoint", name)
+		if err = r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return &reconcile.Result{}, err
+		}
+		return nil, nil
 	}
-
-	return extNSServers
-}
-
-type fakeInfobloxConnector struct {
-	// createObjectObj interface{}
-
-	getObjectObj interface{}
-	getObjectRef string
-
-	// deleteObjectRef string
-
-	// updateObjectObj interface{}
-	// updateObjectRef string
-
-	resultObject interface{}
-
-	fakeRefReturn string
-}
-
-func (c *fakeInfobloxConnector) CreateObject(ibclient.IBObject) (string, error) {
-	return c.fakeRefReturn, nil
-}
-
-func (c *fakeInfobloxConnector) GetObject(ibclient.IBObject, string, interface{}) (err error) {
-	return nil
+	dnsEndpoint := &externaldns.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   gslb.Namespace,
+			Annotations: map[string]string{"k8gb.absa.oss/dnstype": embeddedDNSType},
+		},
+		Spec: externaldns.DNSEndpointSpec{
+			Endpoints: endpoints,
+		},
+	}
+	err := controllerutil.SetControllerReference(gslb, dnsEndpoint, r.Scheme)
+	if err != nil {
+		return &reconcile.Result{}, err
+	}
+	return r.ensureDNSEndpoint(ctx, gslb.Namespace, dnsEndpoint)
 }
 
-func (c *fakeInfobloxConnector) DeleteObject(string) (string, error) {
-	return c.fakeRefReturn, nil
+// regionalEndpoints creates endpoints of host per healthy region, labeled by region geoTag
+func (r *GslbReconciler) regionalEndpoints(ctx context.Context, gslb *k8gbv1beta1.Gslb, host string, ttl externaldns.TTL, targetsByGeoTag map[string][]string) ([]*externaldns.Endpoint, error) {
+	var endpoints []*externaldns.Endpoint
+	geoTags := make([]string, 0, len(targetsByGeoTag))
+	for geoTag := range targetsByGeoTag {
+		geoTags = append(geoTags, geoTag)
+	}
+	sort.Strings(geoTags)
+	for _, geoTag := range geoTags {
+		if len(targetsByGeoTag[geoTag]) == 0 {
+			continue
+		}
+		regional, err := r.targetEndpoints(ctx, gslb, host, ttl, targetsByGeoTag[geoTag], false)
+		if err != nil {
+			return nil, err
+		}
+		for _, ep := range regional {
+			ep.Labels = externaldns.Labels{dnsserver.GeoTagLabel: geoTag}
+		}
+		endpoints = append(endpoints, regional...)
+	}
+	return endpoints, nil
 }
 
-func (c *fakeInfobloxConnector) UpdateObject(ibclient.IBObject, string) (string, error) {
-	return c.fakeRefReturn, nil
+func (r *GslbReconciler) nsServerName() string {
+	return gslbdns.NSServerName(r.Config.ClusterGeoTag, r.Config)
 }
 
-func infobloxConnection(config *depresolver.Config) (*ibclient.ObjectManager, error) {
-	hostConfig := ibclient.HostConfig{
-		Host:     config.Infoblox.Host,
-		Version:  config.Infoblox.Version,
-		Port:     strconv.Itoa(config.Infoblox.Port),
-		Username: config.Infoblox.Username,
-		Password: config.Infoblox.Password,
-	}
-	transportConfig := ibclient.NewTransportConfig("false", 20, 10)
-	requestBuilder := &ibclient.WapiRequestBuilder{}
-	requestor := &ibclient.WapiHttpRequestor{}
-
-	var objMgr *ibclient.ObjectManager
-
-	if config.Override.FakeInfobloxEnabled {
-		fqdn := "fakezone.example.com"
-		fakeRefReturn := "zone_delegated/ZG5zLnpvbmUkLl9kZWZhdWx0LnphLmNvLmFic2EuY2Fhcy5vaG15Z2xiLmdzbGJpYmNsaWVudA:fakezone.example.com/default"
-		ohmyFakeConnector := &fakeInfobloxConnector{
-			getObjectObj: ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn}),
-			getObjectRef: "",
-			resultObject: []ibclient.ZoneDelegated{*ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn, Ref: fakeRefReturn})},
-		}
-		objMgr = ibclient.NewObjectManager(ohmyFakeConnector, "ohmyclient", "")
-	} else {
-		conn, err := ibclient.NewConnector(hostConfig, transportConfig, requestBuilder, requestor)
-		if err != nil {
-			return nil, err
-		}
-		defer func() {
-			err = conn.Logout()
-			if err != nil {
-				log.Error(err, "Failed to close connection to infoblox")
-			}
-		}()
-		objMgr = ibclient.NewObjectManager(conn, "ohmyclient", "")
+func (r *GslbReconciler) nsServerNameExt() []string {
+	var extNSServers []string
+	for _, clusterGeoTag := range r.Config.ExtClustersGeoTags {
+		extNSServers = append(extNSServers, gslbdns.NSServerName(clusterGeoTag, r.Config))
 	}
-	return objMgr, nil
+	return extNSServers
 }
 
-func checkAliveFromTXT(fqdn string, config *depresolver.Config, splitBrainThreshold time.Duration) error {
+// checkAliveFromTXT returns age of split brain TXT record and error when the record can't be found or is older
+// than splitBrainThreshold. Age is zero when the record can't be found
+func checkAliveFromTXT(ctx context.Context, fqdn string, config *depresolver.Config, splitBrainThreshold time.Duration) (time.Duration, error) {
 	m := new(dns.Msg)
 	m.SetQuestion(dns.Fqdn(fqdn), dns.TypeTXT)
-	ns := overrideWithFakeDNS(config.Override.FakeDNSEnabled, config.EdgeDNSServer)
-	txt, err := dns.Exchange(m, ns)
+	ns := overrideWithFakeDNS(config.Override, config.EdgeDNSServer, "")
+	log := logging.FromContext(ctx).WithValues("record", fqdn)
+	txt, err := dns.ExchangeContext(ctx, m, ns)
 	if err != nil {
-		log.Info(fmt.Sprintf("Error contacting EdgeDNS server (%s) for TXT split brain record: (%s)", ns, err))
-		return err
+		log.Info("Can't contact EdgeDNS server for split brain TXT record", "server", ns, "error", err.Error())
+		return 0, err
 	}
 	var timestamp string
 	if len(txt.Answer) > 0 {
 		if t, ok := txt.Answer[0].(*dns.TXT); ok {
-			log.Info(fmt.Sprintf("Split brain TXT raw record: %s", t.String()))
+			log.V(logging.DebugLevel).Info("Found split brain TXT record", "raw", t.String())
 			timestamp = strings.Split(t.String(), "\t")[4]
 			timestamp = strings.Trim(timestamp, "\"") // Otherwise time.Parse() will miserably fail
 		}
 	}
 
 	if len(timestamp) > 0 {
-		log.Info(fmt.Sprintf("Split brain TXT raw time stamp: %s", timestamp))
-		timeFromTXT, err := time.Parse("2006-01-02T15:04:05", timestamp)
+		timeFromTXT, err := time.Parse(gslbdns.HeartbeatTimestampLayout, timestamp)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
-		log.Info(fmt.Sprintf("Split brain TXT parsed time stamp: %s", timeFromTXT))
 		now := time.Now().UTC()
 
 		diff := now.Sub(timeFromTXT)
-		log.Info(fmt.Sprintf("Split brain TXT time diff: %s", diff))
+		log.V(logging.DebugLevel).Info("Split brain TXT record age", "timestamp", timestamp, "age", diff.String())
 
 		if diff > splitBrainThreshold {
-			return errors.NewGone(fmt.Sprintf("Split brain TXT record expired the time threshold: (%s)", splitBrainThreshold))
+			return diff, errors.NewGone(fmt.Sprintf("Split brain TXT record expired the time threshold: (%s)", splitBrainThreshold))
 		}
 
-		return nil
+		return diff, nil
 	}
-	return errors.NewGone(fmt.Sprintf("Can't find split brain TXT record at EdgeDNS server(%s) and record %s ", ns, fqdn))
+	return 0, errors.NewGone(fmt.Sprintf("Can't find split brain TXT record at EdgeDNS server(%s) and record %s ", ns, fqdn))
 
 }
 
+// checkPeerAlive checks split brain TXT record of the cluster in other location and updates its heartbeat metrics
+func (r *GslbReconciler) checkPeerAlive(ctx context.Context, gslb *k8gbv1beta1.Gslb, geoTag, fqdn string) (err error) {
+	ctx, span := tracing.Start(ctx, "peer.CheckHeartbeat", tracing.PeerKey.String(geoTag))
+	defer func() { tracing.End(span, err) }()
+	ctx = logging.IntoContext(ctx, logging.FromContext(ctx).WithValues(logging.PeerKey, geoTag))
+	splitBrainThreshold := time.Second * time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+	age, err := checkAliveFromTXT(ctx, fqdn, r.Config, splitBrainThreshold)
+	switch {
+	case err == nil:
+		r.Metrics.UpdatePeerHeartbeatAgeMetric(gslb, geoTag, age)
+	case age > splitBrainThreshold:
+		r.Metrics.UpdatePeerHeartbeatAgeMetric(gslb, geoTag, age)
+		r.Metrics.IncrementSplitBrainExpiredMetric(gslb, geoTag)
+	default:
+		r.Metrics.DeletePeerHeartbeatAgeMetric(gslb, geoTag)
+	}
+	return err
+}
+
+// checkPeersAlive checks split brain TXT records of clusters in other locations on every reconciliation and returns
+// geoTags of clusters which don't look alive. Infoblox clusters write the records directly, Route53 and NS1 clusters
+// publish them through external-dns
+func (r *GslbReconciler) checkPeersAlive(ctx context.Context, gslb *k8gbv1beta1.Gslb) (stale []string) {
+	if r.Config.EdgeDNSType == depresolver.DNSTypeNoEdgeDNS {
+		// nobody publishes split brain TXT records without EdgeDNS
+		return nil
+	}
+	for i, fqdn := range getExternalClusterHeartbeatFQDNs(gslb, r.Config) {
+		geoTag := r.Config.ExtClustersGeoTags[i]
+		if err := r.checkPeerAlive(ctx, gslb, geoTag, fqdn); err != nil {
+			logging.FromContext(ctx).V(logging.DebugLevel).Info("External cluster doesn't look alive",
+				logging.PeerKey, geoTag, "error", err.Error())
+			stale = append(stale, geoTag)
+		}
+	}
+	return stale
+}
+
 func filterOutDelegateTo(delegateTo []ibclient.NameServer, fqdn string) []ibclient.NameServer {
 	for i := 0; i < len(delegateTo); i++ {
 		if delegateTo[i].Name == fqdn {
@@ -347,13 +449,14 @@ func filterOutDelegateTo(delegateTo []ibclient.NameServer, fqdn string) []ibclie
 	return delegateTo
 }
 
-func (r *GslbReconciler) coreDNSExposedIPs() ([]string, error) {
+func (r *GslbReconciler) coreDNSExposedIPs(ctx context.Context) ([]string, error) {
 	coreDNSService := &corev1.Service{}
+	log := logging.FromContext(ctx).WithValues("service", coreDNSExtServiceName)
 
-	err := r.Get(context.TODO(), types.NamespacedName{Namespace: r.Config.K8gbNamespace, Name: coreDNSExtServiceName}, coreDNSService)
+	err := r.Get(ctx, types.NamespacedName{Namespace: r.Config.K8gbNamespace, Name: coreDNSExtServiceName}, coreDNSService)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			log.Info(fmt.Sprintf("Can't find %s service", coreDNSExtServiceName))
+			log.Info("Can't find CoreDNS Service")
 		}
 		return nil, err
 	}
@@ -361,22 +464,20 @@ func (r *GslbReconciler) coreDNSExposedIPs() ([]string, error) {
 	if len(coreDNSService.Status.LoadBalancer.Ingress) > 0 {
 		lbHostname = coreDNSService.Status.LoadBalancer.Ingress[0].Hostname
 	} else {
-		errMessage := fmt.Sprintf("no Ingress LoadBalancer entries found for %s serice", coreDNSExtServiceName)
-		log.Info(errMessage)
-		err := coreerrors.New(errMessage)
-		return nil, err
+		log.Info("CoreDNS Service has no load balancer address")
+		return nil, fmt.Errorf("no Ingress LoadBalancer entries found for %s service", coreDNSExtServiceName)
 	}
-	IPs, err := utils.Dig(r.Config.EdgeDNSServer, lbHostname)
+	IPs, err := utils.Dig(ctx, r.Config.EdgeDNSServer, lbHostname)
 	if err != nil {
-		log.Info(fmt.Sprintf("Can't dig k8gb-coredns-lb service loadbalancer fqdn %s (%s)", lbHostname, err))
+		log.Info("Can't resolve CoreDNS Service load balancer hostname", "hostname", lbHostname, "error", err.Error())
 		return nil, err
 	}
 	return IPs, nil
 }
 
-func (r *GslbReconciler) createZoneDelegationRecordsForExternalDNS(gslb *k8gbv1beta1.Gslb, dnsProvider string) (*reconcile.Result, error) {
+func (r *GslbReconciler) createZoneDelegationRecordsForExternalDNS(ctx context.Context, gslb *k8gbv1beta1.Gslb, dnsProvider string) (*reconcile.Result, error) {
 	ttl := externaldns.TTL(gslb.Spec.Strategy.DNSTtlSeconds)
-	log.Info(fmt.Sprintf("Creating/Updating DNSEndpoint CRDs for %s...", dnsProvider))
+	logging.FromContext(ctx).V(logging.DebugLevel).Info("Ensuring zone delegation DNSEndpoint", "provider", dnsProvider)
 	var NSServerList []string
 	NSServerList = append(NSServerList, r.nsServerName())
 	NSServerList = append(NSServerList, r.nsServerNameExt()...)
@@ -384,14 +485,28 @@ func (r *GslbReconciler) createZoneDelegationRecordsForExternalDNS(gslb *k8gbv1b
 	var NSServerIPs []string
 	var err error
 	if r.Config.CoreDNSExposed {
-		NSServerIPs, err = r.coreDNSExposedIPs()
+		NSServerIPs, err = r.coreDNSExposedIPs(ctx)
 	} else {
-		NSServerIPs, err = r.getGslbIngressIPs(gslb)
+		NSServerIPs, err = r.getLocalIPs(ctx, gslb)
 
 	}
 	if err != nil {
 		return &reconcile.Result{}, err
 	}
+	endpoints := []*externaldns.Endpoint{
+		{
+			DNSName:    r.Config.DNSZone,
+			RecordTTL:  ttl,
+			RecordType: "NS",
+			Targets:    NSServerList,
+		},
+	}
+	endpoints = append(endpoints, addressEndpoints(r.nsServerName(), ttl, NSServerIPs, true)...)
+	heartbeats, err := r.heartbeatEndpoints(ctx, gslb, dnsProvider, ttl)
+	if err != nil {
+		return &reconcile.Result{}, err
+	}
+	endpoints = append(endpoints, heartbeats...)
 	NSRecord := &externaldns.DNSEndpoint{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        fmt.Sprintf("k8gb-ns-%s", dnsProvider),
@@ -399,41 +514,58 @@ func (r *GslbReconciler) createZoneDelegationRecordsForExternalDNS(gslb *k8gbv1b
 			Annotations: map[string]string{"k8gb.absa.oss/dnstype": dnsProvider},
 		},
 		Spec: externaldns.DNSEndpointSpec{
-			Endpoints: []*externaldns.Endpoint{
-				{
-					DNSName:    r.Config.DNSZone,
-					RecordTTL:  ttl,
-					RecordType: "NS",
-					Targets:    NSServerList,
-				},
-				{
-					DNSName:    r.nsServerName(),
-					RecordTTL:  ttl,
-					RecordType: "A",
-					Targets:    NSServerIPs,
-				},
-			},
+			Endpoints: endpoints,
 		},
 	}
-	res, err := r.ensureDNSEndpoint(r.Config.K8gbNamespace, NSRecord)
+	res, err := r.ensureDNSEndpoint(ctx, r.Config.K8gbNamespace, NSRecord)
 	if err != nil {
 		return res, err
 	}
 	return nil, nil
 }
 
-func (r *GslbReconciler) configureZoneDelegation(gslb *k8gbv1beta1.Gslb) (*reconcile.Result, error) {
+// heartbeatEndpoints returns split brain TXT records of the cluster to publish through external-dns. Zone delegation
+// DNSEndpoint is shared by all Gslbs, so heartbeats of other Gslbs are kept and heartbeat of gslb is refreshed
+func (r *GslbReconciler) heartbeatEndpoints(ctx context.Context, gslb *k8gbv1beta1.Gslb, dnsProvider string,
+	ttl externaldns.TTL) ([]*externaldns.Endpoint, error) {
+	heartbeatTXTName := gslbdns.HeartbeatTXTName(gslb.Name, r.Config.ClusterGeoTag, r.Config)
+	heartbeats := []*externaldns.Endpoint{
+		{
+			DNSName:    heartbeatTXTName,
+			RecordTTL:  ttl,
+			RecordType: "TXT",
+			Targets:    externaldns.Targets{time.Now().UTC().Format(gslbdns.HeartbeatTimestampLayout)},
+		},
+	}
+	found := &externaldns.DNSEndpoint{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: r.Config.K8gbNamespace, Name: fmt.Sprintf("k8gb-ns-%s", dnsProvider)}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	ownSuffix := fmt.Sprintf("-heartbeat-%s.%s", r.Config.ClusterGeoTag, r.Config.EdgeDNSZone)
+	for _, ep := range found.Spec.Endpoints {
+		if ep.RecordType == "TXT" && ep.DNSName != heartbeatTXTName && strings.HasSuffix(ep.DNSName, ownSuffix) {
+			heartbeats = append(heartbeats, ep)
+		}
+	}
+	sort.Slice(heartbeats, func(i, j int) bool { return heartbeats[i].DNSName < heartbeats[j].DNSName })
+	return heartbeats, nil
+}
+
+func (r *GslbReconciler) configureZoneDelegation(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*reconcile.Result, error) {
+	stale := r.checkPeersAlive(ctx, gslb)
 	switch r.Config.EdgeDNSType {
 	case depresolver.DNSTypeRoute53:
-		return r.createZoneDelegationRecordsForExternalDNS(gslb, "route53")
+		return r.createZoneDelegationRecordsForExternalDNS(ctx, gslb, "route53")
 	case depresolver.DNSTypeNS1:
-		return r.createZoneDelegationRecordsForExternalDNS(gslb, "ns1")
+		return r.createZoneDelegationRecordsForExternalDNS(ctx, gslb, "ns1")
 	case depresolver.DNSTypeInfoblox:
-		objMgr, err := infobloxConnection(r.Config)
+		log := logging.FromContext(ctx).WithValues("zone", r.Config.DNSZone)
+		objMgr, err := r.infobloxConnection(ctx)
 		if err != nil {
 			return &reconcile.Result{}, err
 		}
-		addresses, err := r.getGslbIngressIPs(gslb)
+		addresses, err := r.getLocalIPs(ctx, gslb)
 		if err != nil {
 			return &reconcile.Result{}, err
 		}
@@ -460,18 +592,16 @@ func (r *GslbReconciler) configureZoneDelegation(gslb *k8gbv1beta1.Gslb) (*recon
 				existingDelegateTo := filterOutDelegateTo(findZone.DelegateTo, r.nsServerName())
 				existingDelegateTo = append(existingDelegateTo, delegateTo...)
 
-				// Drop external records if they are stale
-				extClusters := getExternalClusterHeartbeatFQDNs(gslb, r.Config)
-				for _, extCluster := range extClusters {
-					err = checkAliveFromTXT(extCluster, r.Config, time.Second*time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds))
-					if err != nil {
-						log.Error(err, "got the error from TXT based checkAlive")
-						log.Info(fmt.Sprintf("External cluster (%s) doesn't look alive, filtering it out from delegated zone configuration...",
-							extCluster))
-						existingDelegateTo = filterOutDelegateTo(existingDelegateTo, extCluster)
+				// Drop external records if they are stale. Records are matched by name server of the cluster,
+				// heartbeat FQDN is only used to check the cluster is alive
+				extNSServers := r.nsServerNameExt()
+				for i, geoTag := range r.Config.ExtClustersGeoTags {
+					if contains(stale, geoTag) {
+						log.Info("External cluster doesn't look alive, filtering it out from delegated zone", logging.PeerKey, geoTag)
+						existingDelegateTo = filterOutDelegateTo(existingDelegateTo, extNSServers[i])
 					}
 				}
-				log.Info(fmt.Sprintf("Updating delegated zone(%s) with the server list(%v)", r.Config.DNSZone, existingDelegateTo))
+				log.V(logging.DebugLevel).Info("Updating delegated zone", "delegateTo", existingDelegateTo)
 
 				_, err = objMgr.UpdateZoneDelegated(findZone.Ref, existingDelegateTo)
 				if err != nil {
@@ -479,32 +609,33 @@ func (r *GslbReconciler) configureZoneDelegation(gslb *k8gbv1beta1.Gslb) (*recon
 				}
 			}
 		} else {
-			log.Info(fmt.Sprintf("Creating delegated zone(%s)...", r.Config.DNSZone))
+			log.Info("Creating delegated zone", "delegateTo", delegateTo)
 			_, err = objMgr.CreateZoneDelegated(r.Config.DNSZone, delegateTo)
 			if err != nil {
 				return &reconcile.Result{}, err
 			}
 		}
 
-		edgeTimestamp := fmt.Sprint(time.Now().UTC().Format("2006-01-02T15:04:05"))
-		heartbeatTXTName := fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, r.Config.ClusterGeoTag, r.Config.EdgeDNSZone)
+		edgeTimestamp := time.Now().UTC().Format(gslbdns.HeartbeatTimestampLayout)
+		heartbeatTXTName := gslbdns.HeartbeatTXTName(gslb.Name, r.Config.ClusterGeoTag, r.Config)
 		heartbeatTXTRecord, err := objMgr.GetTXTRecord(heartbeatTXTName)
 		if err != nil {
 			return &reconcile.Result{}, err
 		}
 		if heartbeatTXTRecord == nil {
-			log.Info(fmt.Sprintf("Creating split brain TXT record(%s)...", heartbeatTXTName))
+			log.Info("Creating split brain TXT record", "record", heartbeatTXTName)
 			_, err := objMgr.CreateTXTRecord(heartbeatTXTName, edgeTimestamp, gslb.Spec.Strategy.DNSTtlSeconds, "default")
 			if err != nil {
 				return &reconcile.Result{}, err
 			}
 		} else {
-			log.Info(fmt.Sprintf("Updating split brain TXT record(%s)...", heartbeatTXTName))
+			log.V(logging.DebugLevel).Info("Updating split brain TXT record", "record", heartbeatTXTName)
 			_, err := objMgr.UpdateTXTRecord(heartbeatTXTName, edgeTimestamp)
 			if err != nil {
 				return &reconcile.Result{}, err
 			}
 		}
+		return nil, nil
 	case depresolver.DNSTypeNoEdgeDNS:
 		return nil, nil
 	}
@@ -512,25 +643,29 @@ func (r *GslbReconciler) configureZoneDelegation(gslb *k8gbv1beta1.Gslb) (*recon
 }
 
 func (r *GslbReconciler) ensureDNSEndpoint(
+	ctx context.Context,
 	namespace string,
 	i *externaldns.DNSEndpoint,
 ) (*reconcile.Result, error) {
 	found := &externaldns.DNSEndpoint{}
-	err := r.Get(context.TODO(), types.NamespacedName{
+	log := logging.FromContext(ctx).WithValues("dnsEndpoint", i.Name, "dnsEndpointNamespace", namespace)
+	err := r.Get(ctx, types.NamespacedName{
 		Name:      i.Name,
 		Namespace: namespace,
 	}, found)
 	if err != nil && errors.IsNotFound(err) {
 
 		// Create the DNSEndpoint
-		log.Info(fmt.Sprintf("Creating a new DNSEndpoint:\n %s", utils.ToString(i)))
-		err = r.Create(context.TODO(), i)
+		log.Info("Creating DNSEndpoint")
+		log.V(logging.DebugLevel).Info("DNSEndpoint records", "endpoints", i.Spec.Endpoints)
+		err = r.Create(ctx, i)
 
 		if err != nil {
 			// Creation failed
-			log.Error(err, "Failed to create new DNSEndpoint", "DNSEndpoint.Namespace", i.Namespace, "DNSEndpoint.Name", i.Name)
+			log.Error(err, "Failed to create DNSEndpoint")
 			return &reconcile.Result{}, err
 		}
+		r.Metrics.IncrementWritesMetric(namespace, i.Name, "DNSEndpoint", metrics.WriteApplied)
 		// Creation was successful
 		return nil, nil
 	} else if err != nil {
@@ -539,15 +674,23 @@ func (r *GslbReconciler) ensureDNSEndpoint(
 		return &reconcile.Result{}, err
 	}
 
+	// Skip update when records didn't change, every write triggers external-dns synchronization
+	if equality.Semantic.DeepEqual(found.Spec, i.Spec) {
+		r.Metrics.IncrementWritesMetric(namespace, i.Name, "DNSEndpoint", metrics.WriteSkipped)
+		return nil, nil
+	}
+
 	// Update existing object with new spec
 	found.Spec = i.Spec
-	err = r.Update(context.TODO(), found)
+	log.V(logging.DebugLevel).Info("Updating DNSEndpoint records", "endpoints", i.Spec.Endpoints)
+	err = r.Update(ctx, found)
 
 	if err != nil {
 		// Update failed
-		log.Error(err, "Failed to update DNSEndpoint", "DNSEndpoint.Namespace", found.Namespace, "DNSEndpoint.Name", found.Name)
+		log.Error(err, "Failed to update DNSEndpoint")
 		return &reconcile.Result{}, err
 	}
+	r.Metrics.IncrementWritesMetric(namespace, i.Name, "DNSEndpoint", metrics.WriteApplied)
 
 	return nil, nil
 }
@@ -560,11 +703,14 @@ func checkZoneDelegated(findZone *ibclient.ZoneDelegated, gslbZoneName string) e
 	return nil
 }
 
-func overrideWithFakeDNS(fakeDNSEnabled bool, server string) (ns string) {
-	if fakeDNSEnabled {
-		ns = "127.0.0.1:7753"
-	} else {
-		ns = fmt.Sprintf("%s:53", server)
+// overrideWithFakeDNS returns address of DNS server to query. When fake DNS is enabled, external cluster identified
+// by geoTag is queried at its own fake server if configured; edge DNS passes empty geoTag
+func overrideWithFakeDNS(override depresolver.Override, server string, geoTag string) (ns string) {
+	if override.FakeDNSEnabled {
+		if peer, found := override.FakeDNSPeers[geoTag]; found {
+			return peer
+		}
+		return override.FakeDNSAddress
 	}
-	return
+	return fmt.Sprintf("%s:53", server)
 }
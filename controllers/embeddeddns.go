@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AbsaOSS/k8gb/controllers/dnsserver"
+	"github.com/AbsaOSS/k8gb/controllers/geoip"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// embeddedDNSTtlSeconds is TTL of SOA and NS records served by embedded DNS server
+const embeddedDNSTtlSeconds = 30
+
+// embeddedDNSType annotates DNSEndpoints served only by embedded DNS server, e.g. regional endpoints of geo strategy.
+// DNSEndpoints annotated local are served as well, CoreDNS serves them when embedded DNS is disabled
+const embeddedDNSType = "embedded"
+
+// SetupEmbeddedDNS creates authoritative DNS server for DNSZone and registers it within manager. All k8gb
+// clusters are authoritative name servers of the zone, local cluster is the primary one
+func (r *GslbReconciler) SetupEmbeddedDNS(mgr ctrl.Manager) error {
+	config := dnsserver.Config{
+		Address:     r.Config.EmbeddedDNS.Address,
+		Zone:        r.Config.DNSZone,
+		NameServers: append([]string{r.nsServerName()}, r.nsServerNameExt()...),
+		TTL:         embeddedDNSTtlSeconds,
+	}
+	if r.Config.GeoIP.Database != "" {
+		// the database stays open for the lifetime of the operator
+		resolver, err := geoip.NewResolver(r.Config.GeoIP.Database, r.Config.GeoIP.Mapping)
+		if err != nil {
+			return err
+		}
+		config.GeoTags = resolver
+		log.Info("Answering geo strategy by client location", "database", r.Config.GeoIP.Database)
+	}
+	r.DNSServer = dnsserver.NewServer(config)
+	if err := mgr.Add(r.DNSServer); err != nil {
+		return err
+	}
+	return mgr.Add(&embeddedDNSFeed{cache: mgr.GetCache(), server: r.DNSServer})
+}
+
+// embeddedDNSFeed sets endpoints of DNSEndpoints written by the leader to embedded DNS server. It runs on every
+// replica of the operator, so followers serve the same records as the leader
+type embeddedDNSFeed struct {
+	cache  cache.Cache
+	server *dnsserver.Server
+}
+
+// NeedLeaderElection implements controller-runtime LeaderElectionRunnable
+func (f *embeddedDNSFeed) NeedLeaderElection() bool {
+	return false
+}
+
+// Start feeds the server from DNSEndpoint informer until stop is closed. The zone is loaded when the cache syncs
+func (f *embeddedDNSFeed) Start(stop <-chan struct{}) error {
+	informer, err := f.cache.GetInformer(context.Background(), &externaldns.DNSEndpoint{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { serveDNSEndpoint(f.server, obj) },
+		UpdateFunc: func(_, obj interface{}) { serveDNSEndpoint(f.server, obj) },
+		DeleteFunc: func(obj interface{}) { stopServingDNSEndpoint(f.server, obj) },
+	})
+	if !f.cache.WaitForCacheSync(stop) {
+		return fmt.Errorf("DNSEndpoint cache of embedded DNS server didn't sync")
+	}
+	if err = loadEmbeddedDNS(context.Background(), f.cache, f.server); err != nil {
+		return err
+	}
+	<-stop
+	return nil
+}
+
+// loadEmbeddedDNS sets endpoints of all served DNSEndpoints to the server and marks the zone loaded
+func loadEmbeddedDNS(ctx context.Context, c client.Reader, server *dnsserver.Server) error {
+	dnsEndpoints := &externaldns.DNSEndpointList{}
+	if err := c.List(ctx, dnsEndpoints); err != nil {
+		return err
+	}
+	for i := range dnsEndpoints.Items {
+		serveDNSEndpoint(server, &dnsEndpoints.Items[i])
+	}
+	server.SetLoaded()
+	return nil
+}
+
+// serveDNSEndpoint sets endpoints of DNSEndpoint to the server, DNSEndpoints of other dnstype are not served
+func serveDNSEndpoint(server *dnsserver.Server, obj interface{}) {
+	dnsEndpoint, ok := obj.(*externaldns.DNSEndpoint)
+	if !ok {
+		return
+	}
+	owner := client.ObjectKey{Namespace: dnsEndpoint.Namespace, Name: dnsEndpoint.Name}.String()
+	switch dnsEndpoint.Annotations["k8gb.absa.oss/dnstype"] {
+	case "local", embeddedDNSType:
+		server.SetEndpoints(owner, dnsEndpoint.Spec.Endpoints)
+	default:
+		server.RemoveEndpoints(owner)
+	}
+}
+
+// stopServingDNSEndpoint removes endpoints of deleted DNSEndpoint from the server
+func stopServingDNSEndpoint(server *dnsserver.Server, obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	dnsEndpoint, ok := obj.(*externaldns.DNSEndpoint)
+	if !ok {
+		return
+	}
+	server.RemoveEndpoints(client.ObjectKey{Namespace: dnsEndpoint.Namespace, Name: dnsEndpoint.Name}.String())
+}
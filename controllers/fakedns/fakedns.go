@@ -0,0 +1,208 @@
+// Package fakedns provides DNS server with records programmed at runtime, used by tests and local development
+// instead of edge DNS and k8gb clusters. Several servers simulate several clusters in one process
+package fakedns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// TTL of served records
+const TTL = 30
+
+type record struct {
+	rr dns.RR
+	// expires is zero when record doesn't expire
+	expires time.Time
+}
+
+// Server answers A, AAAA, CNAME, TXT and NS queries over UDP and TCP
+type Server struct {
+	sync.RWMutex
+	records  map[string]map[uint16][]record
+	latency  time.Duration
+	rcode    int
+	truncate bool
+	udp      *dns.Server
+	tcp      *dns.Server
+}
+
+// New starts server on random port of loopback interface
+func New() (*Server, error) {
+	return NewWithAddress("127.0.0.1:0")
+}
+
+// NewWithAddress starts server on address; e.g. 127.0.0.1:7753. UDP and TCP listen on the same port
+func NewWithAddress(address string) (*Server, error) {
+	pc, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen udp %s: %w", address, err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		_ = pc.Close()
+		return nil, fmt.Errorf("can't listen tcp %s: %w", pc.LocalAddr(), err)
+	}
+	s := &Server{records: make(map[string]map[uint16][]record)}
+	s.udp = &dns.Server{PacketConn: pc, Handler: s}
+	s.tcp = &dns.Server{Listener: l, Handler: s}
+	for _, server := range []*dns.Server{s.udp, s.tcp} {
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go func(server *dns.Server) {
+			_ = server.ActivateAndServe()
+		}(server)
+		<-started
+	}
+	return s, nil
+}
+
+// Address returns host:port the server listens on
+func (s *Server) Address() string {
+	return s.udp.PacketConn.LocalAddr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	err := s.udp.Shutdown()
+	if tcpErr := s.tcp.Shutdown(); err == nil {
+		err = tcpErr
+	}
+	return err
+}
+
+// Add adds records of given type to name. Values are in presentation format; e.g. 10.0.0.1 for A,
+// target.example.com. for CNAME or NS and text for TXT
+func (s *Server) Add(name string, rrType uint16, values ...string) error {
+	name = dns.CanonicalName(name)
+	var rrs []dns.RR
+	for _, value := range values {
+		if rrType == dns.TypeTXT {
+			value = strconv.Quote(value)
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, TTL, dns.TypeToString[rrType], value))
+		if err != nil {
+			return err
+		}
+		if rr == nil {
+			return fmt.Errorf("empty %s value for %s", dns.TypeToString[rrType], name)
+		}
+		rrs = append(rrs, rr)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.records[name] == nil {
+		s.records[name] = make(map[uint16][]record)
+	}
+	for _, rr := range rrs {
+		s.records[name][rrType] = append(s.records[name][rrType], record{rr: rr})
+	}
+	return nil
+}
+
+// Set replaces records of given type of name by values
+func (s *Server) Set(name string, rrType uint16, values ...string) error {
+	s.Remove(name, rrType)
+	return s.Add(name, rrType, values...)
+}
+
+// Remove removes records of given type of name
+func (s *Server) Remove(name string, rrType uint16) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.records[dns.CanonicalName(name)], rrType)
+}
+
+// Expire stops serving records of given type of name after the duration
+func (s *Server) Expire(name string, rrType uint16, after time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	expires := time.Now().Add(after)
+	rrs := s.records[dns.CanonicalName(name)][rrType]
+	for i := range rrs {
+		rrs[i].expires = expires
+	}
+}
+
+// Reset removes all records and faults
+func (s *Server) Reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.records = make(map[string]map[uint16][]record)
+	s.latency = 0
+	s.rcode = dns.RcodeSuccess
+	s.truncate = false
+}
+
+// SetLatency delays every answer
+func (s *Server) SetLatency(latency time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.latency = latency
+}
+
+// SetFailure answers every query with rcode; e.g. dns.RcodeServerFailure. dns.RcodeSuccess stops failing
+func (s *Server) SetFailure(rcode int) {
+	s.Lock()
+	defer s.Unlock()
+	s.rcode = rcode
+}
+
+// SetTruncate answers UDP queries with empty truncated message, so clients have to retry over TCP
+func (s *Server) SetTruncate(truncate bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.truncate = truncate
+}
+
+// ServeDNS implements dns.Handler
+func (s *Server) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	s.RLock()
+	latency, rcode, truncate := s.latency, s.rcode, s.truncate
+	s.RUnlock()
+	time.Sleep(latency)
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+	_, udp := w.RemoteAddr().(*net.UDPAddr)
+	switch {
+	case rcode != dns.RcodeSuccess:
+		m.Rcode = rcode
+	case truncate && udp:
+		m.Truncated = true
+	default:
+		for _, q := range req.Question {
+			m.Answer = append(m.Answer, s.answer(q)...)
+		}
+	}
+	_ = w.WriteMsg(m)
+}
+
+// answer returns records of question, or CNAME of the name when asking for addresses
+func (s *Server) answer(q dns.Question) []dns.RR {
+	s.RLock()
+	defer s.RUnlock()
+	name := strings.ToLower(q.Name)
+	rrs := s.active(name, q.Qtype)
+	if len(rrs) == 0 && (q.Qtype == dns.TypeA || q.Qtype == dns.TypeAAAA) {
+		rrs = s.active(name, dns.TypeCNAME)
+	}
+	return rrs
+}
+
+// active returns copies of records which didn't expire. Caller must hold the lock
+func (s *Server) active(name string, rrType uint16) (rrs []dns.RR) {
+	now := time.Now()
+	for _, r := range s.records[name][rrType] {
+		if r.expires.IsZero() || now.Before(r.expires) {
+			rrs = append(rrs, dns.Copy(r.rr))
+		}
+	}
+	return rrs
+}
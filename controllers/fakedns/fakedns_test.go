@@ -0,0 +1,199 @@
+package fakedns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAnswersAddedRecords(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("localtargets-app.cloud.example.com", dns.TypeA, "10.0.0.1", "10.0.0.2"))
+	require.NoError(t, s.Add("localtargets-app.cloud.example.com", dns.TypeAAAA, "2001:db8::1"))
+	require.NoError(t, s.Add("heartbeat-eu.example.com", dns.TypeTXT, "2021-01-01T00:00:00"))
+	require.NoError(t, s.Add("cloud.example.com", dns.TypeNS, "gslb-ns-eu.example.com."))
+	// act
+	a := exchange(t, "udp", s.Address(), "LocalTargets-app.cloud.example.com.", dns.TypeA)
+	aaaa := exchange(t, "udp", s.Address(), "localtargets-app.cloud.example.com.", dns.TypeAAAA)
+	txt := exchange(t, "udp", s.Address(), "heartbeat-eu.example.com.", dns.TypeTXT)
+	ns := exchange(t, "tcp", s.Address(), "cloud.example.com.", dns.TypeNS)
+	unknown := exchange(t, "udp", s.Address(), "unknown.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, values(a))
+	assert.Equal(t, []string{"2001:db8::1"}, values(aaaa))
+	assert.Equal(t, []string{"2021-01-01T00:00:00"}, values(txt))
+	assert.Equal(t, []string{"gslb-ns-eu.example.com."}, values(ns))
+	assert.Equal(t, dns.RcodeSuccess, unknown.Rcode)
+	assert.Empty(t, unknown.Answer)
+}
+
+func TestServerAnswersCNAMEForAddresses(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("localtargets-elb.cloud.example.com", dns.TypeCNAME, "k8gb-eu.elb.amazonaws.com."))
+	// act
+	r := exchange(t, "udp", s.Address(), "localtargets-elb.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, []string{"k8gb-eu.elb.amazonaws.com."}, values(r))
+}
+
+func TestServerRejectsInvalidValue(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	// act
+	err := s.Add("app.cloud.example.com", dns.TypeA, "not-an-ip")
+	// assert
+	assert.Error(t, err)
+}
+
+func TestServerSetsAndRemovesRecords(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	// act
+	require.NoError(t, s.Set("app.cloud.example.com", dns.TypeA, "10.0.0.2"))
+	replaced := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	s.Remove("app.cloud.example.com", dns.TypeA)
+	removed := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, []string{"10.0.0.2"}, values(replaced))
+	assert.Empty(t, removed.Answer)
+}
+
+func TestServerStopsServingExpiredRecords(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("heartbeat-eu.example.com", dns.TypeTXT, "2021-01-01T00:00:00"))
+	require.NoError(t, s.Add("heartbeat-za.example.com", dns.TypeTXT, "2021-01-01T00:00:00"))
+	// act
+	s.Expire("heartbeat-eu.example.com", dns.TypeTXT, 0)
+	s.Expire("heartbeat-za.example.com", dns.TypeTXT, time.Hour)
+	expired := exchange(t, "udp", s.Address(), "heartbeat-eu.example.com.", dns.TypeTXT)
+	active := exchange(t, "udp", s.Address(), "heartbeat-za.example.com.", dns.TypeTXT)
+	// assert
+	assert.Empty(t, expired.Answer)
+	assert.Len(t, active.Answer, 1)
+}
+
+func TestServerInjectsLatency(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	s.SetLatency(100 * time.Millisecond)
+	m := new(dns.Msg)
+	m.SetQuestion("app.cloud.example.com.", dns.TypeA)
+	// act
+	r, rtt, err := new(dns.Client).Exchange(m, s.Address())
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, values(r))
+	assert.GreaterOrEqual(t, int64(rtt), int64(100*time.Millisecond))
+}
+
+func TestServerInjectsFailure(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	// act
+	s.SetFailure(dns.RcodeServerFailure)
+	failed := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	s.SetFailure(dns.RcodeSuccess)
+	recovered := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, dns.RcodeServerFailure, failed.Rcode)
+	assert.Empty(t, failed.Answer)
+	assert.Equal(t, []string{"10.0.0.1"}, values(recovered))
+}
+
+func TestServerTruncatesUDPAnswers(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	s.SetTruncate(true)
+	// act
+	udp := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	tcp := exchange(t, "tcp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.True(t, udp.Truncated)
+	assert.Empty(t, udp.Answer)
+	assert.False(t, tcp.Truncated)
+	assert.Equal(t, []string{"10.0.0.1"}, values(tcp))
+}
+
+func TestServersSimulateSeveralClusters(t *testing.T) {
+	// arrange
+	eu := startServer(t)
+	defer eu.Close()
+	us := startServer(t)
+	defer us.Close()
+	require.NoError(t, eu.Add("localtargets-app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	require.NoError(t, us.Add("localtargets-app.cloud.example.com", dns.TypeA, "10.1.0.1"))
+	// act
+	euAnswer := exchange(t, "udp", eu.Address(), "localtargets-app.cloud.example.com.", dns.TypeA)
+	usAnswer := exchange(t, "udp", us.Address(), "localtargets-app.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.NotEqual(t, eu.Address(), us.Address())
+	assert.Equal(t, []string{"10.0.0.1"}, values(euAnswer))
+	assert.Equal(t, []string{"10.1.0.1"}, values(usAnswer))
+}
+
+func TestServerResetsRecordsAndFaults(t *testing.T) {
+	// arrange
+	s := startServer(t)
+	defer s.Close()
+	require.NoError(t, s.Add("app.cloud.example.com", dns.TypeA, "10.0.0.1"))
+	s.SetFailure(dns.RcodeRefused)
+	// act
+	s.Reset()
+	r := exchange(t, "udp", s.Address(), "app.cloud.example.com.", dns.TypeA)
+	// assert
+	assert.Equal(t, dns.RcodeSuccess, r.Rcode)
+	assert.Empty(t, r.Answer)
+}
+
+func startServer(t *testing.T) *Server {
+	t.Helper()
+	s, err := New()
+	require.NoError(t, err)
+	return s
+}
+
+func exchange(t *testing.T, net, addr, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	r, _, err := (&dns.Client{Net: net}).Exchange(m, addr)
+	require.NoError(t, err)
+	return r
+}
+
+// values returns record data without header; e.g. 10.0.0.1 of A record
+func values(m *dns.Msg) (values []string) {
+	for _, rr := range m.Answer {
+		switch record := rr.(type) {
+		case *dns.A:
+			values = append(values, record.A.String())
+		case *dns.AAAA:
+			values = append(values, record.AAAA.String())
+		case *dns.CNAME:
+			values = append(values, record.Target)
+		case *dns.NS:
+			values = append(values, record.Ns)
+		case *dns.TXT:
+			values = append(values, record.Txt...)
+		}
+	}
+	return values
+}
@@ -0,0 +1,227 @@
+// fake Infoblox WAPI that is used for edge DNS zone delegation tests of k8gb
+
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AbsaOSS/k8gb/controllers/fakedns"
+	ibclient "github.com/infobloxopen/infoblox-go-client"
+	"github.com/miekg/dns"
+)
+
+// fakeInfobloxGrid stores WAPI objects in their JSON form by object type and reference. It is shared by all
+// connections, so several reconcilers act as k8gb clusters delegating the same zone. TXT records are published to
+// edgeDNS when set, as Infoblox serves them
+type fakeInfobloxGrid struct {
+	sync.Mutex
+	objects  map[string]map[string]map[string]interface{}
+	sequence int
+	edgeDNS  *fakedns.Server
+}
+
+var fakeInfoblox = &fakeInfobloxGrid{}
+
+const txtObjectType = "record:txt"
+
+// fakeInfobloxConnector is connection to fake grid
+type fakeInfobloxConnector struct {
+	*fakeInfobloxGrid
+}
+
+// reset drops all objects
+func (g *fakeInfobloxGrid) reset() {
+	g.Lock()
+	defer g.Unlock()
+	txts := g.objects[txtObjectType]
+	g.objects = nil
+	for _, fields := range txts {
+		g.publishTXT(fields)
+	}
+	g.sequence = 0
+}
+
+func (g *fakeInfobloxGrid) CreateObject(obj ibclient.IBObject) (string, error) {
+	g.Lock()
+	defer g.Unlock()
+	fields, err := toWAPIFields(obj)
+	if err != nil {
+		return "", err
+	}
+	name := fields["fqdn"]
+	if name == nil {
+		name = fields["name"]
+	}
+	g.sequence++
+	ref := fmt.Sprintf("%s/%s:%v/default", obj.ObjectType(),
+		base64.RawStdEncoding.EncodeToString([]byte(fmt.Sprintf("%s$%d", obj.ObjectType(), g.sequence))), name)
+	fields["_ref"] = ref
+	if g.objects == nil {
+		g.objects = make(map[string]map[string]map[string]interface{})
+	}
+	if g.objects[obj.ObjectType()] == nil {
+		g.objects[obj.ObjectType()] = make(map[string]map[string]interface{})
+	}
+	g.objects[obj.ObjectType()][ref] = fields
+	g.publishTXT(fields)
+	return ref, nil
+}
+
+// GetObject returns object by reference, or all objects matching fields set on obj; e.g. fqdn of zone_delegated
+func (g *fakeInfobloxGrid) GetObject(obj ibclient.IBObject, ref string, res interface{}) error {
+	g.Lock()
+	defer g.Unlock()
+	if ref != "" {
+		fields, found := g.objects[refObjectType(ref)][ref]
+		if !found {
+			return notFoundWAPIError(ref)
+		}
+		return fromWAPIFields(fields, res)
+	}
+	query, err := toWAPIFields(obj)
+	if err != nil {
+		return err
+	}
+	refs := make([]string, 0, len(g.objects[obj.ObjectType()]))
+	for ref := range g.objects[obj.ObjectType()] {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	found := []map[string]interface{}{}
+	for _, ref := range refs {
+		fields := g.objects[obj.ObjectType()][ref]
+		if matchWAPIFields(fields, query) {
+			found = append(found, fields)
+		}
+	}
+	return fromWAPIFields(found, res)
+}
+
+func (g *fakeInfobloxGrid) DeleteObject(ref string) (string, error) {
+	g.Lock()
+	defer g.Unlock()
+	fields, found := g.objects[refObjectType(ref)][ref]
+	if !found {
+		return "", notFoundWAPIError(ref)
+	}
+	delete(g.objects[refObjectType(ref)], ref)
+	g.publishTXT(fields)
+	return ref, nil
+}
+
+// UpdateObject overwrites fields sent in obj, the rest of stored object is kept. Object type is taken from ref as
+// objects read from WAPI don't carry it
+func (g *fakeInfobloxGrid) UpdateObject(obj ibclient.IBObject, ref string) (string, error) {
+	g.Lock()
+	defer g.Unlock()
+	fields, found := g.objects[refObjectType(ref)][ref]
+	if !found {
+		return "", notFoundWAPIError(ref)
+	}
+	update, err := toWAPIFields(obj)
+	if err != nil {
+		return "", err
+	}
+	previous := map[string]interface{}{"_ref": fields["_ref"], "name": fields["name"]}
+	for key, value := range update {
+		if key != "_ref" {
+			fields[key] = value
+		}
+	}
+	g.publishTXT(previous)
+	g.publishTXT(fields)
+	return ref, nil
+}
+
+// zoneDelegated returns delegated zone by fqdn or nil
+func (g *fakeInfobloxGrid) zoneDelegated(fqdn string) *ibclient.ZoneDelegated {
+	var res []ibclient.ZoneDelegated
+	_ = g.GetObject(ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn}), "", &res)
+	if len(res) == 0 {
+		return nil
+	}
+	return &res[0]
+}
+
+// txtRecords returns texts of TXT records by name; name might be fully qualified
+func (g *fakeInfobloxGrid) txtRecords(name string) (texts []string) {
+	var res []ibclient.RecordTXT
+	_ = g.GetObject(ibclient.NewRecordTXT(ibclient.RecordTXT{Name: strings.TrimSuffix(name, ".")}), "", &res)
+	for _, record := range res {
+		texts = append(texts, record.Text)
+	}
+	return texts
+}
+
+// publishTXT serves texts of all stored TXT records named as the record on edge DNS, or removes the name once
+// there are none. Caller must hold the lock
+func (g *fakeInfobloxGrid) publishTXT(fields map[string]interface{}) {
+	name, ok := txtRecordName(fields)
+	if !ok || g.edgeDNS == nil {
+		return
+	}
+	var texts []string
+	for _, record := range g.objects[txtObjectType] {
+		if record["name"] == name {
+			texts = append(texts, fmt.Sprint(record["text"]))
+		}
+	}
+	sort.Strings(texts)
+	if len(texts) == 0 {
+		g.edgeDNS.Remove(name, dns.TypeTXT)
+		return
+	}
+	if err := g.edgeDNS.Set(name, dns.TypeTXT, texts...); err != nil {
+		log.Error(err, "Failed to publish fake infoblox TXT record", "name", name)
+	}
+}
+
+// txtRecordName returns name of TXT record, ok is false for other objects
+func txtRecordName(fields map[string]interface{}) (name string, ok bool) {
+	if !strings.HasPrefix(fmt.Sprint(fields["_ref"]), txtObjectType+"/") {
+		return "", false
+	}
+	name, ok = fields["name"].(string)
+	return name, ok
+}
+
+func toWAPIFields(obj ibclient.IBObject) (fields map[string]interface{}, err error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &fields)
+	return fields, err
+}
+
+func fromWAPIFields(fields interface{}, res interface{}) error {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, res)
+}
+
+func matchWAPIFields(fields, query map[string]interface{}) bool {
+	for key, value := range query {
+		if !reflect.DeepEqual(fields[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// refObjectType returns object type from reference; e.g. zone_delegated for zone_delegated/ZG5z...:cloud.example.com/default
+func refObjectType(ref string) string {
+	return strings.SplitN(ref, "/", 2)[0]
+}
+
+func notFoundWAPIError(ref string) error {
+	return fmt.Errorf("WApi request error: 404 Not Found, reference %s not found", ref)
+}
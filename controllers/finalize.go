@@ -2,40 +2,46 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	types "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 )
 
-func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) error {
+func (r *GslbReconciler) finalizeGslb(ctx context.Context, gslb *k8gbv1beta1.Gslb) error {
 	// needs to do before the CR can be deleted. Examples
 	// of finalizers include performing backups and deleting
 	// resources that are not owned by this CR, like a PVC.
+	log := logging.FromContext(ctx)
+
+	r.deleteGslbMetrics(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name})
+	r.references.remove(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name})
 
 	if r.Config.EdgeDNSType == depresolver.DNSTypeRoute53 {
-		log.Info("Removing Zone Delegation entries...")
+		log.Info("Removing zone delegation DNSEndpoint", "dnsEndpoint", "k8gb-ns-route53")
 		dnsEndpointRoute53 := &externaldns.DNSEndpoint{}
-		err := r.Get(context.Background(), client.ObjectKey{Namespace: r.Config.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
+		err := r.Get(ctx, client.ObjectKey{Namespace: r.Config.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
 		if err != nil {
 			if errors.IsNotFound(err) {
-				log.Info(fmt.Sprint(err))
+				log.V(logging.DebugLevel).Info("Zone delegation DNSEndpoint not found", "dnsEndpoint", "k8gb-ns-route53")
 				return nil
 			}
 			return err
 		}
-		err = r.Delete(context.Background(), dnsEndpointRoute53)
+		err = r.Delete(ctx, dnsEndpointRoute53)
 		if err != nil {
 			return err
 		}
 	}
 
 	if r.Config.EdgeDNSType == depresolver.DNSTypeInfoblox {
-		objMgr, err := infobloxConnection(r.Config)
+		objMgr, err := r.infobloxConnection(ctx)
 		if err != nil {
 			return err
 		}
@@ -50,7 +56,7 @@ func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) error {
 				return err
 			}
 			if len(findZone.Ref) > 0 {
-				log.Info(fmt.Sprintf("Deleting delegated zone(%s)...", r.Config.DNSZone))
+				log.Info("Deleting delegated zone", "zone", r.Config.DNSZone)
 				_, err := objMgr.DeleteZoneDelegated(findZone.Ref)
 				if err != nil {
 					return err
@@ -58,7 +64,7 @@ func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) error {
 			}
 		}
 
-		heartbeatTXTName := fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, r.Config.ClusterGeoTag, r.Config.EdgeDNSZone)
+		heartbeatTXTName := gslbdns.HeartbeatTXTName(gslb.Name, r.Config.ClusterGeoTag, r.Config)
 		findTXT, err := objMgr.GetTXTRecord(heartbeatTXTName)
 		if err != nil {
 			return err
@@ -66,7 +72,7 @@ func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) error {
 
 		if findTXT != nil {
 			if len(findTXT.Ref) > 0 {
-				log.Info(fmt.Sprintf("Deleting split brain TXT record(%s)...", heartbeatTXTName))
+				log.Info("Deleting split brain TXT record", "record", heartbeatTXTName)
 				_, err := objMgr.DeleteTXTRecord(findTXT.Ref)
 				if err != nil {
 					return err
@@ -80,12 +86,13 @@ func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) error {
 	return nil
 }
 
-func (r *GslbReconciler) addFinalizer(gslb *k8gbv1beta1.Gslb) error {
-	log.Info("Adding Finalizer for the Gslb")
+func (r *GslbReconciler) addFinalizer(ctx context.Context, gslb *k8gbv1beta1.Gslb) error {
+	log := logging.FromContext(ctx)
+	log.Info("Adding finalizer")
 	gslb.SetFinalizers(append(gslb.GetFinalizers(), gslbFinalizer))
 
 	// Update CR
-	err := r.Update(context.TODO(), gslb)
+	err := r.Update(ctx, gslb)
 	if err != nil {
 		log.Error(err, "Failed to update Gslb with finalizer")
 		return err
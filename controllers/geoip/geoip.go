@@ -0,0 +1,62 @@
+// Package geoip maps client IP addresses to k8gb geoTags using MaxMind-format database; e.g. GeoLite2-Country
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Resolver returns geoTags closest to the client IP
+type Resolver struct {
+	db *maxminddb.Reader
+	// mapping of ISO country codes or continent codes to geoTags in order of preference
+	mapping map[string][]string
+}
+
+// record fields read from the database. GeoIP2 and GeoLite2 Country and City databases provide both
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+}
+
+// NewResolver opens database file. Mapping keys are ISO country codes (e.g. DE) or continent codes (e.g. EU)
+func NewResolver(database string, mapping map[string][]string) (*Resolver, error) {
+	db, err := maxminddb.Open(database)
+	if err != nil {
+		return nil, fmt.Errorf("can't open GeoIP database %s: %w", database, err)
+	}
+	normalized := make(map[string][]string, len(mapping))
+	for code, geoTags := range mapping {
+		normalized[strings.ToUpper(code)] = geoTags
+	}
+	return &Resolver{db: db, mapping: normalized}, nil
+}
+
+// GeoTags returns geoTags for IP in order of preference. Country mapping takes precedence over continent mapping.
+// Nil is returned when IP is not in database or its location isn't mapped
+func (r *Resolver) GeoTags(ip net.IP) []string {
+	var location record
+	err := r.db.Lookup(ip, &location)
+	if err != nil {
+		return nil
+	}
+	if geoTags, found := r.mapping[strings.ToUpper(location.Country.ISOCode)]; found && location.Country.ISOCode != "" {
+		return geoTags
+	}
+	if geoTags, found := r.mapping[strings.ToUpper(location.Continent.Code)]; found && location.Continent.Code != "" {
+		return geoTags
+	}
+	return nil
+}
+
+// Close releases database file
+func (r *Resolver) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,237 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// location of network in test database
+type location struct {
+	country   string
+	continent string
+}
+
+var predefinedNetworks = map[string]location{
+	"81.2.69.0/24":  {"GB", "EU"},
+	"89.160.0.0/16": {"SE", "EU"},
+	"41.0.0.0/8":    {"ZA", "AF"},
+	"2001:db8::/32": {"DE", "EU"},
+	"12.0.0.0/8":    {"US", "NA"},
+	"175.16.0.0/16": {"CN", "AS"},
+}
+
+var predefinedMapping = map[string][]string{
+	"gb": {"uk", "eu"},
+	"EU": {"eu", "uk"},
+	"AF": {"za"},
+	"NA": {"us"},
+}
+
+func TestGeoTagsByCountry(t *testing.T) {
+	// arrange
+	resolver := provideResolver(t)
+	defer resolver.Close()
+	// act
+	got := resolver.GeoTags(net.ParseIP("81.2.69.142"))
+	// assert
+	assert.Equal(t, []string{"uk", "eu"}, got)
+}
+
+func TestGeoTagsFallBackToContinent(t *testing.T) {
+	// arrange
+	resolver := provideResolver(t)
+	defer resolver.Close()
+	// act
+	sweden := resolver.GeoTags(net.ParseIP("89.160.20.112"))
+	southAfrica := resolver.GeoTags(net.ParseIP("41.1.2.3"))
+	germanyIPv6 := resolver.GeoTags(net.ParseIP("2001:db8::1"))
+	// assert
+	assert.Equal(t, []string{"eu", "uk"}, sweden)
+	assert.Equal(t, []string{"za"}, southAfrica)
+	assert.Equal(t, []string{"eu", "uk"}, germanyIPv6)
+}
+
+func TestGeoTagsOfUnknownLocation(t *testing.T) {
+	// arrange
+	resolver := provideResolver(t)
+	defer resolver.Close()
+	// act
+	notInDatabase := resolver.GeoTags(net.ParseIP("10.0.0.1"))
+	notMapped := resolver.GeoTags(net.ParseIP("175.16.199.1"))
+	// assert
+	assert.Nil(t, notInDatabase)
+	assert.Nil(t, notMapped)
+}
+
+func TestResolverWithMissingDatabase(t *testing.T) {
+	// act
+	_, err := NewResolver("/nonexistent/GeoLite2-Country.mmdb", predefinedMapping)
+	// assert
+	assert.Error(t, err)
+}
+
+func provideResolver(t *testing.T) *Resolver {
+	dir, err := ioutil.TempDir("", "geoip")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	database := filepath.Join(dir, "test.mmdb")
+	require.NoError(t, ioutil.WriteFile(database, testDatabase(t, predefinedNetworks), 0600))
+	// the database is read into memory on non mmap platforms and mmapped otherwise, removing the file is safe
+	resolver, err := NewResolver(database, predefinedMapping)
+	require.NoError(t, err)
+	require.NoError(t, resolver.db.Verify(), "invalid test database")
+	return resolver
+}
+
+// testDatabase builds MaxMind DB (https://maxmind.github.io/MaxMind-DB/) with IPv6 search tree and 24 bit records,
+// which is enough to test without downloading real database
+func testDatabase(t *testing.T, networks map[string]location) []byte {
+	type node struct {
+		children [2]*node
+		// data offset + 1 of record leaving the node, 0 = no data
+		data [2]int
+	}
+	root := &node{}
+	var data bytes.Buffer
+	cidrs := make([]string, 0, len(networks))
+	for cidr := range networks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ones, bits := network.Mask.Size()
+		ip := network.IP.To16()
+		if bits == 32 {
+			// IPv4 networks live in ::/96 subtree
+			ip = append(make(net.IP, 12), network.IP.To4()...)
+			ones += 96
+		}
+		offset := data.Len()
+		encodeValue(&data, map[string]interface{}{
+			"country":   map[string]interface{}{"iso_code": networks[cidr].country},
+			"continent": map[string]interface{}{"code": networks[cidr].continent},
+		})
+		n := root
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+			if i == ones-1 {
+				n.data[bit] = offset + 1
+				break
+			}
+			if n.children[bit] == nil {
+				n.children[bit] = &node{}
+			}
+			n = n.children[bit]
+		}
+	}
+
+	// number nodes in pre-order, root is 0
+	var nodes []*node
+	ids := make(map[*node]int)
+	var number func(n *node)
+	number = func(n *node) {
+		ids[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, child := range n.children {
+			if child != nil {
+				number(child)
+			}
+		}
+	}
+	number(root)
+
+	var db bytes.Buffer
+	nodeCount := len(nodes)
+	for _, n := range nodes {
+		for bit := 0; bit < 2; bit++ {
+			value := nodeCount
+			switch {
+			case n.children[bit] != nil:
+				value = ids[n.children[bit]]
+			case n.data[bit] > 0:
+				value = nodeCount + 16 + n.data[bit] - 1
+			}
+			db.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	db.Write(make([]byte, 16))
+	db.Write(data.Bytes())
+	db.WriteString("\xAB\xCD\xEFMaxMind.com")
+	encodeValue(&db, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(6),
+		"database_type":               "k8gb-test",
+		"languages":                   []interface{}{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(0),
+		"description":                 map[string]interface{}{"en": "k8gb test database"},
+	})
+	return db.Bytes()
+}
+
+// encodeValue writes value in MaxMind DB data section format. Only types needed by tests are supported
+func encodeValue(buf *bytes.Buffer, value interface{}) {
+	const (
+		typeString = 2
+		typeUint16 = 5
+		typeUint32 = 6
+		typeMap    = 7
+		typeUint64 = 9
+		typeArray  = 11
+	)
+	control := func(dataType, size int) {
+		if dataType > 7 {
+			buf.WriteByte(byte(size))
+			buf.WriteByte(byte(dataType - 7))
+			return
+		}
+		buf.WriteByte(byte(dataType<<5 | size))
+	}
+	unsigned := func(dataType int, v uint64, size int) {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		b = bytes.TrimLeft(b[8-size:], "\x00")
+		control(dataType, len(b))
+		buf.Write(b)
+	}
+	switch v := value.(type) {
+	case string:
+		control(typeString, len(v))
+		buf.WriteString(v)
+	case uint16:
+		unsigned(typeUint16, uint64(v), 2)
+	case uint32:
+		unsigned(typeUint32, uint64(v), 4)
+	case uint64:
+		unsigned(typeUint64, v, 8)
+	case []interface{}:
+		control(typeArray, len(v))
+		for _, item := range v {
+			encodeValue(buf, item)
+		}
+	case map[string]interface{}:
+		control(typeMap, len(v))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			encodeValue(buf, key)
+			encodeValue(buf, v[key])
+		}
+	}
+}
@@ -18,18 +18,21 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"github.com/AbsaOSS/k8gb/controllers/logging"
 	"github.com/AbsaOSS/k8gb/controllers/metrics"
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/dnsserver"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	types "k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -52,32 +55,47 @@ type GslbReconciler struct {
 	Config      *depresolver.Config
 	DepResolver *depresolver.DependencyResolver
 	Metrics     *metrics.PrometheusMetrics
+	// DNSServer serves Gslb zone when embedded DNS is enabled, otherwise nil
+	DNSServer  *dnsserver.Server
+	hostnames  hostnameTracker
+	references referenceTracker
+	latencies  latencyTracker
+	serving    servingTracker
+	infoblox   infobloxClient
+	// secrets reads Secrets of operator namespace
+	secrets client.Reader
 }
 
 const (
 	gslbFinalizer           = "finalizer.k8gb.absa.oss"
-	roundRobinStrategy      = "roundRobin"
-	failoverStrategy        = "failover"
-	primaryGeoTagAnnotation = "k8gb.io/primary-geotag"
-	strategyAnnotation      = "k8gb.io/strategy"
+	roundRobinStrategy      = depresolver.RoundRobinStrategy
+	failoverStrategy        = depresolver.FailoverStrategy
+	geoStrategy             = depresolver.GeoStrategy
+	latencyStrategy         = depresolver.LatencyStrategy
+	primaryGeoTagAnnotation = depresolver.PrimaryGeoTagAnnotation
+	strategyAnnotation      = depresolver.StrategyAnnotation
 )
 
 // +kubebuilder:rbac:groups=k8gb.absa.oss,resources=gslbs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=k8gb.absa.oss,resources=gslbs/status,verbs=get;update;patch
 
 // Reconcile runs main reconiliation loop
-func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
-	log := r.Log.WithValues("gslb", req.NamespacedName)
+func (r *GslbReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
+	ctx, span := tracing.Start(context.Background(), "Reconcile", tracing.Gslb(req.Namespace, req.Name))
+	defer func() { tracing.End(span, err) }()
+	log := r.Log.WithValues(logging.GslbKey, req.Name, logging.NamespaceKey, req.Namespace)
+	ctx = logging.IntoContext(ctx, log)
 
 	// Fetch the Gslb instance
 	gslb := &k8gbv1beta1.Gslb{}
-	err := r.Get(ctx, req.NamespacedName, gslb)
+	err = r.Get(ctx, req.NamespacedName, gslb)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.deleteGslbMetrics(req.NamespacedName)
+			r.references.remove(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -101,14 +119,14 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			// Run finalization logic for gslbFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeGslb(gslb); err != nil {
+			if err := r.finalizeGslb(ctx, gslb); err != nil {
 				return ctrl.Result{}, err
 			}
 
 			// Remove gslbFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
 			gslb.SetFinalizers(remove(gslb.GetFinalizers(), gslbFinalizer))
-			err := r.Update(ctx, gslb)
+			err = r.Update(ctx, gslb)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -118,44 +136,73 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 	// Add finalizer for this CR
 	if !contains(gslb.GetFinalizers(), gslbFinalizer) {
-		if err := r.addFinalizer(gslb); err != nil {
+		if err := r.addFinalizer(ctx, gslb); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	// == Ingress ==========
-	ingress, err := r.gslbIngress(gslb)
+	// Gslb with invalid strategy is still finalized above, so it can be deleted
+	err = depresolver.ValidateStrategy(gslb.Spec.Strategy, r.Config)
 	if err != nil {
-		// Requeue the request
+		log.Error(err, "validating spec.strategy")
 		return ctrl.Result{}, err
 	}
 
-	result, err = r.ensureIngress(gslb, ingress)
-	if result != nil {
-		return *result, err
+	sourceType, err := gslbSource(gslb)
+	if err != nil {
+		log.Error(err, "resolving gslb source")
+		return ctrl.Result{}, err
+	}
+
+	// == Ingress ==========
+	if sourceType == ingressSource {
+		phaseCtx, end := r.startPhase(ctx, gslb, metrics.PhaseIngress)
+		ingress, err := r.gslbIngress(gslb)
+		if err != nil {
+			end(err)
+			// Requeue the request
+			return ctrl.Result{}, err
+		}
+
+		result, err = r.ensureIngress(phaseCtx, gslb, ingress)
+		end(err)
+		if result != nil {
+			return *result, err
+		}
 	}
 
 	// == external-dns dnsendpoints CRs ==
-	dnsEndpoint, err := r.gslbDNSEndpoint(gslb)
+	phaseCtx, end := r.startPhase(ctx, gslb, metrics.PhaseDNSEndpoint)
+	dnsEndpoint, geoEndpoints, err := r.gslbDNSEndpoint(phaseCtx, gslb)
 	if err != nil {
+		end(err)
 		// Requeue the request
 		return ctrl.Result{}, err
 	}
 
-	result, err = r.ensureDNSEndpoint(gslb.Namespace, dnsEndpoint)
+	result, err = r.ensureDNSEndpoint(phaseCtx, gslb.Namespace, dnsEndpoint)
+	if result != nil {
+		end(err)
+		return *result, err
+	}
+	result, err = r.ensureGeoDNSEndpoint(phaseCtx, gslb, geoEndpoints)
+	end(err)
 	if result != nil {
 		return *result, err
 	}
 
 	// == handle delegated zone in Edge DNS
-
-	result, err = r.configureZoneDelegation(gslb)
+	phaseCtx, end = r.startPhase(ctx, gslb, metrics.PhaseZoneDelegation)
+	result, err = r.configureZoneDelegation(phaseCtx, gslb)
+	end(err)
 	if result != nil {
 		return *result, err
 	}
 
 	// == Status =
-	err = r.updateGslbStatus(gslb)
+	phaseCtx, end = r.startPhase(ctx, gslb, metrics.PhaseStatus)
+	err = r.updateGslbStatus(phaseCtx, gslb)
+	end(err)
 	if err != nil {
 		// Requeue the request
 		return ctrl.Result{}, err
@@ -169,6 +216,20 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	return ctrl.Result{RequeueAfter: time.Second * time.Duration(r.Config.ReconcileRequeueSeconds)}, nil
 }
 
+// startPhase starts span of reconciliation phase. Returned end records duration of the phase, counts
+// the phase error and ends the span
+func (r *GslbReconciler) startPhase(ctx context.Context, gslb *k8gbv1beta1.Gslb, phase string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "Reconcile."+phase)
+	return ctx, func(err error) {
+		r.Metrics.ObserveReconcileDuration(phase, time.Since(start))
+		if err != nil {
+			r.Metrics.IncrementReconcileErrorsMetric(gslb, phase)
+		}
+		tracing.End(span, err)
+	}
+}
+
 // SetupWithManager configures controller manager
 func (r *GslbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Figure out Gslb resource name to Reconcile when non controlled Endpoint is updated
@@ -179,10 +240,11 @@ func (r *GslbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			opts := []client.ListOption{
 				client.InNamespace(a.Meta.GetNamespace()),
 			}
+			ctx := context.TODO()
 			c := mgr.GetClient()
-			err := c.List(context.TODO(), gslbList, opts...)
+			err := c.List(ctx, gslbList, opts...)
 			if err != nil {
-				log.Info("Can't fetch gslb objects")
+				log.Error(err, "Can't fetch gslb objects", "endpoints", a.Meta.GetName(), logging.NamespaceKey, a.Meta.GetNamespace())
 				return nil
 			}
 			gslbName := ""
@@ -194,94 +256,69 @@ func (r *GslbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 						}
 					}
 				}
+				for _, ref := range gslb.Spec.Services {
+					if ref.ServiceName == a.Meta.GetName() {
+						gslbName = gslb.Name
+					}
+				}
 			}
+			// Gslbs with ingressRef and unstructured sources are found by index, reading the sources here would
+			// hit API server or cache on every Endpoints change
+			requests := r.references.requests(reference{
+				GroupKind:      serviceGroupKind,
+				NamespacedName: types.NamespacedName{Namespace: a.Meta.GetNamespace(), Name: a.Meta.GetName()},
+			})
 			if len(gslbName) > 0 {
-				return []reconcile.Request{
-					{NamespacedName: types.NamespacedName{
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
 						Name:      gslbName,
 						Namespace: a.Meta.GetNamespace(),
-					}},
-				}
+					}})
 			}
-			return nil
+			return requests
 		})
 
-	createGslbFromIngress := func(annotationKey string, annotationValue string, a handler.MapObject, strategy string) {
-		log.Info(fmt.Sprintf("Detected strategy annotation(%s:%s) on Ingress(%s)",
-			annotationKey, annotationValue, a.Meta.GetName()))
-		c := mgr.GetClient()
-		ingressToReuse := &v1beta1.Ingress{}
-		err := c.Get(context.Background(), client.ObjectKey{
-			Namespace: a.Meta.GetNamespace(),
-			Name:      a.Meta.GetName(),
-		}, ingressToReuse)
-		if err != nil {
-			log.Info(fmt.Sprintf("Ingress(%s) does not exist anymore. Skipping Glsb creation...", a.Meta.GetName()))
-			return
-		}
-		gslbExist := &k8gbv1beta1.Gslb{}
-		err = c.Get(context.Background(), client.ObjectKey{
-			Namespace: a.Meta.GetNamespace(),
-			Name:      a.Meta.GetName(),
-		}, gslbExist)
-		if err == nil {
-			log.Info(fmt.Sprintf("Gslb(%s) already exists. Skipping Gslb creation...", gslbExist.Name))
-			return
-		}
-		gslb := &k8gbv1beta1.Gslb{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace:   a.Meta.GetNamespace(),
-				Name:        a.Meta.GetName(),
-				Annotations: a.Meta.GetAnnotations(),
-			},
-			Spec: k8gbv1beta1.GslbSpec{
-				Ingress: ingressToReuse.Spec,
-				Strategy: k8gbv1beta1.Strategy{
-					Type: strategy,
-				},
-			},
-		}
-
-		if strategy == failoverStrategy {
-			for annotationKey, annotationValue := range a.Meta.GetAnnotations() {
-				if annotationKey == primaryGeoTagAnnotation {
-					gslb.Spec.Strategy.PrimaryGeoTag = annotationValue
-				}
-			}
-			if gslb.Spec.Strategy.PrimaryGeoTag == "" {
-				log.Info(fmt.Sprintf("%s annotation is missing, skipping Gslb creation...", primaryGeoTagAnnotation))
-				return
-			}
-		}
-
-		log.Info(fmt.Sprintf("Creating new Gslb(%s) out of Ingress annotation", gslb.Name))
-		_ = c.Create(context.Background(), gslb)
-	}
-	ingressMapFn := handler.ToRequestsFunc(
-		func(a handler.MapObject) []reconcile.Request {
-			for annotationKey, annotationValue := range a.Meta.GetAnnotations() {
-				if annotationKey == strategyAnnotation {
-					switch annotationValue {
-					case roundRobinStrategy:
-						createGslbFromIngress(annotationKey, annotationKey, a, roundRobinStrategy)
-					case failoverStrategy:
-						createGslbFromIngress(annotationKey, annotationKey, a, failoverStrategy)
-					}
-				}
-			}
-			return nil
-		})
-
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&k8gbv1beta1.Gslb{}).
 		Owns(&v1beta1.Ingress{}).
 		Owns(&externaldns.DNSEndpoint{}).
+		Watches(&source.Kind{Type: &v1beta1.Ingress{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.ingressRefRequests)}).
 		Watches(&source.Kind{Type: &corev1.Endpoints{}},
 			&handler.EnqueueRequestsFromMapFunc{
 				ToRequests: endpointMapFn}).
-		Watches(&source.Kind{Type: &v1beta1.Ingress{}},
+		Watches(&source.Kind{Type: &corev1.Service{}},
 			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: ingressMapFn}).
-		Complete(r)
+				ToRequests: endpointMapFn})
+	// Gateway API and Istio objects are watched only when their CRDs are installed, controller can't start
+	// watching unknown kinds
+	unstructuredWatches := []struct {
+		gvk        schema.GroupVersionKind
+		toRequests handler.ToRequestsFunc
+	}{
+		{httpRouteGroupKind.WithVersion("v1"), r.httpRouteRequests},
+		{gatewayGroupKind.WithVersion("v1"), r.referenceRequests(gatewayGroupKind)},
+		{istioVirtualServiceGroupKind.WithVersion("v1beta1"), r.virtualServiceRequests},
+		{istioGatewayGroupKind.WithVersion("v1beta1"), r.referenceRequests(istioGatewayGroupKind)},
+	}
+	for _, w := range unstructuredWatches {
+		if !kindInstalled(mgr.GetRESTMapper(), w.gvk) {
+			log.Info("Kind is not installed, not watching it", "kind", w.gvk.String())
+			continue
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(w.gvk)
+		builder = builder.Watches(&source.Kind{Type: u},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: w.toRequests})
+	}
+	if r.Config.Infoblox.CredentialsSecret != "" {
+		var err error
+		builder, err = r.setupInfobloxSecretWatch(mgr, builder)
+		if err != nil {
+			return err
+		}
+	}
+	return builder.Complete(r)
 
 }
@@ -1,9 +1,16 @@
 package controllers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
@@ -13,27 +20,41 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 
+	"github.com/AbsaOSS/k8gb/controllers/logging"
 	"github.com/AbsaOSS/k8gb/controllers/metrics"
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/stretchr/testify/require"
 
 	ibclient "github.com/infobloxopen/infoblox-go-client"
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/dnsserver"
+	"github.com/AbsaOSS/k8gb/controllers/fakedns"
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	toolscache "k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -52,6 +73,9 @@ type testSettings struct {
 
 var crSampleYaml = "../deploy/crds/k8gb.absa.oss_v1beta1_gslb_cr.yaml"
 
+// edgeDNS serves edge DNS and external clusters to all tests
+var edgeDNS *fakedns.Server
+
 var predefinedConfig = depresolver.Config{
 	ReconcileRequeueSeconds: 30,
 	ClusterGeoTag:           "us-west-1",
@@ -269,9 +293,29 @@ func TestMetricLinterCheck(t *testing.T) {
 	require.NoError(t, err)
 	healthyRecordsMetric := settings.reconciler.Metrics.GetHealthyRecordsMetric()
 	ingressHostsPerStatusMetric := settings.reconciler.Metrics.GetIngressHostsPerStatusMetric()
+	peerLatencyMetric := settings.reconciler.Metrics.GetPeerLatencyMetric()
+	writesMetric := settings.reconciler.Metrics.GetWritesMetric()
+	reconcileDurationMetric := settings.reconciler.Metrics.GetReconcileDurationMetric()
+	reconcileErrorsMetric := settings.reconciler.Metrics.GetReconcileErrorsMetric()
+	failoverMetric := settings.reconciler.Metrics.GetFailoverMetric()
+	activeGeoTagMetric := settings.reconciler.Metrics.GetActiveGeoTagMetric()
+	peerHeartbeatAgeMetric := settings.reconciler.Metrics.GetPeerHeartbeatAgeMetric()
+	peerDNSQueryDurationMetric := settings.reconciler.Metrics.GetPeerDNSQueryDurationMetric()
+	peerUpMetric := settings.reconciler.Metrics.GetPeerUpMetric()
+	splitBrainExpiredMetric := settings.reconciler.Metrics.GetSplitBrainExpiredMetric()
 	for name, scenario := range map[string]prometheus.Collector{
-		"healthy_records":          healthyRecordsMetric,
-		"ingress_hosts_per_status": ingressHostsPerStatusMetric,
+		"healthy_records":                 healthyRecordsMetric,
+		"ingress_hosts_per_status":        ingressHostsPerStatusMetric,
+		"peer_latency_seconds":            peerLatencyMetric,
+		"writes_total":                    writesMetric,
+		"reconcile_duration_seconds":      reconcileDurationMetric,
+		"reconcile_errors_total":          reconcileErrorsMetric,
+		"failover_total":                  failoverMetric,
+		"active_geotag":                   activeGeoTagMetric,
+		"peer_heartbeat_age_seconds":      peerHeartbeatAgeMetric,
+		"peer_dns_query_duration_seconds": peerDNSQueryDurationMetric,
+		"peer_up":                         peerUpMetric,
+		"split_brain_expired_total":       splitBrainExpiredMetric,
 	} {
 		// act
 		// assert
@@ -281,6 +325,162 @@ func TestMetricLinterCheck(t *testing.T) {
 	}
 }
 
+func TestReconcilePhasesAreObservedInMetrics(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	reconcileDurationMetric := settings.reconciler.Metrics.GetReconcileDurationMetric()
+	reconcileErrorsMetric := settings.reconciler.Metrics.GetReconcileErrorsMetric()
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	// ingress, dns_endpoint, zone_delegation and status phases
+	assert.Equal(t, 4, testutil.CollectAndCount(reconcileDurationMetric))
+	assert.Equal(t, 0, testutil.CollectAndCount(reconcileErrorsMetric))
+}
+
+func TestReconcilePhasesAreTraced(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.reconciler.Client = tracing.NewClient(settings.reconciler.Client)
+	recorder := new(oteltest.StandardSpanRecorder)
+	otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	spans := make(map[string][]*oteltest.Span)
+	for _, span := range recorder.Completed() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	require.Len(t, spans["Reconcile"], 1)
+	root := spans["Reconcile"][0]
+	assert.Equal(t, label.StringValue("test-gslb/test-gslb"), root.Attributes()[tracing.GslbKey])
+	for _, phase := range []string{metrics.PhaseIngress, metrics.PhaseDNSEndpoint, metrics.PhaseZoneDelegation, metrics.PhaseStatus} {
+		require.Len(t, spans["Reconcile."+phase], 1, phase)
+		assert.Equal(t, root.SpanContext().SpanID, spans["Reconcile."+phase][0].ParentSpanID(), phase)
+	}
+	assert.NotEmpty(t, spans["k8s.Get"])
+	assert.NotEmpty(t, spans["k8s.UpdateStatus"])
+	assert.NotEmpty(t, spans["infoblox.GetObject"])
+	require.NotEmpty(t, spans["peer.QueryLocalTargets"])
+	peerQuery := spans["peer.QueryLocalTargets"][0]
+	assert.Equal(t, label.StringValue("us-east-1"), peerQuery.Attributes()[tracing.PeerKey])
+	assert.Contains(t, peerQuery.Attributes(), tracing.HostKey)
+}
+
+func TestFailedReconcileIsRecordedInTrace(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	recorder := new(oteltest.StandardSpanRecorder)
+	otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	// act
+	_, err = settings.reconciler.Reconcile(settings.request)
+	// assert
+	require.Error(t, err)
+	require.Len(t, recorder.Completed(), 1)
+	root := recorder.Completed()[0]
+	assert.Equal(t, "Reconcile", root.Name())
+	assert.Equal(t, codes.Error, root.StatusCode())
+	assert.Equal(t, err.Error(), root.StatusMessage())
+}
+
+func TestReconcileLogsCarryGslbContext(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	var buf bytes.Buffer
+	settings.reconciler.Log = zap.New(zap.WriteTo(&buf), zap.Level(zapcore.DebugLevel))
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	var peerLines int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		assert.Equal(t, "test-gslb", line[logging.GslbKey], line["msg"])
+		assert.Equal(t, "test-gslb", line[logging.NamespaceKey], line["msg"])
+		if line[logging.PeerKey] == "us-east-1" && line[logging.HostKey] != nil {
+			peerLines++
+		}
+	}
+	assert.NotZero(t, peerLines)
+}
+
+func TestFailoverOfUnhealthyPrimaryIsLogged(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	settings.gslb.Spec.Strategy.Type = failoverStrategy
+	settings.gslb.Spec.Strategy.PrimaryGeoTag = customConfig.ClusterGeoTag
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	var buf bytes.Buffer
+	settings.reconciler.Log = zap.New(zap.WriteTo(&buf), zap.Level(zapcore.InfoLevel))
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	var failoverLines []interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		if line["msg"] == "Executing failover strategy on primary, local workload is unhealthy" {
+			failoverLines = append(failoverLines, line[logging.HostKey])
+		}
+	}
+	assert.Contains(t, failoverLines, "roundrobin.cloud.example.com")
+}
+
+func TestFinalizedGslbHasNoMetricSeries(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
+	settings := provideSettings(t, customConfig)
+	settings.reconciler.Metrics.IncrementReconcileErrorsMetric(settings.gslb, metrics.PhaseStatus)
+	settings.reconciler.Metrics.UpdateActiveGeoTagMetric(settings.gslb, map[string][]string{"roundrobin.cloud.example.com": {"us-west-1"}})
+	// act
+	err := settings.reconciler.finalizeGslb(context.TODO(), settings.gslb)
+	// assert
+	require.NoError(t, err)
+	for name, collector := range map[string]prometheus.Collector{
+		"healthy_records":          settings.reconciler.Metrics.GetHealthyRecordsMetric(),
+		"ingress_hosts_per_status": settings.reconciler.Metrics.GetIngressHostsPerStatusMetric(),
+		"writes_total":             settings.reconciler.Metrics.GetWritesMetric(),
+		"reconcile_errors_total":   settings.reconciler.Metrics.GetReconcileErrorsMetric(),
+		"active_geotag":            settings.reconciler.Metrics.GetActiveGeoTagMetric(),
+	} {
+		assert.Equal(t, 0, testutil.CollectAndCount(collector), name)
+	}
+	assert.Empty(t, settings.reconciler.Metrics.Gslbs())
+}
+
+func TestMetricsSweepRemovesSeriesOfDeletedGslb(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	deleted := &k8gbv1beta1.Gslb{ObjectMeta: metav1.ObjectMeta{Namespace: settings.gslb.Namespace, Name: "deleted-gslb"}}
+	err := settings.reconciler.Metrics.UpdateHealthyRecordsMetric(deleted, map[string][]string{"deleted.cloud.example.com": {"10.0.0.1"}})
+	require.NoError(t, err)
+	healthyRecordsMetric := settings.reconciler.Metrics.GetHealthyRecordsMetric()
+	// act
+	err = settings.reconciler.sweepMetrics()
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, testutil.CollectAndCount(healthyRecordsMetric))
+	assert.Equal(t, []types.NamespacedName{settings.request.NamespacedName}, settings.reconciler.Metrics.Gslbs())
+}
+
 func TestGslbCreatesDNSEndpointCRForHealthyIngressHosts(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -324,6 +524,59 @@ func TestGslbCreatesDNSEndpointCRForHealthyIngressHosts(t *testing.T) {
 	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
 }
 
+func TestEmbeddedDNSServesReconciledEndpoints(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3"},
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	settings := provideSettings(t, predefinedConfig)
+	settings.reconciler.DNSServer = dnsserver.NewServer(dnsserver.Config{
+		Address: addr,
+		Zone:    predefinedConfig.DNSZone,
+		TTL:     embeddedDNSTtlSeconds,
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = settings.reconciler.DNSServer.Start(stop)
+	}()
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	require.NoError(t, loadEmbeddedDNS(context.TODO(), settings.client, settings.reconciler.DNSServer))
+	var got []string
+	require.Eventually(t, func() bool {
+		got = nil
+		r, _, err := new(dns.Client).Exchange(new(dns.Msg).SetQuestion("roundrobin.cloud.example.com.", dns.TypeA), addr)
+		if err != nil {
+			return false
+		}
+		for _, rr := range r.Answer {
+			got = append(got, rr.(*dns.A).A.String())
+		}
+		return true
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// assert
+	assert.ElementsMatch(t, want, got)
+}
+
 func TestDNSRecordReflectionInStatus(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -448,219 +701,1056 @@ func TestCanGetExternalTargetsFromK8gbInAnotherLocation(t *testing.T) {
 	assert.Equal(t, hrGot, hrWant, "got:\n %s Gslb Records status,\n\n want:\n %s", hrGot, hrWant)
 }
 
-func TestCanCheckExternalGslbTXTRecordForValidityAndFailIfItIsExpired(t *testing.T) {
+func TestQueriesExternalClusterAtItsOwnFakeDNS(t *testing.T) {
 	// arrange
 	defer cleanup()
+	serviceName := "frontend-podinfo"
+	peer, err := fakedns.New()
+	require.NoError(t, err)
+	defer peer.Close()
+	require.NoError(t, peer.Add("localtargets-roundrobin.cloud.example.com", dns.TypeA, "10.2.0.1"))
+	hrWant := map[string][]string{"roundrobin.cloud.example.com": {"10.0.0.1", "10.2.0.1"}}
 	customConfig := predefinedConfig
 	customConfig.Override.FakeDNSEnabled = true
-	customConfig.EdgeDNSServer = "fake"
-	// act
-	got := checkAliveFromTXT("test-gslb-heartbeat-eu.example.com", &customConfig, time.Minute*5)
-	want := errors.NewGone("Split brain TXT record expired the time threshold: (5m0s)")
-	// assert
-	assert.Equal(t, want, got, "got:\n %s from TXT split brain check,\n\n want error:\n %v", got, want)
-}
-
-func TestCanFilterOutDelegatedZoneEntryAccordingFQDNProvided(t *testing.T) {
-	// arrange
-	defer cleanup()
-	delegateTo := []ibclient.NameServer{
-		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-		{Address: "10.0.0.2", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-		{Address: "10.0.0.3", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-		{Address: "10.1.0.1", Name: "gslb-ns-cloud-example-com-za.example.com"},
-		{Address: "10.1.0.2", Name: "gslb-ns-cloud-example-com-za.example.com"},
-		{Address: "10.1.0.3", Name: "gslb-ns-cloud-example-com-za.example.com"},
-	}
-	want := []ibclient.NameServer{
-		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-		{Address: "10.0.0.2", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-		{Address: "10.0.0.3", Name: "gslb-ns-cloud-example-com-eu.example.com"},
-	}
-	customConfig := predefinedConfig
-	customConfig.EdgeDNSZone = "example.com"
-	customConfig.ExtClustersGeoTags = []string{"za"}
+	customConfig.Override.FakeDNSPeers = map[string]string{"us-east-1": peer.Address()}
 	settings := provideSettings(t, customConfig)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+
 	// act
-	extClusters := settings.reconciler.nsServerNameExt()
-	got := filterOutDelegateTo(delegateTo, extClusters[0])
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	reconcileAndUpdateGslb(t, settings)
+
 	// assert
-	assert.Equal(t, want, got, "got:\n %q filtered out delegation records,\n\n want:\n %q", got, want)
+	assert.Equal(t, hrWant, settings.gslb.Status.HealthyRecords)
 }
 
-func TestCanGenerateExternalHeartbeatFQDNs(t *testing.T) {
+func TestCanGetExternalIPv6TargetsFromK8gbInAnotherLocation(t *testing.T) {
 	// arrange
 	defer cleanup()
-	want := []string{"test-gslb-heartbeat-za.example.com"}
+	want := []string{"10.1.0.4", "2001:db8::1:4"}
 	customConfig := predefinedConfig
-	customConfig.EdgeDNSZone = "example.com"
-	customConfig.ExtClustersGeoTags = []string{"za"}
+	customConfig.Override.FakeDNSEnabled = true
 	settings := provideSettings(t, customConfig)
 	// act
-	got := getExternalClusterHeartbeatFQDNs(settings.gslb, &settings.config)
+	got, err := settings.reconciler.getExternalTargets(context.TODO(), "dualstack.cloud.example.com")
 	// assert
-	assert.Equal(t, want, got, "got:\n %s unexpected heartbeat records,\n\n want:\n %s", got, want)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "got:\n %q external targets,\n\n want:\n %q", got, want)
 }
 
-func TestCanCheckExternalGslbTXTRecordForValidityAndPAssIfItISNotExpired(t *testing.T) {
+func TestGslbCreatesAAAARecordsForIPv6IngressAddresses(t *testing.T) {
 	// arrange
-	customConfig := predefinedConfig
-	customConfig.Override.FakeDNSEnabled = true
-	customConfig.EdgeDNSServer = "fake"
-	// act
-	err2 := checkAliveFromTXT("test-gslb-heartbeat-za.example.com", &customConfig, time.Minute*5)
-	// assert
-	assert.NoError(t, err2, "got:\n %s from TXT split brain check,\n\n want error:\n %v", err2, nil)
-}
-
-func TestReturnsOwnRecordsUsingFailoverStrategyWhenPrimary(t *testing.T) {
 	defer cleanup()
 	serviceName := "frontend-podinfo"
+	dnsEndpoint := &externaldns.DNSEndpoint{}
 	want := []*externaldns.Endpoint{
 		{
 			DNSName:    "localtargets-roundrobin.cloud.example.com",
 			RecordTTL:  30,
 			RecordType: "A",
-			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
-		},
+			Targets:    externaldns.Targets{"10.0.0.1"}},
+		{
+			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "AAAA",
+			Targets:    externaldns.Targets{"2001:db8::1", "2001:db8::2"}},
 		{
 			DNSName:    "roundrobin.cloud.example.com",
 			RecordTTL:  30,
 			RecordType: "A",
-			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
-		},
+			Targets:    externaldns.Targets{"10.0.0.1"}},
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "AAAA",
+			Targets:    externaldns.Targets{"2001:db8::1", "2001:db8::2"}},
 	}
+	hrWant := map[string][]string{"roundrobin.cloud.example.com": {"10.0.0.1", "2001:db8::1", "2001:db8::2"}}
 	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "2001:db8::1"},
 		{IP: "10.0.0.1"},
-		{IP: "10.0.0.2"},
-		{IP: "10.0.0.3"},
+		{IP: "2001:db8::2"},
 	}
-	dnsEndpoint := &externaldns.DNSEndpoint{}
-	customConfig := predefinedConfig
-	customConfig.ClusterGeoTag = "eu"
-	customConfig.Override.FakeDNSEnabled = true
-	settings := provideSettings(t, customConfig)
-
-	// ingress
+	settings := provideSettings(t, predefinedConfig)
 	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
 	require.NoError(t, err, "Failed to get expected ingress")
 	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
 	err = settings.client.Status().Update(context.TODO(), settings.ingress)
 	require.NoError(t, err, "Failed to update gslb Ingress Address")
-
-	// enable failover strategy
-	settings.gslb.Spec.Strategy.Type = "failover"
-	settings.gslb.Spec.Strategy.PrimaryGeoTag = "eu"
-	err = settings.client.Update(context.TODO(), settings.gslb)
-	require.NoError(t, err, "Can't update gslb")
-
-	// act
 	createHealthyService(t, &settings, serviceName)
 	defer deleteHealthyService(t, &settings, serviceName)
+
+	// act
 	reconcileAndUpdateGslb(t, settings)
 	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
-	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	require.NoError(t, err, "Failed to load DNS endpoint")
 	got := dnsEndpoint.Spec.Endpoints
+	hrGot := settings.gslb.Status.HealthyRecords
 	prettyGot := utils.ToString(got)
 	prettyWant := utils.ToString(want)
 
 	// assert
 	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, hrWant, hrGot, "got:\n %s Gslb Records status,\n\n want:\n %s", hrGot, hrWant)
 }
 
-func TestReturnsExternalRecordsUsingFailoverStrategy(t *testing.T) {
+func TestCanGetExternalCNAMETargetsFromK8gbInAnotherLocation(t *testing.T) {
+	// arrange
+	defer cleanup()
+	want := []string{"k8gb-eu.elb.eu-west-1.amazonaws.com"}
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	// act
+	got, err := settings.reconciler.getExternalTargets(context.TODO(), "elb.cloud.example.com")
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "got:\n %q external targets,\n\n want:\n %q", got, want)
+}
+
+func TestGslbCreatesCNAMERecordsForLoadBalancerHostname(t *testing.T) {
 	// arrange
+	defer cleanup()
 	serviceName := "frontend-podinfo"
+	dnsEndpoint := &externaldns.DNSEndpoint{}
 	want := []*externaldns.Endpoint{
 		{
 			DNSName:    "localtargets-roundrobin.cloud.example.com",
 			RecordTTL:  30,
-			RecordType: "A",
-			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
-		},
+			RecordType: "CNAME",
+			Targets:    externaldns.Targets{"k8gb-za.elb.af-south-1.amazonaws.com"}},
 		{
 			DNSName:    "roundrobin.cloud.example.com",
 			RecordTTL:  30,
-			RecordType: "A",
-			Targets:    externaldns.Targets{"10.1.0.1", "10.1.0.2", "10.1.0.3"},
-		},
+			RecordType: "CNAME",
+			Targets:    externaldns.Targets{"k8gb-za.elb.af-south-1.amazonaws.com"}},
 	}
+	hrWant := map[string][]string{"roundrobin.cloud.example.com": {"k8gb-za.elb.af-south-1.amazonaws.com"}}
 	ingressIPs := []corev1.LoadBalancerIngress{
-		{IP: "10.0.0.1"},
-		{IP: "10.0.0.2"},
-		{IP: "10.0.0.3"},
+		{Hostname: "k8gb-za.elb.af-south-1.amazonaws.com"},
 	}
-	dnsEndpoint := &externaldns.DNSEndpoint{}
 	customConfig := predefinedConfig
 	customConfig.ClusterGeoTag = "za"
 	customConfig.Override.FakeDNSEnabled = true
 	settings := provideSettings(t, customConfig)
-
-	// ingress
 	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
 	require.NoError(t, err, "Failed to get expected ingress")
 	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
 	err = settings.client.Status().Update(context.TODO(), settings.ingress)
 	require.NoError(t, err, "Failed to update gslb Ingress Address")
-
-	// enable failover strategy
+	// healthy primary publishes only own targets, so the hostname isn't mixed with external IPs
 	settings.gslb.Spec.Strategy.Type = "failover"
-	settings.gslb.Spec.Strategy.PrimaryGeoTag = "eu"
+	settings.gslb.Spec.Strategy.PrimaryGeoTag = "za"
+	settings.gslb.Spec.Strategy.HostnameTargets = depresolver.HostnameTargetsCNAME
 	err = settings.client.Update(context.TODO(), settings.gslb)
 	require.NoError(t, err, "Can't update gslb")
-
-	// act
 	createHealthyService(t, &settings, serviceName)
 	defer deleteHealthyService(t, &settings, serviceName)
+
+	// act
 	reconcileAndUpdateGslb(t, settings)
 	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
-	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	require.NoError(t, err, "Failed to load DNS endpoint")
 	got := dnsEndpoint.Spec.Endpoints
+	hrGot := settings.gslb.Status.HealthyRecords
 	prettyGot := utils.ToString(got)
 	prettyWant := utils.ToString(want)
 
 	// assert
 	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, hrWant, hrGot, "got:\n %s Gslb Records status,\n\n want:\n %s", hrGot, hrWant)
 }
 
-func TestGslbProperlyPropagatesAnnotationDownToIngress(t *testing.T) {
+func TestCNAMETargetIsUsedOnlyForSingleHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		hostname string
+		ok       bool
+	}{
+		{"single hostname", []string{"lb.example.com"}, "lb.example.com", true},
+		{"repeated hostname", []string{"lb.example.com.", "lb.example.com"}, "lb.example.com", true},
+		{"multiple hostnames", []string{"lb-eu.example.com", "lb-za.example.com"}, "", false},
+		{"hostname mixed with IP", []string{"lb.example.com", "10.0.0.1"}, "", false},
+		{"IPs only", []string{"10.0.0.1", "2001:db8::1"}, "", false},
+		{"no targets", nil, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// act
+			hostname, ok := cnameTarget(test.targets)
+			// assert
+			assert.Equal(t, test.hostname, hostname)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
+
+func TestHostnameTrackerReportsChangedIPs(t *testing.T) {
 	// arrange
-	defer cleanup()
-	settings := provideSettings(t, predefinedConfig)
-	expectedAnnotations := map[string]string{"annotation": "test"}
-	settings.gslb.Annotations = expectedAnnotations
-	err := settings.client.Update(context.TODO(), settings.gslb)
-	require.NoError(t, err, "Can't update gslb")
+	tracker := hostnameTracker{}
+	gslb := types.NamespacedName{Namespace: "test-gslb", Name: "test-gslb"}
 	// act
-	reconcileAndUpdateGslb(t, settings)
-	err2 := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	_, changedFirst := tracker.update(gslb, "lb.example.com", []string{"10.0.0.1", "10.0.0.2"})
+	_, changedReordered := tracker.update(gslb, "lb.example.com", []string{"10.0.0.2", "10.0.0.1"})
+	previous, changed := tracker.update(gslb, "lb.example.com", []string{"10.0.0.3"})
 	// assert
-	assert.NoError(t, err2, "Failed to get expected ingress")
-	assert.Equal(t, expectedAnnotations, settings.ingress.Annotations)
+	assert.False(t, changedFirst)
+	assert.False(t, changedReordered)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.1"}, previous)
 }
 
-func TestReflectGeoTagInStatusAsUnsetByDefault(t *testing.T) {
+func TestHostnameTrackerForgetsDeletedGslb(t *testing.T) {
 	// arrange
-	defer cleanup()
-	want := "us-west-1"
-	settings := provideSettings(t, predefinedConfig)
+	tracker := hostnameTracker{}
+	deleted := types.NamespacedName{Namespace: "test-gslb", Name: "deleted"}
+	other := types.NamespacedName{Namespace: "test-gslb", Name: "other"}
+	tracker.update(deleted, "lb.example.com", []string{"10.0.0.1"})
+	tracker.update(other, "lb.example.com", []string{"10.0.0.1"})
 	// act
-	reconcileAndUpdateGslb(t, settings)
-	got := settings.gslb.Status.GeoTag
+	tracker.remove(deleted)
+	_, changedDeleted := tracker.update(deleted, "lb.example.com", []string{"10.0.0.2"})
+	_, changedOther := tracker.update(other, "lb.example.com", []string{"10.0.0.2"})
 	// assert
-	assert.Equal(t, want, got, "got: '%s' GeoTag status, want:'%s'", got, want)
+	assert.False(t, changedDeleted)
+	assert.True(t, changedOther)
+	assert.Len(t, tracker.resolved, 2)
 }
 
-func TestReflectGeoTagInTheStatus(t *testing.T) {
+func TestServingTrackerCountsMovesBetweenSeveralClusters(t *testing.T) {
 	// arrange
-	defer cleanup()
-	want := "eu"
-	customConfig := predefinedConfig
-	customConfig.ClusterGeoTag = "eu"
-	settings := provideSettings(t, customConfig)
+	tracker := servingTracker{}
+	gslb := types.NamespacedName{Namespace: "test-gslb", Name: "test-gslb"}
+	tracker.update(gslb, map[string][]string{"a.example.com": {"eu"}, "b.example.com": {"us"}, "c.example.com": {"eu", "za"}})
 	// act
-	reconcileAndUpdateGslb(t, settings)
-	got := settings.gslb.Status.GeoTag
+	moved := tracker.update(gslb, map[string][]string{"a.example.com": {"us", "za"}, "b.example.com": {"us", "za"}, "c.example.com": {"za"}})
+	// assert
+	assert.Equal(t, map[string][]string{"a.example.com": {"eu"}, "c.example.com": {"eu", "za"}}, moved)
+}
+
+func TestCanCheckExternalGslbTXTRecordForValidityAndFailIfItIsExpired(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.EdgeDNSServer = "fake"
+	// act
+	_, got := checkAliveFromTXT(context.TODO(), "test-gslb-heartbeat-eu.example.com", &customConfig, time.Minute*5)
+	want := errors.NewGone("Split brain TXT record expired the time threshold: (5m0s)")
+	// assert
+	assert.Equal(t, want, got, "got:\n %s from TXT split brain check,\n\n want error:\n %v", got, want)
+}
+
+func TestChecksPeerHeartbeatsPublishedByExternalDNS(t *testing.T) {
+	// arrange
+	defer cleanup()
+	// test-gslb-heartbeat-eu is expired and test-gslb-heartbeat-za is alive in fake DNS
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.ExtClustersGeoTags = []string{"eu", "za"}
+	settings := provideSettings(t, customConfig)
+	settings.reconciler.Config.EdgeDNSType = depresolver.DNSTypeRoute53
+	otherHeartbeat := &externaldns.Endpoint{
+		DNSName:    fmt.Sprintf("other-gslb-heartbeat-%s.%s", customConfig.ClusterGeoTag, customConfig.EdgeDNSZone),
+		RecordTTL:  30,
+		RecordType: "TXT",
+		Targets:    externaldns.Targets{"2021-01-01T10:00:00"},
+	}
+	err := settings.client.Create(context.TODO(), &externaldns.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8gb-ns-route53", Namespace: customConfig.K8gbNamespace},
+		Spec:       externaldns.DNSEndpointSpec{Endpoints: []*externaldns.Endpoint{otherHeartbeat}},
+	})
+	require.NoError(t, err)
+	labels := func(peer string) prometheus.Labels {
+		return prometheus.Labels{"namespace": settings.gslb.Namespace, "name": settings.gslb.Name, "peer": peer}
+	}
+	// act
+	_, err = settings.reconciler.configureZoneDelegation(context.TODO(), settings.gslb)
+	// assert
+	require.NoError(t, err)
+	heartbeatAgeMetric := settings.reconciler.Metrics.GetPeerHeartbeatAgeMetric()
+	splitBrainExpiredMetric := settings.reconciler.Metrics.GetSplitBrainExpiredMetric()
+	assert.Equal(t, 2, testutil.CollectAndCount(heartbeatAgeMetric))
+	assert.Greater(t, testutil.ToFloat64(splitBrainExpiredMetric.With(labels("eu"))), 0.0)
+	assert.Equal(t, 0.0, testutil.ToFloat64(splitBrainExpiredMetric.With(labels("za"))))
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: customConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpoint)
+	require.NoError(t, err)
+	heartbeats := map[string]string{}
+	for _, ep := range dnsEndpoint.Spec.Endpoints {
+		if ep.RecordType == "TXT" {
+			heartbeats[ep.DNSName] = ep.Targets[0]
+		}
+	}
+	ownHeartbeat := fmt.Sprintf("test-gslb-heartbeat-%s.%s", customConfig.ClusterGeoTag, customConfig.EdgeDNSZone)
+	require.Len(t, heartbeats, 2)
+	assert.Equal(t, otherHeartbeat.Targets[0], heartbeats[otherHeartbeat.DNSName], "heartbeat of other Gslb must be kept")
+	timestamp, err := time.Parse("2006-01-02T15:04:05", heartbeats[ownHeartbeat])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().UTC(), timestamp, time.Minute)
+}
+
+func TestSkipsPeerHeartbeatsWithoutEdgeDNS(t *testing.T) {
+	// arrange
+	defer cleanup()
+	// test-gslb-heartbeat-eu is expired in fake DNS, but it isn't checked
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.ExtClustersGeoTags = []string{"eu", "za"}
+	settings := provideSettings(t, customConfig)
+	settings.reconciler.Config.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
+	// act
+	stale := settings.reconciler.checkPeersAlive(context.TODO(), settings.gslb)
+	// assert
+	assert.Empty(t, stale)
+}
+
+func TestCanFilterOutDelegatedZoneEntryAccordingFQDNProvided(t *testing.T) {
+	// arrange
+	defer cleanup()
+	delegateTo := []ibclient.NameServer{
+		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.0.0.2", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.0.0.3", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.1.0.1", Name: "gslb-ns-cloud-example-com-za.example.com"},
+		{Address: "10.1.0.2", Name: "gslb-ns-cloud-example-com-za.example.com"},
+		{Address: "10.1.0.3", Name: "gslb-ns-cloud-example-com-za.example.com"},
+	}
+	want := []ibclient.NameServer{
+		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.0.0.2", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.0.0.3", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+	}
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSZone = "example.com"
+	customConfig.ExtClustersGeoTags = []string{"za"}
+	settings := provideSettings(t, customConfig)
+	// act
+	extClusters := settings.reconciler.nsServerNameExt()
+	got := filterOutDelegateTo(delegateTo, extClusters[0])
+	// assert
+	assert.Equal(t, want, got, "got:\n %q filtered out delegation records,\n\n want:\n %q", got, want)
+}
+
+func TestCanGenerateExternalHeartbeatFQDNs(t *testing.T) {
+	// arrange
+	defer cleanup()
+	want := []string{"test-gslb-heartbeat-za.example.com"}
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSZone = "example.com"
+	customConfig.ExtClustersGeoTags = []string{"za"}
+	settings := provideSettings(t, customConfig)
+	// act
+	got := getExternalClusterHeartbeatFQDNs(settings.gslb, &settings.config)
+	// assert
+	assert.Equal(t, want, got, "got:\n %s unexpected heartbeat records,\n\n want:\n %s", got, want)
+}
+
+func TestCanCheckExternalGslbTXTRecordForValidityAndPAssIfItISNotExpired(t *testing.T) {
+	// arrange
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.EdgeDNSServer = "fake"
+	// act
+	_, err2 := checkAliveFromTXT(context.TODO(), "test-gslb-heartbeat-za.example.com", &customConfig, time.Minute*5)
+	// assert
+	assert.NoError(t, err2, "got:\n %s from TXT split brain check,\n\n want error:\n %v", err2, nil)
+}
+
+func TestReturnsOwnRecordsUsingFailoverStrategyWhenPrimary(t *testing.T) {
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+	}
+	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3"},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	customConfig := predefinedConfig
+	customConfig.ClusterGeoTag = "eu"
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+
+	// ingress
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+
+	// enable failover strategy
+	settings.gslb.Spec.Strategy.Type = "failover"
+	settings.gslb.Spec.Strategy.PrimaryGeoTag = "eu"
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+
+	// act
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+func TestReturnsExternalRecordsUsingFailoverStrategy(t *testing.T) {
+	// arrange
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.1.0.1", "10.1.0.2", "10.1.0.3"},
+		},
+	}
+	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3"},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	customConfig := predefinedConfig
+	customConfig.ClusterGeoTag = "za"
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+
+	// ingress
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+
+	// enable failover strategy
+	settings.gslb.Spec.Strategy.Type = "failover"
+	settings.gslb.Spec.Strategy.PrimaryGeoTag = "eu"
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+
+	// act
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+func TestEmbeddedDNSAnswersGeoStrategyByClientLocation(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3"},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.EmbeddedDNS.Enabled = true
+	settings := provideSettings(t, customConfig)
+	settings.reconciler.DNSServer = dnsserver.NewServer(dnsserver.Config{
+		Address: addr,
+		Zone:    predefinedConfig.DNSZone,
+		TTL:     embeddedDNSTtlSeconds,
+		// queries of the test come from 127.0.0.1
+		GeoTags: fakeGeoTags{"127.0.0.1": {"us-east-1", "us-west-1"}},
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = settings.reconciler.DNSServer.Start(stop)
+	}()
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	require.NoError(t, loadEmbeddedDNS(context.TODO(), settings.client, settings.reconciler.DNSServer))
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	var got []string
+	require.Eventually(t, func() bool {
+		got = nil
+		r, _, err := new(dns.Client).Exchange(new(dns.Msg).SetQuestion("roundrobin.cloud.example.com.", dns.TypeA), addr)
+		if err != nil {
+			return false
+		}
+		for _, rr := range r.Answer {
+			got = append(got, rr.(*dns.A).A.String())
+		}
+		return true
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// assert
+	// DNSEndpoint holds targets of all healthy regions, the closest region is served by embedded DNS only
+	for _, ep := range dnsEndpoint.Spec.Endpoints {
+		assert.Empty(t, ep.Labels[dnsserver.GeoTagLabel], "regional endpoint %s in DNSEndpoint", ep.DNSName)
+		if ep.DNSName == "roundrobin.cloud.example.com" {
+			assert.ElementsMatch(t, externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.1.0.1", "10.1.0.2", "10.1.0.3"}, ep.Targets)
+		}
+	}
+	assert.ElementsMatch(t, []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"}, got)
+}
+
+func TestGeoStrategyIsRejectedWithoutEmbeddedDNS(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	_, err = settings.reconciler.Reconcile(settings.request)
+	// assert
+	assert.EqualError(t, err, "strategy geo requires embedded DNS server, set EMBEDDED_DNS_ENABLED=true")
+}
+
+func TestGeoStrategyGslbCanBeDeletedWithoutEmbeddedDNS(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	deletionTimestamp := metav1.Now()
+	settings.gslb.SetDeletionTimestamp(&deletionTimestamp)
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	_, err = settings.reconciler.Reconcile(settings.request)
+	// assert
+	require.NoError(t, err)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err)
+	assert.NotContains(t, gslb.GetFinalizers(), gslbFinalizer)
+}
+
+func TestGeoStrategyCreatesRegionalEndpoints(t *testing.T) {
+	// arrange
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.1.0.1", "10.1.0.2", "10.1.0.3"},
+			Labels:     externaldns.Labels{dnsserver.GeoTagLabel: "us-east-1"},
+		},
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			Labels:     externaldns.Labels{dnsserver.GeoTagLabel: "us-west-1"},
+		},
+	}
+	ingressIPs := []corev1.LoadBalancerIngress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3"},
+	}
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, ingressIPs...)
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+
+	// act
+	_, got, err := settings.reconciler.gslbDNSEndpoint(context.TODO(), settings.gslb)
+	require.NoError(t, err)
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %s regional endpoints,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+func TestGeoDNSEndpointIsDeletedWithGeoStrategy(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.EmbeddedDNS.Enabled = true
+	settings := provideSettings(t, customConfig)
+	settings.gslb.Spec.Strategy.Type = geoStrategy
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	reconcileAndUpdateGslb(t, settings)
+	key := client.ObjectKey{Namespace: settings.gslb.Namespace, Name: "test-gslb-geo"}
+	geoDNSEndpoint := &externaldns.DNSEndpoint{}
+	require.NoError(t, settings.client.Get(context.TODO(), key, geoDNSEndpoint), "geo DNSEndpoint was not created")
+	assert.Equal(t, embeddedDNSType, geoDNSEndpoint.Annotations["k8gb.absa.oss/dnstype"])
+	assert.True(t, metav1.IsControlledBy(geoDNSEndpoint, settings.gslb), "geo DNSEndpoint is not controlled by Gslb")
+
+	// act
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.gslb))
+	settings.gslb.Spec.Strategy.Type = roundRobinStrategy
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	reconcileAndUpdateGslb(t, settings)
+
+	// assert
+	err := settings.client.Get(context.TODO(), key, &externaldns.DNSEndpoint{})
+	assert.True(t, errors.IsNotFound(err), "geo DNSEndpoint was not deleted: %v", err)
+}
+
+func TestEmbeddedDNSServesOnlyLocalAndEmbeddedDNSEndpoints(t *testing.T) {
+	// arrange
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	server := dnsserver.NewServer(dnsserver.Config{Address: addr, Zone: predefinedConfig.DNSZone, TTL: embeddedDNSTtlSeconds})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = server.Start(stop)
+	}()
+	dnsEndpoint := func(name, dnsType, host string) *externaldns.DNSEndpoint {
+		return &externaldns.DNSEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: name,
+				Annotations: map[string]string{"k8gb.absa.oss/dnstype": dnsType}},
+			Spec: externaldns.DNSEndpointSpec{Endpoints: []*externaldns.Endpoint{
+				{DNSName: host, RecordType: "A", Targets: externaldns.Targets{"10.0.0.1"}},
+			}},
+		}
+	}
+	local := dnsEndpoint("local", "local", "local.cloud.example.com")
+	geo := dnsEndpoint("local-geo", embeddedDNSType, "geo.cloud.example.com")
+	route53 := dnsEndpoint("k8gb-ns-route53", "route53", "route53.cloud.example.com")
+	// act
+	serveDNSEndpoint(server, local)
+	serveDNSEndpoint(server, geo)
+	serveDNSEndpoint(server, route53)
+	stopServingDNSEndpoint(server, toolscache.DeletedFinalStateUnknown{Key: "test-gslb/local-geo", Obj: geo})
+	server.SetLoaded()
+	// assert
+	assert.Equal(t, dns.RcodeSuccess, embeddedDNSRcode(t, addr, "local.cloud.example.com."))
+	assert.Equal(t, dns.RcodeNameError, embeddedDNSRcode(t, addr, "geo.cloud.example.com."))
+	assert.Equal(t, dns.RcodeNameError, embeddedDNSRcode(t, addr, "route53.cloud.example.com."))
+}
+
+// embeddedDNSRcode returns rcode of A query for name answered by embedded DNS server at addr
+func embeddedDNSRcode(t *testing.T, addr, name string) int {
+	t.Helper()
+	var r *dns.Msg
+	require.Eventually(t, func() bool {
+		var err error
+		r, _, err = new(dns.Client).Exchange(new(dns.Msg).SetQuestion(name, dns.TypeA), addr)
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	return r.Rcode
+}
+
+// fakeGeoTags maps client IP to geoTags
+type fakeGeoTags map[string][]string
+
+func (f fakeGeoTags) GeoTags(ip net.IP) []string {
+	return f[ip.String()]
+}
+
+func TestLatencyStrategyAnswersLocalTargetsWhileHealthy(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	settings := provideLatencySettings(t, predefinedConfig)
+	defer settings.reconciler.Metrics.Unregister()
+	require.NoError(t, settings.reconciler.Metrics.Register())
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	settings.reconciler.probeLatencies(context.TODO())
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	endpoints := getDNSEndpoints(t, settings)
+	gslb := &k8gbv1beta1.Gslb{}
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
+
+	// assert
+	require.Contains(t, endpoints, "roundrobin.cloud.example.com")
+	assert.Equal(t, want, endpoints["roundrobin.cloud.example.com"].Targets)
+	assert.Regexp(t, "^[0-9.]+[µm]?s$", gslb.Status.Latency["us-east-1"])
+	assert.Equal(t, 1, testutil.CollectAndCount(settings.reconciler.Metrics.GetPeerLatencyMetric()))
+}
+
+func TestLatencyStrategyFailsOverToClosestCluster(t *testing.T) {
+	// arrange
+	defer cleanup()
+	want := externaldns.Targets{"10.1.0.1", "10.1.0.2", "10.1.0.3"}
+	settings := provideLatencySettings(t, predefinedConfig)
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	endpoints := getDNSEndpoints(t, settings)
+
+	// assert
+	require.Contains(t, endpoints, "roundrobin.cloud.example.com")
+	assert.Equal(t, want, endpoints["roundrobin.cloud.example.com"].Targets)
+	// reconciliation only reads measurements of latency probe
+	assert.Empty(t, settings.reconciler.latencies.measurements())
+}
+
+func TestLatencyStrategyMeasuresProbeTargets(t *testing.T) {
+	// arrange
+	defer cleanup()
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer probe.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+	customConfig := predefinedConfig
+	customConfig.ExtClustersGeoTags = []string{"eu", "za"}
+	customConfig.LatencyProbeTargets = map[string]string{"eu": probe.Addr().String(), "za": closed.Addr().String()}
+	settings := provideLatencySettings(t, customConfig)
+	settings.reconciler.latencies.update("za", time.Millisecond)
+
+	// act
+	settings.reconciler.probeLatencies(context.TODO())
+	got := settings.reconciler.latencies.measurements()
+
+	// assert
+	// unreachable probe target of za cluster is not measured anymore
+	assert.Contains(t, got, "eu")
+	assert.NotContains(t, got, "za")
+}
+
+func TestLatencyProbePublishesMeasurementsOfCluster(t *testing.T) {
+	// arrange
+	defer cleanup()
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer probe.Close()
+	customConfig := predefinedConfig
+	customConfig.ExtClustersGeoTags = []string{"eu"}
+	customConfig.LatencyProbeTargets = map[string]string{"eu": probe.Addr().String()}
+	settings := provideLatencySettings(t, customConfig)
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	key := client.ObjectKey{Namespace: customConfig.K8gbNamespace, Name: latencyDNSEndpointName}
+
+	// act
+	settings.reconciler.probeLatencies(context.TODO())
+
+	// assert
+	err = settings.client.Get(context.TODO(), key, dnsEndpoint)
+	require.NoError(t, err, "Failed to get latency DNSEndpoint")
+	assert.Equal(t, "local", dnsEndpoint.Annotations["k8gb.absa.oss/dnstype"])
+	require.Len(t, dnsEndpoint.Spec.Endpoints, 1)
+	assert.Equal(t, "latency-us-west-1.cloud.example.com", dnsEndpoint.Spec.Endpoints[0].DNSName)
+	assert.Equal(t, "TXT", dnsEndpoint.Spec.Endpoints[0].RecordType)
+	assert.Regexp(t, "^eu=[0-9.]+[µm]?s$", dnsEndpoint.Spec.Endpoints[0].Targets[0])
+
+	// act
+	require.NoError(t, probe.Close())
+	settings.reconciler.probeLatencies(context.TODO())
+
+	// assert
+	// cluster which can't be reached anymore is not published
+	dnsEndpoint = &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), key, dnsEndpoint)
+	require.NoError(t, err, "Failed to get latency DNSEndpoint")
+	assert.Empty(t, dnsEndpoint.Spec.Endpoints)
+}
+
+func TestLatencyTrackerPublishesOnlyChangesAndPeriodically(t *testing.T) {
+	// arrange
+	tracker := &latencyTracker{}
+	now := time.Now()
+	tracker.update("eu", time.Millisecond)
+	// act
+	first := tracker.shouldPublish(now)
+	tracker.update("eu", 2*time.Millisecond)
+	sameClusters := tracker.shouldPublish(now.Add(latencyProbeInterval))
+	tracker.update("za", time.Millisecond)
+	newCluster := tracker.shouldPublish(now.Add(2 * latencyProbeInterval))
+	expired := tracker.shouldPublish(now.Add(2*latencyProbeInterval + latencyPublishInterval))
+	// assert
+	assert.True(t, first)
+	assert.False(t, sameClusters)
+	assert.True(t, newCluster)
+	assert.True(t, expired)
+}
+
+// provideLatencySettings returns settings of Gslb with latency strategy and load balancer IPs of its Ingress,
+// external clusters are answered by fake DNS
+func provideLatencySettings(t *testing.T, config depresolver.Config) testSettings {
+	t.Helper()
+	config.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, config)
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress,
+		corev1.LoadBalancerIngress{IP: "10.0.0.1"}, corev1.LoadBalancerIngress{IP: "10.0.0.2"}, corev1.LoadBalancerIngress{IP: "10.0.0.3"})
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	settings.gslb.Spec.Strategy.Type = latencyStrategy
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	return settings
+}
+
+// getDNSEndpoints returns endpoints of Gslb DNSEndpoint by DNS name
+func getDNSEndpoints(t *testing.T, settings testSettings) map[string]*externaldns.Endpoint {
+	t.Helper()
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	endpoints := make(map[string]*externaldns.Endpoint)
+	for _, ep := range dnsEndpoint.Spec.Endpoints {
+		endpoints[ep.DNSName] = ep
+	}
+	return endpoints
+}
+
+func TestReconcileSkipsUnchangedDNSEndpointAndIngress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	reconcileAndUpdateGslb(t, settings)
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	writes := settings.reconciler.Metrics.GetWritesMetric()
+	skippedDNSEndpoint := writes.With(prometheus.Labels{"namespace": settings.gslb.Namespace, "name": settings.gslb.Name,
+		"kind": "DNSEndpoint", "result": metrics.WriteSkipped})
+	skippedIngress := writes.With(prometheus.Labels{"namespace": settings.gslb.Namespace, "name": settings.gslb.Name,
+		"kind": "Ingress", "result": metrics.WriteSkipped})
+	skippedDNSEndpointBefore := testutil.ToFloat64(skippedDNSEndpoint)
+	skippedIngressBefore := testutil.ToFloat64(skippedIngress)
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	gotDNSEndpoint := &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gotDNSEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	gotIngress := &v1beta1.Ingress{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gotIngress)
+	require.NoError(t, err, "Failed to get expected ingress")
+
+	// assert
+	assert.Equal(t, dnsEndpoint.ResourceVersion, gotDNSEndpoint.ResourceVersion)
+	assert.Equal(t, settings.ingress.ResourceVersion, gotIngress.ResourceVersion)
+	assert.Equal(t, skippedDNSEndpointBefore+1, testutil.ToFloat64(skippedDNSEndpoint))
+	assert.Equal(t, skippedIngressBefore+1, testutil.ToFloat64(skippedIngress))
+}
+
+func TestReconcileUpdatesDNSEndpointWithOrderedTargets(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := externaldns.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	reconcileAndUpdateGslb(t, settings)
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	writes := settings.reconciler.Metrics.GetWritesMetric()
+	applied := writes.With(prometheus.Labels{"namespace": settings.gslb.Namespace, "name": settings.gslb.Name,
+		"kind": "DNSEndpoint", "result": metrics.WriteApplied})
+	appliedBefore := testutil.ToFloat64(applied)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress,
+		corev1.LoadBalancerIngress{IP: "10.0.0.3"}, corev1.LoadBalancerIngress{IP: "10.0.0.1"}, corev1.LoadBalancerIngress{IP: "10.0.0.2"})
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	got := getDNSEndpoints(t, settings)
+	gotDNSEndpoint := &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gotDNSEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+
+	// assert
+	assert.NotEqual(t, dnsEndpoint.ResourceVersion, gotDNSEndpoint.ResourceVersion)
+	assert.Equal(t, appliedBefore+1, testutil.ToFloat64(applied))
+	require.Contains(t, got, "localtargets-roundrobin.cloud.example.com")
+	assert.Equal(t, want, got["localtargets-roundrobin.cloud.example.com"].Targets)
+	require.Contains(t, got, "roundrobin.cloud.example.com")
+	assert.Equal(t, want, got["roundrobin.cloud.example.com"].Targets)
+}
+
+func TestGslbProperlyPropagatesAnnotationDownToIngress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.IngressPropagation.Annotations = []string{"annotation", "nginx.ingress.kubernetes.io/*"}
+	settings := provideSettings(t, customConfig)
+	settings.gslb.Annotations = map[string]string{"annotation": "test", "nginx.ingress.kubernetes.io/ssl-redirect": "false", "internal": "gslb only"}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	err2 := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	// assert
+	assert.NoError(t, err2, "Failed to get expected ingress")
+	assert.Equal(t, map[string]string{
+		"annotation": "test",
+		"nginx.ingress.kubernetes.io/ssl-redirect": "false",
+		propagatedAnnotationsAnnotation:            "annotation,nginx.ingress.kubernetes.io/ssl-redirect",
+		strategyAnnotation:                         roundRobinStrategy,
+		ingressSpecHashAnnotation:                  wantIngressSpecHash(t, settings.gslb),
+	}, settings.ingress.Annotations)
+	assert.Equal(t, map[string]string{"annotation": "test", "nginx.ingress.kubernetes.io/ssl-redirect": "false", "internal": "gslb only"},
+		settings.gslb.Annotations, "Gslb annotations were mutated")
+}
+
+func TestGslbPropagatesAllAnnotationsDownToIngressByDefault(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.gslb.Annotations = map[string]string{"annotation": "test"}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	// assert
+	require.NoError(t, err, "Failed to get expected ingress")
+	assert.Equal(t, "test", settings.ingress.Annotations["annotation"])
+}
+
+func TestGslbPropagatesLabelsDownToIngress(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.IngressPropagation.Labels = []string{"team"}
+	settings := provideSettings(t, customConfig)
+	settings.gslb.Labels = map[string]string{"team": "payments", "internal": "gslb only"}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	// assert
+	require.NoError(t, err, "Failed to get expected ingress")
+	assert.Equal(t, map[string]string{"team": "payments"}, settings.ingress.Labels)
+	assert.Equal(t, "team", settings.ingress.Annotations[propagatedLabelsAnnotation])
+}
+
+func TestGslbPreservesIngressMetadataNotManagedByK8gb(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.IngressPropagation.Annotations = []string{"annotation"}
+	customConfig.IngressPropagation.Labels = []string{"team"}
+	settings := provideSettings(t, customConfig)
+	settings.gslb.Annotations = map[string]string{"annotation": "test"}
+	settings.gslb.Labels = map[string]string{"team": "payments"}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	// metadata added by other operators
+	settings.ingress.Annotations["cert-manager.io/cluster-issuer"] = "letsencrypt"
+	settings.ingress.Labels["app.kubernetes.io/managed-by"] = "argocd"
+	err = settings.client.Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Can't update ingress")
+	// propagated metadata removed from Gslb
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
+	settings.gslb.Annotations = nil
+	settings.gslb.Labels = nil
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+	// act
+	_, err = settings.reconciler.Reconcile(settings.request)
+	require.NoError(t, err)
+	ingress := &v1beta1.Ingress{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, ingress)
+	// assert
+	require.NoError(t, err, "Failed to get expected ingress")
+	assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", strategyAnnotation: roundRobinStrategy,
+		ingressSpecHashAnnotation: wantIngressSpecHash(t, settings.gslb)}, ingress.Annotations)
+	assert.Equal(t, map[string]string{"app.kubernetes.io/managed-by": "argocd"}, ingress.Labels)
+}
+
+func TestReflectGeoTagInStatusAsUnsetByDefault(t *testing.T) {
+	// arrange
+	defer cleanup()
+	want := "us-west-1"
+	settings := provideSettings(t, predefinedConfig)
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	got := settings.gslb.Status.GeoTag
+	// assert
+	assert.Equal(t, want, got, "got: '%s' GeoTag status, want:'%s'", got, want)
+}
+
+func TestReflectGeoTagInTheStatus(t *testing.T) {
+	// arrange
+	defer cleanup()
+	want := "eu"
+	customConfig := predefinedConfig
+	customConfig.ClusterGeoTag = "eu"
+	settings := provideSettings(t, customConfig)
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	got := settings.gslb.Status.GeoTag
 	// assert
 	assert.Equal(t, want, got, "got: '%s' GeoTag status, want:'%s'", got, want)
 }
@@ -736,200 +1826,641 @@ func TestCreatesNSDNSRecordsForRoute53(t *testing.T) {
 	require.NoError(t, err, "Failed to update coredns service lb hostname")
 
 	// act
-	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
-	customConfig.ClusterGeoTag = "eu"
-	customConfig.ExtClustersGeoTags = []string{"za", "us"}
-	customConfig.DNSZone = dnsZone
-	// apply new environment variables and update config only
-	settings.reconciler.Config = &customConfig
-
+	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
+	customConfig.ClusterGeoTag = "eu"
+	customConfig.ExtClustersGeoTags = []string{"za", "us"}
+	customConfig.DNSZone = dnsZone
+	// apply new environment variables and update config only
+	settings.reconciler.Config = &customConfig
+
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpointRoute53.Annotations["k8gb.absa.oss/dnstype"]
+	gotEp := withoutHeartbeats(dnsEndpointRoute53.Spec.Endpoints)
+	prettyGot := utils.ToString(gotEp)
+	prettyWant := utils.ToString(wantEp)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %q annotation value,\n\n want:\n %q", got, want)
+	assert.Equal(t, wantEp, gotEp, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+// withoutHeartbeats drops split brain TXT records, their timestamps change on every reconciliation
+func withoutHeartbeats(endpoints []*externaldns.Endpoint) (filtered []*externaldns.Endpoint) {
+	for _, ep := range endpoints {
+		if ep.RecordType != "TXT" {
+			filtered = append(filtered, ep)
+		}
+	}
+	return filtered
+}
+
+func TestCreatesNSDNSRecordsForNS1(t *testing.T) {
+	// arrange
+	defer cleanup()
+	const dnsZone = "cloud.example.com"
+	const want = "ns1"
+	wantEp := []*externaldns.Endpoint{
+		{
+			DNSName:    dnsZone,
+			RecordTTL:  30,
+			RecordType: "NS",
+			Targets: externaldns.Targets{
+				"gslb-ns-cloud-example-com-eu.example.com",
+				"gslb-ns-cloud-example-com-us.example.com",
+				"gslb-ns-cloud-example-com-za.example.com",
+			},
+		},
+		{
+			DNSName:    "gslb-ns-cloud-example-com-eu.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets: externaldns.Targets{
+				"1.0.0.1",
+				"1.1.1.1",
+			},
+		},
+	}
+	dnsEndpointNS1 := &externaldns.DNSEndpoint{}
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSServer = "1.1.1.1"
+	customConfig.CoreDNSExposed = true
+	coreDNSService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      coreDNSExtServiceName,
+			Namespace: predefinedConfig.K8gbNamespace,
+		},
+	}
+	serviceIPs := []corev1.LoadBalancerIngress{
+		{Hostname: "one.one.one.one"}, // rely on 1.1.1.1 response from Cloudflare
+	}
+	settings := provideSettings(t, customConfig)
+	err := settings.client.Create(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to create testing %s service", coreDNSExtServiceName)
+	coreDNSService.Status.LoadBalancer.Ingress = append(coreDNSService.Status.LoadBalancer.Ingress, serviceIPs...)
+	err = settings.client.Status().Update(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to update coredns service lb hostname")
+
+	// act
+	customConfig.EdgeDNSType = depresolver.DNSTypeNS1
+	customConfig.ClusterGeoTag = "eu"
+	customConfig.ExtClustersGeoTags = []string{"za", "us"}
+	customConfig.DNSZone = dnsZone
+	// apply new environment variables and update config only
+	settings.reconciler.Config = &customConfig
+
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-ns1"}, dnsEndpointNS1)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpointNS1.Annotations["k8gb.absa.oss/dnstype"]
+	gotEp := withoutHeartbeats(dnsEndpointNS1.Spec.Endpoints)
+	prettyGot := utils.ToString(gotEp)
+	prettyWant := utils.ToString(wantEp)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %q annotation value,\n\n want:\n %q", got, want)
+	assert.Equal(t, wantEp, gotEp, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+func TestResolvesLoadBalancerHostnameFromIngressStatus(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"1.0.0.1", "1.1.1.1"}},
+		{
+			DNSName:    "roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"1.0.0.1", "1.1.1.1"}},
+	}
+	settings := provideSettings(t, predefinedConfig)
+	dnsEndpoint := &externaldns.DNSEndpoint{ObjectMeta: metav1.ObjectMeta{Namespace: settings.gslb.Namespace, Name: settings.gslb.Name}}
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+
+	settings.ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "one.one.one.one"}}
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+
+	// act
+	err = settings.client.Delete(context.Background(), dnsEndpoint)
+	require.NoError(t, err, "Failed to update DNSEndpoint")
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+}
+
+func TestRoute53ZoneDelegationGarbageCollection(t *testing.T) {
+	// arrange
+	defer cleanup()
+
+	customConfig := predefinedConfig
+	settings := provideSettings(t, customConfig)
+	coreDNSService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      coreDNSExtServiceName,
+			Namespace: predefinedConfig.K8gbNamespace,
+		},
+	}
+	serviceIPs := []corev1.LoadBalancerIngress{
+		{Hostname: "one.one.one.one"}, // rely on 1.1.1.1 response from Cloudflare
+	}
+	err := settings.client.Create(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to create testing %s service", coreDNSExtServiceName)
+	coreDNSService.Status.LoadBalancer.Ingress = append(coreDNSService.Status.LoadBalancer.Ingress, serviceIPs...)
+	err = settings.client.Status().Update(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to update coredns service lb hostname")
+
+	// act
+	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
+	// apply new environment variables and update config only
+	settings.reconciler.Config = &customConfig
+	reconcileAndUpdateGslb(t, settings)
+
+	deletionTimestamp := metav1.Now()
+	settings.gslb.SetDeletionTimestamp(&deletionTimestamp)
+	err = settings.client.Update(context.Background(), settings.gslb)
+	require.NoError(t, err, "Failed to update Gslb")
+	settings.finalCall = true // Gslb is about to be deleted, no requeue expected
+	reconcileAndUpdateGslb(t, settings)
+
+	// assert
+	dnsEndpointRoute53 := &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
+	require.Error(t, err, "k8gb-ns-route53 DNSEndpoint should be garbage collected")
+}
+
+func TestGslbSetsAnnotationsOnTheIngress(t *testing.T) {
+	// arrange
+	defer cleanup()
+
+	settings := provideSettings(t, predefinedConfig)
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+
+	// assert
+	ingress := &v1beta1.Ingress{}
+	err := settings.client.Get(context.Background(), client.ObjectKey{Namespace: settings.gslb.Namespace, Name: settings.gslb.Name}, ingress)
+	require.NoError(t, err, "Gslb should be created from annotated Ingress")
+
+	assert.Equal(t, map[string]string{strategyAnnotation: "roundRobin", ingressSpecHashAnnotation: wantIngressSpecHash(t, settings.gslb)},
+		ingress.Annotations)
+}
+
+func TestGslbUpdatesIngressWhenSpecChanges(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	ingress := &v1beta1.Ingress{}
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	// defaults applied by API server don't make the spec look changed
+	pathType := v1beta1.PathTypeImplementationSpecific
+	ingress.Spec.Rules[0].HTTP.Paths[0].PathType = &pathType
+	require.NoError(t, settings.client.Update(context.TODO(), ingress))
+	reconcileAndUpdateGslb(t, settings)
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, ingress))
+	assert.Equal(t, &pathType, ingress.Spec.Rules[0].HTTP.Paths[0].PathType, "defaulted Ingress was rewritten")
+
+	// act
+	settings.gslb.Spec.Ingress.Rules[0].Host = "changed.cloud.example.com"
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	reconcileAndUpdateGslb(t, settings)
+
+	// assert
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, ingress))
+	assert.Equal(t, "changed.cloud.example.com", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, wantIngressSpecHash(t, settings.gslb), ingress.Annotations[ingressSpecHashAnnotation])
+}
+
+func wantIngressSpecHash(t *testing.T, gslb *k8gbv1beta1.Gslb) string {
+	t.Helper()
+	hash, err := ingressSpecHash(gslb.Spec.Ingress)
+	require.NoError(t, err)
+	return hash
+}
+
+func TestGslbCreatesDNSEndpointForServiceTypeLoadBalancer(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-grpc.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.2.0.1", "10.2.0.2"}},
+		{
+			DNSName:    "grpc.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.2.0.1", "10.2.0.2"}},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	service := &corev1.Service{}
+	err := settings.client.Get(context.TODO(), client.ObjectKey{Namespace: settings.gslb.Namespace, Name: serviceName}, service)
+	require.NoError(t, err, "Failed to get expected service")
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	err = settings.client.Update(context.TODO(), service)
+	require.NoError(t, err, "Failed to update service type")
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.2.0.1"}, {IP: "10.2.0.2"}}
+	err = settings.client.Status().Update(context.TODO(), service)
+	require.NoError(t, err, "Failed to update service LoadBalancer status")
+	settings.gslb.Spec.Ingress = v1beta1.IngressSpec{}
+	settings.gslb.Spec.Services = []k8gbv1beta1.ServiceRef{{Host: "grpc.cloud.example.com", ServiceName: serviceName}}
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
+
+	// assert
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, map[string]string{"grpc.cloud.example.com": "Healthy"}, gslb.Status.ServiceHealth)
+}
+
+func TestGslbCreatesDNSEndpointForHTTPRoute(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-gateway.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.3.0.1"}},
+		{
+			DNSName:    "gateway.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.3.0.1"}},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "10.3.0.1"}},
+		},
+	}}
+	gateway.SetAPIVersion(gatewayAPIGroupVersion)
+	gateway.SetKind(gatewayKind)
+	gateway.SetNamespace("gateway-system")
+	gateway.SetName("public")
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hostnames":  []interface{}{"gateway.cloud.example.com"},
+			"parentRefs": []interface{}{map[string]interface{}{"name": "public", "namespace": "gateway-system"}},
+			"rules": []interface{}{map[string]interface{}{
+				"backendRefs": []interface{}{map[string]interface{}{"name": serviceName, "port": int64(80)}},
+			}},
+		},
+	}}
+	route.SetAPIVersion(gatewayAPIGroupVersion)
+	route.SetKind(httpRouteKind)
+	route.SetNamespace(settings.gslb.Namespace)
+	route.SetName("podinfo")
+	for _, o := range []runtime.Object{gateway, route} {
+		err := settings.client.Create(context.TODO(), o)
+		require.NoError(t, err, "Failed to create Gateway API object")
+	}
+	settings.gslb.Spec.Ingress = v1beta1.IngressSpec{}
+	settings.gslb.Spec.HTTPRoute = &k8gbv1beta1.HTTPRouteRef{Name: "podinfo"}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
+
+	// act
 	reconcileAndUpdateGslb(t, settings)
-	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
 	require.NoError(t, err, "Failed to get expected DNSEndpoint")
-	got := dnsEndpointRoute53.Annotations["k8gb.absa.oss/dnstype"]
-	gotEp := dnsEndpointRoute53.Spec.Endpoints
-	prettyGot := utils.ToString(gotEp)
-	prettyWant := utils.ToString(wantEp)
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
 
 	// assert
-	assert.Equal(t, want, got, "got:\n %q annotation value,\n\n want:\n %q", got, want)
-	assert.Equal(t, wantEp, gotEp, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, map[string]string{"gateway.cloud.example.com": "Healthy"}, gslb.Status.ServiceHealth)
+	wantRequests := []reconcile.Request{settings.request}
+	assert.Equal(t, wantRequests, settings.reconciler.httpRouteRequests(handler.MapObject{Meta: route, Object: route}))
+	assert.Equal(t, wantRequests, settings.reconciler.referenceRequests(gatewayGroupKind)(handler.MapObject{Meta: gateway, Object: gateway}))
+	backend := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: settings.gslb.Namespace}}
+	assert.Equal(t, wantRequests, settings.reconciler.referenceRequests(serviceGroupKind)(handler.MapObject{Meta: backend, Object: backend}))
 }
 
-func TestCreatesNSDNSRecordsForNS1(t *testing.T) {
+func TestGslbCreatesDNSEndpointForIstioVirtualService(t *testing.T) {
 	// arrange
 	defer cleanup()
-	const dnsZone = "cloud.example.com"
-	const want = "ns1"
-	wantEp := []*externaldns.Endpoint{
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
 		{
-			DNSName:    dnsZone,
+			DNSName:    "localtargets-istio.cloud.example.com",
 			RecordTTL:  30,
-			RecordType: "NS",
-			Targets: externaldns.Targets{
-				"gslb-ns-cloud-example-com-eu.example.com",
-				"gslb-ns-cloud-example-com-us.example.com",
-				"gslb-ns-cloud-example-com-za.example.com",
-			},
-		},
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.4.0.1"}},
 		{
-			DNSName:    "gslb-ns-cloud-example-com-eu.example.com",
+			DNSName:    "istio.cloud.example.com",
 			RecordTTL:  30,
 			RecordType: "A",
-			Targets: externaldns.Targets{
-				"1.0.0.1",
-				"1.1.1.1",
-			},
-		},
+			Targets:    externaldns.Targets{"10.4.0.1"}},
 	}
-	dnsEndpointNS1 := &externaldns.DNSEndpoint{}
-	customConfig := predefinedConfig
-	customConfig.EdgeDNSServer = "1.1.1.1"
-	customConfig.CoreDNSExposed = true
-	coreDNSService := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      coreDNSExtServiceName,
-			Namespace: predefinedConfig.K8gbNamespace,
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	ingressGateway := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway", Namespace: "istio-system"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"app": "istio-ingressgateway", "istio": "ingressgateway"},
 		},
 	}
-	serviceIPs := []corev1.LoadBalancerIngress{
-		{Hostname: "one.one.one.one"}, // rely on 1.1.1.1 response from Cloudflare
+	err := settings.client.Create(context.TODO(), ingressGateway)
+	require.NoError(t, err, "Failed to create Istio ingress gateway service")
+	ingressGateway.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.4.0.1"}}
+	err = settings.client.Status().Update(context.TODO(), ingressGateway)
+	require.NoError(t, err, "Failed to update Istio ingress gateway LoadBalancer status")
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"istio": "ingressgateway"},
+		},
+	}}
+	gateway.SetAPIVersion(istioGroupVersion)
+	gateway.SetKind(istioGatewayKind)
+	gateway.SetNamespace("istio-system")
+	gateway.SetName("public-gateway")
+	vs := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hosts":    []interface{}{"istio.cloud.example.com", "frontend"},
+			"gateways": []interface{}{"istio-system/public-gateway", "mesh"},
+			"http": []interface{}{map[string]interface{}{
+				"route": []interface{}{map[string]interface{}{
+					"destination": map[string]interface{}{"host": serviceName + "." + settings.gslb.Namespace + ".svc.cluster.local"},
+				}},
+			}},
+		},
+	}}
+	vs.SetAPIVersion(istioGroupVersion)
+	vs.SetKind(istioVirtualServiceKind)
+	vs.SetNamespace(settings.gslb.Namespace)
+	vs.SetName("podinfo")
+	for _, o := range []runtime.Object{gateway, vs} {
+		err = settings.client.Create(context.TODO(), o)
+		require.NoError(t, err, "Failed to create Istio object")
 	}
-	settings := provideSettings(t, customConfig)
-	err := settings.client.Create(context.TODO(), coreDNSService)
-	require.NoError(t, err, "Failed to create testing %s service", coreDNSExtServiceName)
-	coreDNSService.Status.LoadBalancer.Ingress = append(coreDNSService.Status.LoadBalancer.Ingress, serviceIPs...)
-	err = settings.client.Status().Update(context.TODO(), coreDNSService)
-	require.NoError(t, err, "Failed to update coredns service lb hostname")
+	settings.gslb.Spec.Ingress = v1beta1.IngressSpec{}
+	settings.gslb.Spec.VirtualService = &k8gbv1beta1.VirtualServiceRef{Name: "podinfo"}
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
 
 	// act
-	customConfig.EdgeDNSType = depresolver.DNSTypeNS1
-	customConfig.ClusterGeoTag = "eu"
-	customConfig.ExtClustersGeoTags = []string{"za", "us"}
-	customConfig.DNSZone = dnsZone
-	// apply new environment variables and update config only
-	settings.reconciler.Config = &customConfig
-
 	reconcileAndUpdateGslb(t, settings)
-	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-ns1"}, dnsEndpointNS1)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
 	require.NoError(t, err, "Failed to get expected DNSEndpoint")
-	got := dnsEndpointNS1.Annotations["k8gb.absa.oss/dnstype"]
-	gotEp := dnsEndpointNS1.Spec.Endpoints
-	prettyGot := utils.ToString(gotEp)
-	prettyWant := utils.ToString(wantEp)
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
 
 	// assert
-	assert.Equal(t, want, got, "got:\n %q annotation value,\n\n want:\n %q", got, want)
-	assert.Equal(t, wantEp, gotEp, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, map[string]string{"istio.cloud.example.com": "Healthy"}, gslb.Status.ServiceHealth)
+	wantRequests := []reconcile.Request{settings.request}
+	assert.Equal(t, wantRequests, settings.reconciler.virtualServiceRequests(handler.MapObject{Meta: vs, Object: vs}))
+	assert.Equal(t, wantRequests, settings.reconciler.referenceRequests(istioGatewayGroupKind)(handler.MapObject{Meta: gateway, Object: gateway}))
+	assert.Equal(t, wantRequests, settings.reconciler.referenceRequests(serviceGroupKind)(handler.MapObject{Meta: ingressGateway, Object: ingressGateway}))
+	destination := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: settings.gslb.Namespace}}
+	assert.Equal(t, wantRequests, settings.reconciler.referenceRequests(serviceGroupKind)(handler.MapObject{Meta: destination, Object: destination}))
 }
 
-func TestResolvesLoadBalancerHostnameFromIngressStatus(t *testing.T) {
+func TestGslbCreatesDNSEndpointForGatewayListener(t *testing.T) {
 	// arrange
 	defer cleanup()
-	serviceName := "frontend-podinfo"
 	want := []*externaldns.Endpoint{
 		{
-			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			DNSName:    "localtargets-listener.cloud.example.com",
 			RecordTTL:  30,
 			RecordType: "A",
-			Targets:    externaldns.Targets{"1.0.0.1", "1.1.1.1"}},
+			Targets:    externaldns.Targets{"10.3.0.2"}},
 		{
-			DNSName:    "roundrobin.cloud.example.com",
+			DNSName:    "listener.cloud.example.com",
 			RecordTTL:  30,
 			RecordType: "A",
-			Targets:    externaldns.Targets{"1.0.0.1", "1.1.1.1"}},
+			Targets:    externaldns.Targets{"10.3.0.2"}},
 	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
 	settings := provideSettings(t, predefinedConfig)
-	dnsEndpoint := &externaldns.DNSEndpoint{ObjectMeta: metav1.ObjectMeta{Namespace: settings.gslb.Namespace, Name: settings.gslb.Name}}
-	createHealthyService(t, &settings, serviceName)
-	defer deleteHealthyService(t, &settings, serviceName)
-	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
-	require.NoError(t, err, "Failed to get expected ingress")
-
-	settings.ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "one.one.one.one"}}
-	err = settings.client.Status().Update(context.TODO(), settings.ingress)
-	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	programmed := []interface{}{map[string]interface{}{"type": "Programmed", "status": "True"}}
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "https", "hostname": "listener.cloud.example.com"},
+				map[string]interface{}{"name": "idle", "hostname": "idle.cloud.example.com"},
+				map[string]interface{}{"name": "wildcard", "hostname": "*.cloud.example.com"},
+			},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "10.3.0.2"}},
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "https", "attachedRoutes": int64(1), "conditions": programmed},
+				map[string]interface{}{"name": "idle", "attachedRoutes": int64(0), "conditions": programmed},
+				map[string]interface{}{"name": "wildcard", "attachedRoutes": int64(1), "conditions": programmed},
+			},
+		},
+	}}
+	gateway.SetAPIVersion(gatewayAPIGroupVersion)
+	gateway.SetKind(gatewayKind)
+	gateway.SetNamespace(settings.gslb.Namespace)
+	gateway.SetName("public")
+	err := settings.client.Create(context.TODO(), gateway)
+	require.NoError(t, err, "Failed to create Gateway")
+	settings.gslb.Spec.Ingress = v1beta1.IngressSpec{}
+	settings.gslb.Spec.Gateway = &k8gbv1beta1.GatewayListenerRef{Name: "public"}
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
 
 	// act
-	err = settings.client.Delete(context.Background(), dnsEndpoint)
-	require.NoError(t, err, "Failed to update DNSEndpoint")
 	reconcileAndUpdateGslb(t, settings)
 	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
 	require.NoError(t, err, "Failed to get expected DNSEndpoint")
 	got := dnsEndpoint.Spec.Endpoints
 	prettyGot := utils.ToString(got)
 	prettyWant := utils.ToString(want)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
+	sectionGslb := gslb.DeepCopy()
+	sectionGslb.Spec.Gateway.SectionName = "idle"
+	sectionTargets, err := settings.reconciler.getLocalTargets(context.TODO(), sectionGslb)
+	require.NoError(t, err, "Failed to get targets of Gateway listener")
 
 	// assert
 	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, map[string]string{"listener.cloud.example.com": "Healthy", "idle.cloud.example.com": "Unhealthy"},
+		gslb.Status.ServiceHealth)
+	assert.Equal(t, map[string][]string{"idle.cloud.example.com": {"10.3.0.2"}}, sectionTargets)
+	assert.Equal(t, []reconcile.Request{settings.request},
+		settings.reconciler.referenceRequests(gatewayGroupKind)(handler.MapObject{Meta: gateway, Object: gateway}))
 }
 
-func TestRoute53ZoneDelegationGarbageCollection(t *testing.T) {
+func TestGslbReadsReferencedIngressWithoutWritingIt(t *testing.T) {
 	// arrange
 	defer cleanup()
-
-	customConfig := predefinedConfig
-	settings := provideSettings(t, customConfig)
-	coreDNSService := &corev1.Service{
+	serviceName := "frontend-podinfo"
+	want := []*externaldns.Endpoint{
+		{
+			DNSName:    "localtargets-referenced.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.4.0.1"}},
+		{
+			DNSName:    "referenced.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.4.0.1"}},
+	}
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	settings := provideSettings(t, predefinedConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	ingress := &v1beta1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      coreDNSExtServiceName,
-			Namespace: predefinedConfig.K8gbNamespace,
+			Namespace:   settings.gslb.Namespace,
+			Name:        "user-ingress",
+			Annotations: map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"},
 		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host: "referenced.cloud.example.com",
+				IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+					Paths: []v1beta1.HTTPIngressPath{{Backend: v1beta1.IngressBackend{ServiceName: serviceName}}},
+				}},
+			}},
+		},
+		Status: v1beta1.IngressStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "10.4.0.1"}},
+		}},
 	}
-	serviceIPs := []corev1.LoadBalancerIngress{
-		{Hostname: "one.one.one.one"}, // rely on 1.1.1.1 response from Cloudflare
-	}
-	err := settings.client.Create(context.TODO(), coreDNSService)
-	require.NoError(t, err, "Failed to create testing %s service", coreDNSExtServiceName)
-	coreDNSService.Status.LoadBalancer.Ingress = append(coreDNSService.Status.LoadBalancer.Ingress, serviceIPs...)
-	err = settings.client.Status().Update(context.TODO(), coreDNSService)
-	require.NoError(t, err, "Failed to update coredns service lb hostname")
+	err := settings.client.Create(context.TODO(), ingress)
+	require.NoError(t, err, "Failed to create referenced Ingress")
+	settings.gslb.Spec.Ingress = v1beta1.IngressSpec{}
+	settings.gslb.Spec.IngressRef = &k8gbv1beta1.IngressRef{Name: "user-ingress"}
+	err = settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
 
 	// act
-	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
-	// apply new environment variables and update config only
-	settings.reconciler.Config = &customConfig
-	reconcileAndUpdateGslb(t, settings)
-
-	deletionTimestamp := metav1.Now()
-	settings.gslb.SetDeletionTimestamp(&deletionTimestamp)
-	err = settings.client.Update(context.Background(), settings.gslb)
-	require.NoError(t, err, "Failed to update Gslb")
-	settings.finalCall = true // Gslb is about to be deleted, no requeue expected
 	reconcileAndUpdateGslb(t, settings)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	got := dnsEndpoint.Spec.Endpoints
+	prettyGot := utils.ToString(got)
+	prettyWant := utils.ToString(want)
+	gslb := &k8gbv1beta1.Gslb{}
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb)
+	require.NoError(t, err, "Failed to get expected gslb")
+	found := &v1beta1.Ingress{}
+	err = settings.client.Get(context.TODO(), types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, found)
+	require.NoError(t, err, "Failed to get referenced Ingress")
 
 	// assert
-	dnsEndpointRoute53 := &externaldns.DNSEndpoint{}
-	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
-	require.Error(t, err, "k8gb-ns-route53 DNSEndpoint should be garbage collected")
+	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
+	assert.Equal(t, map[string]string{"referenced.cloud.example.com": "Healthy"}, gslb.Status.ServiceHealth)
+	assert.Equal(t, ingress.ResourceVersion, found.ResourceVersion, "referenced Ingress was written")
+	assert.Empty(t, found.OwnerReferences)
+	backend := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: settings.gslb.Namespace}}
+	assert.Equal(t, []reconcile.Request{settings.request},
+		settings.reconciler.referenceRequests(serviceGroupKind)(handler.MapObject{Meta: backend, Object: backend}))
 }
 
-func TestGslbSetsAnnotationsOnTheIngress(t *testing.T) {
+func TestGslbWithIngressAndServicesIsRejected(t *testing.T) {
 	// arrange
 	defer cleanup()
-
 	settings := provideSettings(t, predefinedConfig)
+	settings.gslb.Spec.Services = []k8gbv1beta1.ServiceRef{{Host: "grpc.cloud.example.com", ServiceName: "grpc"}}
+	err := settings.client.Update(context.TODO(), settings.gslb)
+	require.NoError(t, err, "Can't update gslb")
 
 	// act
-	reconcileAndUpdateGslb(t, settings)
+	_, err = settings.reconciler.Reconcile(settings.request)
 
 	// assert
-	ingress := &v1beta1.Ingress{}
-	err := settings.client.Get(context.Background(), client.ObjectKey{Namespace: settings.gslb.Namespace, Name: settings.gslb.Name}, ingress)
-	require.NoError(t, err, "Gslb should be created from annotated Ingress")
-
-	assert.Equal(t, map[string]string{strategyAnnotation: "roundRobin"}, ingress.Annotations)
+	assert.Error(t, err)
 }
 
 func TestMain(m *testing.M) {
 	// setup tests
-	fakeDNS()
+	var err error
+	edgeDNS, err = fakedns.New()
+	if err != nil {
+		panic(fmt.Errorf("starting fake DNS: %w", err))
+	}
+	seedEdgeDNS()
+	predefinedConfig.Override.FakeDNSAddress = edgeDNS.Address()
+	fakeInfoblox.edgeDNS = edgeDNS
 	// run tests
 	exitVal := m.Run()
 	// teardown
+	_ = edgeDNS.Close()
 	os.Exit(exitVal)
 }
 
+// seedEdgeDNS serves records of edge DNS and external clusters queried by tests
+func seedEdgeDNS() {
+	records := []struct {
+		name   string
+		rrType uint16
+		values []string
+	}{
+		{"localtargets-roundrobin.cloud.example.com", dns.TypeA, []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"}},
+		{"localtargets-dualstack.cloud.example.com", dns.TypeA, []string{"10.1.0.4"}},
+		{"localtargets-dualstack.cloud.example.com", dns.TypeAAAA, []string{"2001:db8::1:4"}},
+		{"localtargets-elb.cloud.example.com", dns.TypeCNAME, []string{"k8gb-eu.elb.eu-west-1.amazonaws.com."}},
+		{"test-gslb-heartbeat-eu.example.com", dns.TypeTXT, []string{oldEdgeTimestamp("10m")}},
+		{"test-gslb-heartbeat-za.example.com", dns.TypeTXT, []string{oldEdgeTimestamp("3m")}},
+	}
+	for _, r := range records {
+		if err := edgeDNS.Add(r.name, r.rrType, r.values...); err != nil {
+			panic(fmt.Errorf("seeding fake DNS: %w", err))
+		}
+	}
+}
+
+func oldEdgeTimestamp(threshold string) string {
+	duration, _ := time.ParseDuration(threshold)
+	return time.Now().Add(-duration).UTC().Format("2006-01-02T15:04:05")
+}
+
 func createHealthyService(t *testing.T, s *testSettings, serviceName string) {
 	t.Helper()
 	service := &corev1.Service{
@@ -1070,6 +2601,195 @@ func reconcileAndUpdateGslb(t *testing.T, s testSettings) {
 	}
 }
 
+func TestInfobloxDelegatesZoneToAllLiveClusters(t *testing.T) {
+	// arrange
+	defer cleanup()
+	fakeInfoblox.reset()
+	westNS := "gslb-ns-cloud-example-com-us-west-1.example.com"
+	eastNS := "gslb-ns-cloud-example-com-us-east-1.example.com"
+	want := []ibclient.NameServer{
+		{Address: "10.0.0.1", Name: westNS},
+		{Address: "10.0.0.2", Name: westNS},
+		{Address: "10.1.0.1", Name: eastNS},
+	}
+	// act
+	west := provideInfobloxCluster(t, "us-west-1", []string{"us-east-1"}, "10.0.0.1", "10.0.0.2")
+	westOnly := fakeInfoblox.zoneDelegated("cloud.example.com")
+	provideInfobloxCluster(t, "us-east-1", []string{"us-west-1"}, "10.1.0.1")
+	_, err := west.reconciler.Reconcile(west.request)
+	require.NoError(t, err)
+	zone := fakeInfoblox.zoneDelegated("cloud.example.com")
+	// assert
+	require.NotNil(t, westOnly)
+	assert.ElementsMatch(t, want[:2], westOnly.DelegateTo)
+	require.NotNil(t, zone)
+	assert.ElementsMatch(t, want, zone.DelegateTo)
+	for _, geoTag := range []string{"us-west-1", "us-east-1"} {
+		heartbeat := fakeInfoblox.txtRecords(fmt.Sprintf("test-gslb-heartbeat-%s.example.com", geoTag))
+		require.Len(t, heartbeat, 1, "expected single heartbeat TXT record of %s cluster", geoTag)
+		timestamp, err := time.Parse("2006-01-02T15:04:05", heartbeat[0])
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().UTC(), timestamp, time.Minute)
+	}
+}
+
+// Stale clusters used to be filtered out of delegateTo by their heartbeat FQDN, which never matches a name server,
+// so the delegated zone kept pointing to dead clusters
+func TestInfobloxFiltersOutStaleClusterFromDelegatedZone(t *testing.T) {
+	// arrange
+	defer cleanup()
+	fakeInfoblox.reset()
+	// test-gslb-heartbeat-eu is expired and test-gslb-heartbeat-za is alive in fake DNS
+	_, err := fakeInfoblox.CreateObject(ibclient.NewZoneDelegated(ibclient.ZoneDelegated{
+		Fqdn: "cloud.example.com",
+		DelegateTo: []ibclient.NameServer{
+			{Address: "10.2.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+			{Address: "10.3.0.1", Name: "gslb-ns-cloud-example-com-za.example.com"},
+			{Address: "10.9.9.9", Name: "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		},
+	}))
+	require.NoError(t, err)
+	want := []ibclient.NameServer{
+		{Address: "10.3.0.1", Name: "gslb-ns-cloud-example-com-za.example.com"},
+		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-us-west-1.example.com"},
+	}
+	// act
+	provideInfobloxCluster(t, "us-west-1", []string{"eu", "za"}, "10.0.0.1")
+	zone := fakeInfoblox.zoneDelegated("cloud.example.com")
+	// assert
+	require.NotNil(t, zone)
+	assert.Equal(t, want, zone.DelegateTo)
+}
+
+func TestInfobloxFinalizeRemovesDelegatedZoneAndHeartbeat(t *testing.T) {
+	// arrange
+	defer cleanup()
+	fakeInfoblox.reset()
+	settings := provideInfobloxCluster(t, "us-west-1", []string{"us-east-1"}, "10.0.0.1")
+	require.NotNil(t, fakeInfoblox.zoneDelegated("cloud.example.com"))
+	require.Len(t, fakeInfoblox.txtRecords("test-gslb-heartbeat-us-west-1.example.com"), 1)
+	// act
+	err := settings.reconciler.finalizeGslb(context.TODO(), settings.gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Nil(t, fakeInfoblox.zoneDelegated("cloud.example.com"))
+	assert.Empty(t, fakeInfoblox.txtRecords("test-gslb-heartbeat-us-west-1.example.com"))
+}
+
+func TestInfobloxConnectionVerifiesWAPICertificate(t *testing.T) {
+	// arrange
+	wapi := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer wapi.Close()
+	host, port, err := net.SplitHostPort(wapi.Listener.Addr().String())
+	require.NoError(t, err)
+	caFile, err := ioutil.TempFile("", "infoblox-ca")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: wapi.Certificate().Raw})
+	require.NoError(t, err)
+	invalidCAFile, err := ioutil.TempFile("", "infoblox-ca-invalid")
+	require.NoError(t, err)
+	defer os.Remove(invalidCAFile.Name())
+	config := predefinedConfig
+	config.Infoblox.Host = host
+	config.Infoblox.Port, _ = strconv.Atoi(port)
+	config.Infoblox.HTTPRequestTimeout = 5
+	config.Infoblox.HTTPPoolConnections = 1
+	config.Override.FakeInfobloxEnabled = false
+	credentials := infobloxCredentials{username: "foo", password: "blah"}
+	var tests = []struct {
+		name      string
+		sslVerify bool
+		caFile    string
+		connected bool
+	}{
+		{"CA bundle", true, caFile.Name(), true},
+		{"system CAs", true, "", false},
+		{"insecure opt-in", false, "", true},
+		{"missing CA bundle", true, caFile.Name() + ".missing", false},
+		{"CA bundle without certificates", true, invalidCAFile.Name(), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.Infoblox.SSLVerify = test.sslVerify
+			config.Infoblox.CAFile = test.caFile
+			c := &infobloxClient{}
+			// act
+			conn, err := c.connect(&config, credentials)
+			// assert
+			assert.Equal(t, test.connected, err == nil, "unexpected connect error: (%v)", err)
+			assert.Equal(t, test.connected, conn != nil)
+		})
+	}
+}
+
+func TestInfobloxConnectionIsReusedAndRotatedWithCredentialsSecret(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	r := settings.reconciler
+	r.Config.Infoblox.CredentialsSecret = "infoblox"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "infoblox",
+			Namespace: predefinedConfig.K8gbNamespace,
+		},
+		Data: map[string][]byte{
+			depresolver.InfobloxUsernameKey: []byte("foo"),
+			depresolver.InfobloxPasswordKey: []byte("blah"),
+		},
+	}
+	// act
+	_, errMissingSecret := r.Reconcile(settings.request)
+	err := settings.client.Create(context.TODO(), secret)
+	require.NoError(t, err, "Failed to create infoblox credentials secret")
+	_, err = r.Reconcile(settings.request)
+	require.NoError(t, err)
+	connector := r.infoblox.connector
+	_, err = r.Reconcile(settings.request)
+	require.NoError(t, err)
+	reusedConnector := r.infoblox.connector
+	secret.Data[depresolver.InfobloxPasswordKey] = []byte("rotated")
+	err = settings.client.Update(context.TODO(), secret)
+	require.NoError(t, err, "Failed to rotate infoblox credentials secret")
+	requests := r.infobloxSecretRequests(handler.MapObject{Meta: secret, Object: secret})
+	// reconciliation may be using the connection, it is replaced only by the next one
+	connectorAfterEvent := r.infoblox.connector
+	_, err = r.Reconcile(settings.request)
+	require.NoError(t, err)
+	// assert
+	assert.Error(t, errMissingSecret)
+	assert.NotNil(t, connector)
+	assert.Same(t, connector, reusedConnector)
+	assert.Same(t, connector, connectorAfterEvent)
+	assert.Equal(t, []reconcile.Request{settings.request}, requests)
+	assert.NotSame(t, connector, r.infoblox.connector)
+	assert.Equal(t, infobloxCredentials{username: "foo", password: "rotated"}, r.infoblox.credentials)
+}
+
+func TestInfobloxSecretRequestsIgnoresOtherSecrets(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	r := settings.reconciler
+	r.Config.Infoblox.CredentialsSecret = "infoblox"
+	connector := r.infoblox.connector
+	secrets := []*corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: predefinedConfig.K8gbNamespace}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "infoblox", Namespace: "default"}},
+	}
+	for _, secret := range secrets {
+		// act
+		requests := r.infobloxSecretRequests(handler.MapObject{Meta: secret, Object: secret})
+		// assert
+		assert.Empty(t, requests)
+		assert.Same(t, connector, r.infoblox.connector)
+		assert.False(t, r.infoblox.stale)
+	}
+}
+
 func provideSettings(t *testing.T, expected depresolver.Config) (settings testSettings) {
 	configureEnvVar(expected)
 	_, err := os.Stat(crSampleYaml)
@@ -1091,9 +2811,9 @@ func provideSettings(t *testing.T, expected depresolver.Config) (settings testSe
 	}
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(k8gbv1beta1.GroupVersion, gslb)
+	s.AddKnownTypes(k8gbv1beta1.GroupVersion, gslb, &k8gbv1beta1.GslbList{})
 	// Register external-dns DNSEndpoint CRD
-	s.AddKnownTypes(schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}, &externaldns.DNSEndpoint{})
+	s.AddKnownTypes(schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}, &externaldns.DNSEndpoint{}, &externaldns.DNSEndpointList{})
 	// Create a fake client to mock API calls.
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 	// Create config
@@ -1146,15 +2866,40 @@ func provideSettings(t *testing.T, expected depresolver.Config) (settings testSe
 	return settings
 }
 
+// provideInfobloxCluster simulates k8gb cluster delegating zone in fake Infoblox, exposing Gslb on ingress IPs
+func provideInfobloxCluster(t *testing.T, geoTag string, extGeoTags []string, ingressIPs ...string) testSettings {
+	t.Helper()
+	config := predefinedConfig
+	config.ClusterGeoTag = geoTag
+	config.ExtClustersGeoTags = extGeoTags
+	config.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, config)
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	for _, ip := range ingressIPs {
+		settings.ingress.Status.LoadBalancer.Ingress = append(settings.ingress.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	_, err = settings.reconciler.Reconcile(settings.request)
+	require.NoError(t, err)
+	return settings
+}
+
 func cleanup() {
 	for _, s := range []string{depresolver.ReconcileRequeueSecondsKey, depresolver.ClusterGeoTagKey, depresolver.ExtClustersGeoTagsKey,
 		depresolver.EdgeDNSZoneKey, depresolver.DNSZoneKey, depresolver.EdgeDNSServerKey, depresolver.K8gbNamespaceKey,
 		depresolver.Route53EnabledKey, depresolver.InfobloxGridHostKey, depresolver.InfobloxVersionKey, depresolver.InfobloxPortKey,
-		depresolver.InfobloxUsernameKey, depresolver.InfobloxPasswordKey, depresolver.OverrideWithFakeDNSKey, depresolver.OverrideFakeInfobloxKey} {
+		depresolver.InfobloxUsernameKey, depresolver.InfobloxPasswordKey, depresolver.OverrideWithFakeDNSKey, depresolver.OverrideFakeInfobloxKey,
+		depresolver.LatencyProbeTargetsKey, depresolver.InfobloxSecretKey, depresolver.FakeDNSAddressKey, depresolver.FakeDNSPeersKey,
+		depresolver.PropagatedAnnotationsKey, depresolver.PropagatedLabelsKey} {
 		if os.Unsetenv(s) != nil {
 			panic(fmt.Errorf("cleanup %s", s))
 		}
 	}
+	fakeInfoblox.reset()
+	edgeDNS.Reset()
+	seedEdgeDNS()
 }
 
 func configureEnvVar(config depresolver.Config) {
@@ -1171,6 +2916,21 @@ func configureEnvVar(config depresolver.Config) {
 	_ = os.Setenv(depresolver.InfobloxPortKey, strconv.Itoa(config.Infoblox.Port))
 	_ = os.Setenv(depresolver.InfobloxUsernameKey, config.Infoblox.Username)
 	_ = os.Setenv(depresolver.InfobloxPasswordKey, config.Infoblox.Password)
+	_ = os.Setenv(depresolver.InfobloxSecretKey, config.Infoblox.CredentialsSecret)
 	_ = os.Setenv(depresolver.OverrideWithFakeDNSKey, strconv.FormatBool(config.Override.FakeDNSEnabled))
 	_ = os.Setenv(depresolver.OverrideFakeInfobloxKey, strconv.FormatBool(config.Override.FakeInfobloxEnabled))
+	_ = os.Setenv(depresolver.FakeDNSAddressKey, config.Override.FakeDNSAddress)
+	_ = os.Setenv(depresolver.EmbeddedDNSEnabledKey, strconv.FormatBool(config.EmbeddedDNS.Enabled))
+	var peers []string
+	for geoTag, address := range config.Override.FakeDNSPeers {
+		peers = append(peers, geoTag+"="+address)
+	}
+	_ = os.Setenv(depresolver.FakeDNSPeersKey, strings.Join(peers, ","))
+	var probeTargets []string
+	for geoTag, address := range config.LatencyProbeTargets {
+		probeTargets = append(probeTargets, geoTag+"="+address)
+	}
+	_ = os.Setenv(depresolver.LatencyProbeTargetsKey, strings.Join(probeTargets, ","))
+	_ = os.Setenv(depresolver.PropagatedAnnotationsKey, strings.Join(config.IngressPropagation.Annotations, ","))
+	_ = os.Setenv(depresolver.PropagatedLabelsKey, strings.Join(config.IngressPropagation.Labels, ","))
 }
@@ -0,0 +1,91 @@
+package gslbdns
+
+import (
+	"sort"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+)
+
+// HostAnswer is DNS answer of Gslb host by one k8gb cluster
+type HostAnswer struct {
+	// Targets answered for the host
+	Targets []string
+	// Serving are geoTags of clusters whose targets are answered, the local cluster first
+	Serving []string
+}
+
+// AnswerHost returns answer of the cluster with clusterGeoTag for Gslb host with local health and targets.
+// externalTargetsByGeoTag are targets answered by clusters in other locations listed by extGeoTags, latencies
+// their round-trip times measured for latency strategy. Answers previewed by kubectl-k8gb simulate come from here
+// as well, so they can't drift from answers of the operator
+func AnswerHost(strategy k8gbv1beta1.Strategy, clusterGeoTag string, extGeoTags []string, health string, localTargets []string,
+	externalTargetsByGeoTag map[string][]string, latencies map[string]time.Duration) HostAnswer {
+	var targets, serving []string
+	if health == "Healthy" && len(localTargets) > 0 {
+		targets = append(targets, localTargets...)
+		serving = append(serving, clusterGeoTag)
+	}
+	externalTargets := MergeTargets(extGeoTags, externalTargetsByGeoTag)
+	if len(externalTargets) > 0 {
+		var externalServing []string
+		for _, geoTag := range extGeoTags {
+			if len(externalTargetsByGeoTag[geoTag]) > 0 {
+				externalServing = append(externalServing, geoTag)
+			}
+		}
+		switch strategy.Type {
+		case depresolver.RoundRobinStrategy, depresolver.GeoStrategy:
+			targets = append(targets, externalTargets...)
+			serving = append(serving, externalServing...)
+		case depresolver.LatencyStrategy:
+			// Local cluster is the closest one while Healthy, otherwise the cluster with the lowest latency is answered
+			if health != "Healthy" {
+				if geoTag, found := ClosestGeoTag(latencies, extGeoTags, externalTargetsByGeoTag); found {
+					targets = externalTargetsByGeoTag[geoTag]
+					serving = []string{geoTag}
+				}
+			}
+		case depresolver.FailoverStrategy:
+			// Primary answers own targets while Healthy, secondary answers targets of external clusters whenever
+			// they have any
+			if strategy.PrimaryGeoTag != clusterGeoTag || health != "Healthy" {
+				targets = externalTargets
+				serving = externalServing
+			}
+		}
+	}
+	return HostAnswer{Targets: targets, Serving: serving}
+}
+
+// ClosestGeoTag returns geoTag of the cluster with the lowest round-trip time among clusters having targets.
+// Clusters which were not measured yet follow in order of geoTags
+func ClosestGeoTag(measurements map[string]time.Duration, geoTags []string, targetsByGeoTag map[string][]string) (string, bool) {
+	var candidates []string
+	for _, geoTag := range geoTags {
+		if len(targetsByGeoTag[geoTag]) > 0 {
+			candidates = append(candidates, geoTag)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		rttI, measuredI := measurements[candidates[i]]
+		rttJ, measuredJ := measurements[candidates[j]]
+		if measuredI != measuredJ {
+			return measuredI
+		}
+		return rttI < rttJ
+	})
+	return candidates[0], true
+}
+
+// MergeTargets returns targets of all geoTags in given order
+func MergeTargets(geoTags []string, targetsByGeoTag map[string][]string) (targets []string) {
+	for _, geoTag := range geoTags {
+		targets = append(targets, targetsByGeoTag[geoTag]...)
+	}
+	return targets
+}
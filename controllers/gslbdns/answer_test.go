@@ -0,0 +1,38 @@
+package gslbdns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosestGeoTagPrefersLowestMeasuredLatency(t *testing.T) {
+	var tests = []struct {
+		name         string
+		measurements map[string]time.Duration
+		targets      map[string][]string
+		want         string
+		found        bool
+	}{
+		{"lowest latency", map[string]time.Duration{"eu": 30 * time.Millisecond, "za": 10 * time.Millisecond},
+			map[string][]string{"eu": {"10.1.0.1"}, "za": {"10.2.0.1"}}, "za", true},
+		{"lowest latency without targets", map[string]time.Duration{"eu": 30 * time.Millisecond, "za": 10 * time.Millisecond},
+			map[string][]string{"eu": {"10.1.0.1"}}, "eu", true},
+		{"measured before not measured", map[string]time.Duration{"za": 10 * time.Millisecond},
+			map[string][]string{"eu": {"10.1.0.1"}, "za": {"10.2.0.1"}}, "za", true},
+		{"not measured in order", map[string]time.Duration{},
+			map[string][]string{"eu": {"10.1.0.1"}, "za": {"10.2.0.1"}}, "eu", true},
+		{"no targets", map[string]time.Duration{"eu": 30 * time.Millisecond},
+			map[string][]string{}, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// act
+			got, found := ClosestGeoTag(test.measurements, []string{"eu", "za"}, test.targets)
+			// assert
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.found, found)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package gslbdns names DNS records k8gb clusters publish and answers Gslb hosts from targets of the clusters.
+// It is shared by the operator and kubectl-k8gb plugin, so it must not depend on the operator itself
+package gslbdns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+)
+
+// HeartbeatTimestampLayout is UTC time format of split brain TXT record value
+const HeartbeatTimestampLayout = "2006-01-02T15:04:05"
+
+// HeartbeatTXTName returns FQDN of split brain TXT record written to EdgeDNS by the cluster with geoTag for Gslb
+func HeartbeatTXTName(gslbName, geoTag string, config *depresolver.Config) string {
+	return fmt.Sprintf("%s-heartbeat-%s.%s", gslbName, geoTag, config.EdgeDNSZone)
+}
+
+// LocalTargetsName returns FQDN of record holding targets of host healthy in the cluster itself, clusters in other
+// locations query it to learn targets of the cluster
+func LocalTargetsName(host string) string {
+	return fmt.Sprintf("localtargets-%s", host)
+}
+
+// LatencyTXTName returns FQDN of TXT record with round-trip times the cluster with geoTag measured to clusters in
+// other locations. It is served by name server of the cluster only
+func LatencyTXTName(geoTag string, config *depresolver.Config) string {
+	return fmt.Sprintf("latency-%s.%s", geoTag, config.DNSZone)
+}
+
+// NSServerName returns FQDN of name server of the cluster with geoTag, the zone is delegated to it in EdgeDNS
+func NSServerName(geoTag string, config *depresolver.Config) string {
+	dnsZoneIntoNS := strings.ReplaceAll(config.DNSZone, ".", "-")
+	return fmt.Sprintf("gslb-ns-%s-%s.%s", dnsZoneIntoNS, geoTag, config.EdgeDNSZone)
+}
@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Gateway API types are read as unstructured objects, so k8gb doesn't depend on Gateway API CRDs
+// being installed in the clusters which don't use them
+const (
+	gatewayAPIGroup        = "gateway.networking.k8s.io"
+	gatewayAPIGroupVersion = gatewayAPIGroup + "/v1"
+	gatewayKind            = "Gateway"
+	httpRouteKind          = "HTTPRoute"
+)
+
+var (
+	gatewayGroupKind   = schema.GroupKind{Group: gatewayAPIGroup, Kind: gatewayKind}
+	httpRouteGroupKind = schema.GroupKind{Group: gatewayAPIGroup, Kind: httpRouteKind}
+)
+
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;gateways,verbs=get;list;watch
+
+func (r *GslbReconciler) getHTTPRoute(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*unstructured.Unstructured, error) {
+	route := &unstructured.Unstructured{}
+	route.SetAPIVersion(gatewayAPIGroupVersion)
+	route.SetKind(httpRouteKind)
+	err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Spec.HTTPRoute.Name}, route)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Info("Can't find gslb HTTPRoute", "httpRoute", gslb.Spec.HTTPRoute.Name)
+		}
+		return nil, err
+	}
+	return route, nil
+}
+
+// getGslbHTTPRouteTargets returns addresses of parent Gateways for every HTTPRoute hostname. Parent Gateways and
+// backendRefs Services are indexed, so their changes reconcile the Gslb
+func (r *GslbReconciler) getGslbHTTPRouteTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	route, err := r.getHTTPRoute(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	if err != nil {
+		return nil, err
+	}
+	var references []reference
+	for _, service := range httpRouteBackendServices(route) {
+		references = append(references, reference{GroupKind: serviceGroupKind, NamespacedName: service})
+	}
+	var gatewayTargets []string
+	for _, p := range parentRefs {
+		parentRef, ok := p.(map[string]interface{})
+		if !ok || !isGatewayAPIRef(parentRef, gatewayAPIGroup, gatewayKind) {
+			continue
+		}
+		gateway := &unstructured.Unstructured{}
+		gateway.SetAPIVersion(gatewayAPIGroupVersion)
+		gateway.SetKind(gatewayKind)
+		gatewayName := refNamespacedName(parentRef, route.GetNamespace())
+		references = append(references, reference{GroupKind: gatewayGroupKind, NamespacedName: gatewayName})
+		err = r.Get(ctx, gatewayName, gateway)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				logging.FromContext(ctx).Info("Can't find parent Gateway of HTTPRoute", "httpRoute", route.GetName(), "gateway", gatewayName.String())
+				continue
+			}
+			return nil, err
+		}
+		gatewayTargets = append(gatewayTargets, loadBalancerTargets(gatewayAddresses(gateway))...)
+	}
+	r.references.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, references)
+	targets := make(map[string][]string)
+	for _, host := range httpRouteHostnames(route) {
+		targets[host] = gatewayTargets
+	}
+	return targets, nil
+}
+
+// httpRouteRequests maps changed HTTPRoute to Gslbs referencing it by spec.httpRoute
+func (r *GslbReconciler) httpRouteRequests(a handler.MapObject) []reconcile.Request {
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.List(context.TODO(), gslbList, client.InNamespace(a.Meta.GetNamespace()))
+	if err != nil {
+		log.Error(err, "Can't fetch gslb objects", "httpRoute", a.Meta.GetName(), logging.NamespaceKey, a.Meta.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbList.Items {
+		if gslb.Spec.HTTPRoute != nil && gslb.Spec.HTTPRoute.Name == a.Meta.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      gslb.Name,
+				Namespace: gslb.Namespace,
+			}})
+		}
+	}
+	return requests
+}
+
+// getHTTPRouteHealthStatus returns health of HTTPRoute hostnames. Hostname is Healthy when at least one
+// of the backendRefs Services is Healthy
+func (r *GslbReconciler) getHTTPRouteHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	route, err := r.getHTTPRoute(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	health, err := r.getServicesHealth(ctx, httpRouteBackendServices(route))
+	if err != nil {
+		return nil, err
+	}
+	serviceHealth := make(map[string]string)
+	for _, host := range httpRouteHostnames(route) {
+		serviceHealth[host] = health
+	}
+	return serviceHealth, nil
+}
+
+func (r *GslbReconciler) getGateway(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*unstructured.Unstructured, error) {
+	gateway := &unstructured.Unstructured{}
+	gateway.SetAPIVersion(gatewayAPIGroupVersion)
+	gateway.SetKind(gatewayKind)
+	err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Spec.Gateway.Name}, gateway)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Info("Can't find gslb Gateway", "gateway", gslb.Spec.Gateway.Name)
+		}
+		return nil, err
+	}
+	return gateway, nil
+}
+
+// getGslbGatewayTargets returns Gateway addresses for every hostname of the referenced Gateway listeners.
+// The Gateway is indexed, so its changes reconcile the Gslb
+func (r *GslbReconciler) getGslbGatewayTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	r.references.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, []reference{{
+		GroupKind:      gatewayGroupKind,
+		NamespacedName: types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Spec.Gateway.Name},
+	}})
+	gateway, err := r.getGateway(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	gatewayTargets := loadBalancerTargets(gatewayAddresses(gateway))
+	targets := make(map[string][]string)
+	for _, listener := range gatewayListeners(gateway, gslb.Spec.Gateway.SectionName) {
+		if listener.hostname != "" {
+			targets[listener.hostname] = gatewayTargets
+		}
+	}
+	return targets, nil
+}
+
+// getGatewayHealthStatus returns health of Gateway listener hostnames. Hostname is Healthy when at least one
+// of its listeners is programmed and has routes attached, Gateway doesn't expose backends of the routes
+func (r *GslbReconciler) getGatewayHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	gateway, err := r.getGateway(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	serviceHealth := make(map[string]string)
+	for _, listener := range gatewayListeners(gateway, gslb.Spec.Gateway.SectionName) {
+		if listener.hostname == "" || serviceHealth[listener.hostname] == "Healthy" {
+			continue
+		}
+		serviceHealth[listener.hostname] = "Unhealthy"
+		if listener.programmed && listener.attachedRoutes > 0 {
+			serviceHealth[listener.hostname] = "Healthy"
+		}
+	}
+	return serviceHealth, nil
+}
+
+type gatewayListener struct {
+	hostname       string
+	programmed     bool
+	attachedRoutes int64
+}
+
+// gatewayListeners returns Gateway listeners merged with their status, all of them when sectionName is empty.
+// Wildcard hostnames are skipped
+func gatewayListeners(gateway *unstructured.Unstructured, sectionName string) (listeners []gatewayListener) {
+	statuses := make(map[string]map[string]interface{})
+	listenerStatuses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "listeners")
+	for _, s := range listenerStatuses {
+		status, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(status, "name")
+		statuses[name] = status
+	}
+	specListeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	for _, l := range specListeners {
+		spec, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(spec, "name")
+		if sectionName != "" && name != sectionName {
+			continue
+		}
+		listener := gatewayListener{}
+		listener.hostname, _, _ = unstructured.NestedString(spec, "hostname")
+		if strings.HasPrefix(listener.hostname, "*") {
+			listener.hostname = ""
+		}
+		if status, ok := statuses[name]; ok {
+			listener.attachedRoutes, _, _ = unstructured.NestedInt64(status, "attachedRoutes")
+			listener.programmed = hasTrueCondition(status, "Programmed")
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners
+}
+
+// hasTrueCondition checks the condition of given type has status True
+func hasTrueCondition(status map[string]interface{}, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t, _, _ := unstructured.NestedString(condition, "type")
+		s, _, _ := unstructured.NestedString(condition, "status")
+		if t == conditionType {
+			return s == "True"
+		}
+	}
+	return false
+}
+
+func httpRouteHostnames(route *unstructured.Unstructured) []string {
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	return hostnames
+}
+
+// httpRouteBackendServices returns Services referenced by backendRefs across all HTTPRoute rules
+func httpRouteBackendServices(route *unstructured.Unstructured) (services []types.NamespacedName) {
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, b := range backendRefs {
+			backendRef, ok := b.(map[string]interface{})
+			if !ok || !isGatewayAPIRef(backendRef, "", "Service") {
+				continue
+			}
+			services = append(services, refNamespacedName(backendRef, route.GetNamespace()))
+		}
+	}
+	return services
+}
+
+// gatewayAddresses converts Gateway status addresses into load balancer status entries
+func gatewayAddresses(gateway *unstructured.Unstructured) (lbIngress []corev1.LoadBalancerIngress) {
+	addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+	for _, a := range addresses {
+		address, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addressType, _, _ := unstructured.NestedString(address, "type")
+		value, _, _ := unstructured.NestedString(address, "value")
+		switch addressType {
+		case "", "IPAddress":
+			lbIngress = append(lbIngress, corev1.LoadBalancerIngress{IP: value})
+		case "Hostname":
+			lbIngress = append(lbIngress, corev1.LoadBalancerIngress{Hostname: value})
+		}
+	}
+	return lbIngress
+}
+
+// isGatewayAPIRef checks group and kind of Gateway API object reference. Missing values are defaulted
+// to given group and kind as Gateway API does
+func isGatewayAPIRef(ref map[string]interface{}, group, kind string) bool {
+	refGroup, found, _ := unstructured.NestedString(ref, "group")
+	if !found {
+		refGroup = group
+	}
+	refKind, found, _ := unstructured.NestedString(ref, "kind")
+	if !found {
+		refKind = kind
+	}
+	return refGroup == group && refKind == kind
+}
+
+func refNamespacedName(ref map[string]interface{}, defaultNamespace string) types.NamespacedName {
+	name, _, _ := unstructured.NestedString(ref, "name")
+	namespace, _, _ := unstructured.NestedString(ref, "namespace")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"sync"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
+	ibclient "github.com/infobloxopen/infoblox-go-client"
+	corev1 "k8s.io/api/core/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+)
+
+// +kubebuilder:rbac:groups="",namespace=system,resources=secrets,verbs=get;list;watch
+
+// infobloxCredentials to Infoblox WAPI
+type infobloxCredentials struct {
+	username string
+	password string
+}
+
+// infobloxClient keeps one connection to Infoblox WAPI across reconciliations. The connection is opened
+// again only when credentials or CA bundle change, or when it is marked stale
+type infobloxClient struct {
+	sync.Mutex
+	connector   ibclient.IBConnector
+	credentials infobloxCredentials
+	caBundle    []byte
+	stale       bool
+}
+
+// connect returns shared connector, replacing the existing one when credentials or CA bundle differ
+func (c *infobloxClient) connect(config *depresolver.Config, credentials infobloxCredentials) (ibclient.IBConnector, error) {
+	c.Lock()
+	defer c.Unlock()
+	caBundle, err := readCABundle(config)
+	if err != nil {
+		return nil, err
+	}
+	if c.connector != nil && !c.stale && c.credentials == credentials && bytes.Equal(c.caBundle, caBundle) {
+		return c.connector, nil
+	}
+	c.close()
+	c.stale = false
+	if config.Override.FakeInfobloxEnabled {
+		c.connector = &fakeInfobloxConnector{fakeInfoblox}
+		c.credentials = credentials
+		c.caBundle = caBundle
+		return c.connector, nil
+	}
+	hostConfig := ibclient.HostConfig{
+		Host:     config.Infoblox.Host,
+		Version:  config.Infoblox.Version,
+		Port:     strconv.Itoa(config.Infoblox.Port),
+		Username: credentials.username,
+		Password: credentials.password,
+	}
+	transportConfig := infobloxTransportConfig(config)
+	requestBuilder := &ibclient.WapiRequestBuilder{}
+	requestor := &ibclient.WapiHttpRequestor{}
+	conn, err := ibclient.NewConnector(hostConfig, transportConfig, requestBuilder, requestor)
+	if err != nil {
+		return nil, err
+	}
+	c.connector = conn
+	c.credentials = credentials
+	c.caBundle = caBundle
+	return c.connector, nil
+}
+
+// invalidate marks shared connection stale, so the next reconciliation connects again. The connection is not
+// closed here as reconciliation may be using it, it is replaced by connect
+func (c *infobloxClient) invalidate() {
+	c.Lock()
+	defer c.Unlock()
+	c.stale = true
+}
+
+// close logs out from WAPI and drops connector. Caller must hold the lock
+func (c *infobloxClient) close() {
+	if conn, ok := c.connector.(*ibclient.Connector); ok {
+		err := conn.Logout()
+		if err != nil {
+			log.Error(err, "Failed to close connection to infoblox")
+		}
+	}
+	c.connector = nil
+	c.credentials = infobloxCredentials{}
+	c.caBundle = nil
+}
+
+// readCABundle returns content of CA bundle verifying WAPI certificate, or nil when system CAs are used. The bundle
+// is read on every connect, so the connection is opened again once mounted ConfigMap or Secret changes
+func readCABundle(config *depresolver.Config) ([]byte, error) {
+	if !config.Infoblox.SSLVerify || config.Infoblox.CAFile == "" {
+		return nil, nil
+	}
+	caBundle, err := ioutil.ReadFile(config.Infoblox.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading infoblox CA bundle: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("infoblox CA bundle %s contains no PEM certificates", config.Infoblox.CAFile)
+	}
+	return caBundle, nil
+}
+
+// infobloxTransportConfig verifies WAPI certificate against the CA bundle or system CAs, unless insecure
+// connection is explicitly enabled
+func infobloxTransportConfig(config *depresolver.Config) ibclient.TransportConfig {
+	sslVerify := strconv.FormatBool(config.Infoblox.SSLVerify)
+	if config.Infoblox.SSLVerify && config.Infoblox.CAFile != "" {
+		// ibclient loads CA bundle from path passed instead of "true"
+		sslVerify = config.Infoblox.CAFile
+	}
+	return ibclient.NewTransportConfig(sslVerify, config.Infoblox.HTTPRequestTimeout, config.Infoblox.HTTPPoolConnections)
+}
+
+// infobloxConnection returns object manager on top of the shared WAPI connection
+func (r *GslbReconciler) infobloxConnection(ctx context.Context) (*ibclient.ObjectManager, error) {
+	credentials, err := r.infobloxCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := r.infoblox.connect(r.Config, credentials)
+	if err != nil {
+		return nil, err
+	}
+	return ibclient.NewObjectManager(tracing.NewIBConnector(ctx, conn), "ohmyclient", ""), nil
+}
+
+// infobloxCredentials reads credentials from the Secret when configured, otherwise from operator configuration
+func (r *GslbReconciler) infobloxCredentials(ctx context.Context) (infobloxCredentials, error) {
+	if r.Config.Infoblox.CredentialsSecret == "" {
+		return infobloxCredentials{username: r.Config.Infoblox.Username, password: r.Config.Infoblox.Password}, nil
+	}
+	secret := &corev1.Secret{}
+	err := r.secretReader().Get(ctx, types.NamespacedName{
+		Namespace: r.Config.K8gbNamespace,
+		Name:      r.Config.Infoblox.CredentialsSecret,
+	}, secret)
+	if err != nil {
+		return infobloxCredentials{}, fmt.Errorf("reading infoblox credentials secret(%s): %w", r.Config.Infoblox.CredentialsSecret, err)
+	}
+	credentials := infobloxCredentials{
+		username: string(secret.Data[depresolver.InfobloxUsernameKey]),
+		password: string(secret.Data[depresolver.InfobloxPasswordKey]),
+	}
+	if credentials.username == "" || credentials.password == "" {
+		return infobloxCredentials{}, fmt.Errorf("infoblox credentials secret(%s) must contain %s and %s",
+			r.Config.Infoblox.CredentialsSecret, depresolver.InfobloxUsernameKey, depresolver.InfobloxPasswordKey)
+	}
+	return credentials, nil
+}
+
+// secretReader returns reader of Secrets cached in operator namespace only. Reconciler client is used when the
+// cache is not set up with manager
+func (r *GslbReconciler) secretReader() client.Reader {
+	if r.secrets == nil {
+		return r.Client
+	}
+	return r.secrets
+}
+
+// setupInfobloxSecretWatch watches credentials Secret through cache limited to operator namespace, so Secrets of
+// other namespaces are neither cached nor accessible to the operator
+func (r *GslbReconciler) setupInfobloxSecretWatch(mgr ctrl.Manager, b *builder.Builder) (*builder.Builder, error) {
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: r.Config.K8gbNamespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = mgr.Add(secretCache)
+	if err != nil {
+		return nil, err
+	}
+	r.secrets = secretCache
+	return b.Watches(source.NewKindWithCache(&corev1.Secret{}, secretCache),
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.infobloxSecretRequests)}), nil
+}
+
+// infobloxSecretRequests marks the shared connection stale when credentials Secret changes and requests
+// reconciliation of all Gslbs, so zone delegation is configured again with rotated credentials
+func (r *GslbReconciler) infobloxSecretRequests(a handler.MapObject) []reconcile.Request {
+	if a.Meta.GetNamespace() != r.Config.K8gbNamespace || a.Meta.GetName() != r.Config.Infoblox.CredentialsSecret {
+		return nil
+	}
+	log.Info("Infoblox credentials secret changed, reconnecting", "secret", a.Meta.GetName())
+	r.infoblox.invalidate()
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.List(context.TODO(), gslbList)
+	if err != nil {
+		log.Error(err, "Can't fetch gslb objects")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      gslb.Name,
+			Namespace: gslb.Namespace,
+		}})
+	}
+	return requests
+}
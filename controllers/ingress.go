@@ -2,9 +2,17 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	"github.com/AbsaOSS/k8gb/controllers/metrics"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -12,47 +20,74 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// Gslb annotations and labels copied to the Ingress by propagation allowlist are recorded on the Ingress, so keys
+// removed from the Gslb are removed from the Ingress as well. Keys set by anybody else are never touched
+const (
+	propagatedAnnotationsAnnotation = "k8gb.io/propagated-annotations"
+	propagatedLabelsAnnotation      = "k8gb.io/propagated-labels"
+)
+
+// ingressSpecHashAnnotation records hash of Gslb spec.ingress the Ingress was written from. API server defaults
+// fields of the live spec (e.g. pathType), so it never equals the desired one and can't be compared directly
+const ingressSpecHashAnnotation = "k8gb.io/ingress-spec-hash"
+
 func (r *GslbReconciler) gslbIngress(gslb *k8gbv1beta1.Gslb) (*v1beta1.Ingress, error) {
-	if gslb.Annotations == nil {
-		gslb.Annotations = make(map[string]string)
+	annotations := propagatedMetadata(gslb.Annotations, r.Config.IngressPropagation.Annotations)
+	labels := propagatedMetadata(gslb.Labels, r.Config.IngressPropagation.Labels)
+	if len(annotations) > 0 {
+		annotations[propagatedAnnotationsAnnotation] = joinKeys(annotations)
+	}
+	if len(labels) > 0 {
+		annotations[propagatedLabelsAnnotation] = joinKeys(labels)
 	}
-	gslb.Annotations[strategyAnnotation] = gslb.Spec.Strategy.Type
+	specHash, err := ingressSpecHash(gslb.Spec.Ingress)
+	if err != nil {
+		return nil, err
+	}
+	annotations[ingressSpecHashAnnotation] = specHash
+	annotations[strategyAnnotation] = gslb.Spec.Strategy.Type
 	if gslb.Spec.Strategy.PrimaryGeoTag != "" {
-		gslb.Annotations[primaryGeoTagAnnotation] = gslb.Spec.Strategy.PrimaryGeoTag
+		annotations[primaryGeoTagAnnotation] = gslb.Spec.Strategy.PrimaryGeoTag
+	}
+	if len(labels) == 0 {
+		labels = nil
 	}
 	ingress := &v1beta1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        gslb.Name,
 			Namespace:   gslb.Namespace,
-			Annotations: gslb.Annotations,
+			Annotations: annotations,
+			Labels:      labels,
 		},
 		Spec: gslb.Spec.Ingress,
 	}
 
-	err := controllerutil.SetControllerReference(gslb, ingress, r.Scheme)
+	err = controllerutil.SetControllerReference(gslb, ingress, r.Scheme)
 	if err != nil {
 		return nil, err
 	}
 	return ingress, err
 }
 
-func (r *GslbReconciler) ensureIngress(instance *k8gbv1beta1.Gslb, i *v1beta1.Ingress) (*reconcile.Result, error) {
+func (r *GslbReconciler) ensureIngress(ctx context.Context, instance *k8gbv1beta1.Gslb, i *v1beta1.Ingress) (*reconcile.Result, error) {
 	found := &v1beta1.Ingress{}
-	err := r.Get(context.TODO(), types.NamespacedName{
+	log := logging.FromContext(ctx).WithValues("ingress", i.Name)
+	err := r.Get(ctx, types.NamespacedName{
 		Name:      instance.Name,
 		Namespace: instance.Namespace,
 	}, found)
 	if err != nil && errors.IsNotFound(err) {
 
 		// Create the service
-		log.Info("Creating a new Ingress", "Ingress.Namespace", i.Namespace, "Ingress.Name", i.Name)
-		err = r.Create(context.TODO(), i)
+		log.Info("Creating Ingress")
+		err = r.Create(ctx, i)
 
 		if err != nil {
 			// Creation failed
-			log.Error(err, "Failed to create new Ingress", "Ingress.Namespace", i.Namespace, "Ingress.Name", i.Name)
+			log.Error(err, "Failed to create Ingress")
 			return &reconcile.Result{}, err
 		}
+		r.Metrics.IncrementWritesMetric(instance.Namespace, instance.Name, "Ingress", metrics.WriteApplied)
 		// Creation was successful
 		return nil, nil
 	} else if err != nil {
@@ -61,16 +96,92 @@ func (r *GslbReconciler) ensureIngress(instance *k8gbv1beta1.Gslb, i *v1beta1.In
 		return &reconcile.Result{}, err
 	}
 
-	// Update existing object with new spec and annotations
+	// Three-way merge of live metadata with desired one; previously applied keys are the strategy annotations
+	// and the keys recorded by the last propagation
+	annotations := mergeMetadata(found.Annotations, i.Annotations, append(splitKeys(found.Annotations[propagatedAnnotationsAnnotation]),
+		strategyAnnotation, primaryGeoTagAnnotation, propagatedAnnotationsAnnotation, propagatedLabelsAnnotation,
+		ingressSpecHashAnnotation))
+	labels := mergeMetadata(found.Labels, i.Labels, splitKeys(found.Annotations[propagatedLabelsAnnotation]))
+
+	// Skip update when neither spec nor managed metadata changed; spec changes are detected by the spec hash annotation
+	if equality.Semantic.DeepEqual(found.Annotations, annotations) && equality.Semantic.DeepEqual(found.Labels, labels) {
+		r.Metrics.IncrementWritesMetric(instance.Namespace, instance.Name, "Ingress", metrics.WriteSkipped)
+		return nil, nil
+	}
+
+	// Update existing object with new spec and merged metadata
 	found.Spec = i.Spec
-	found.Annotations = i.Annotations
-	err = r.Update(context.TODO(), found)
+	found.Annotations = annotations
+	found.Labels = labels
+	log.V(logging.DebugLevel).Info("Updating Ingress")
+	err = r.Update(ctx, found)
 
 	if err != nil {
 		// Update failed
-		log.Error(err, "Failed to update Ingress", "Ingress.Namespace", found.Namespace, "Ingress.Name", found.Name)
+		log.Error(err, "Failed to update Ingress")
 		return &reconcile.Result{}, err
 	}
+	r.Metrics.IncrementWritesMetric(instance.Namespace, instance.Name, "Ingress", metrics.WriteApplied)
 
 	return nil, nil
 }
+
+// ingressSpecHash returns hash of Ingress spec as written by Gslb
+func ingressSpecHash(spec v1beta1.IngressSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// propagatedMetadata returns annotations or labels matching the allowlist. Allowlist entry ending with *
+// matches keys by prefix
+func propagatedMetadata(metadata map[string]string, allowlist []string) map[string]string {
+	propagated := make(map[string]string)
+	for key, value := range metadata {
+		for _, allowed := range allowlist {
+			if key == allowed || (strings.HasSuffix(allowed, "*") && strings.HasPrefix(key, strings.TrimSuffix(allowed, "*"))) {
+				propagated[key] = value
+				break
+			}
+		}
+	}
+	return propagated
+}
+
+// mergeMetadata returns live annotations or labels with desired ones applied. Previously applied keys which
+// are not desired anymore are removed, keys not managed by k8gb are left untouched
+func mergeMetadata(live, desired map[string]string, applied []string) map[string]string {
+	merged := make(map[string]string, len(live)+len(desired))
+	for key, value := range live {
+		merged[key] = value
+	}
+	for _, key := range applied {
+		delete(merged, key)
+	}
+	for key, value := range desired {
+		merged[key] = value
+	}
+	if len(merged) == 0 && live == nil {
+		return nil
+	}
+	return merged
+}
+
+func joinKeys(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func splitKeys(keys string) []string {
+	if keys == "" {
+		return nil
+	}
+	return strings.Split(keys, ",")
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+)
+
+// +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reasons of Events recorded on annotated Ingress
+const (
+	reasonGslbCreated       = "GslbCreated"
+	reasonGslbUpdated       = "GslbUpdated"
+	reasonGslbDeleted       = "GslbDeleted"
+	reasonGslbConflict      = "GslbConflict"
+	reasonInvalidAnnotation = "InvalidAnnotation"
+	reasonSyncFailed        = "SyncFailed"
+)
+
+// IngressReconciler keeps Gslb generated from Ingress annotated by k8gb.io/strategy in sync with the Ingress. The Gslb
+// references the Ingress by spec.ingressRef and its strategy is configured by k8gb.io annotations of the Ingress. The
+// Gslb is controlled by the Ingress; it is deleted once the annotation is removed and garbage collected with the Ingress
+type IngressReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// Reconcile creates, updates or deletes Gslb of the Ingress
+func (r *IngressReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := log.WithValues("ingress", req.Name, logging.NamespaceKey, req.Namespace)
+	ingress := &v1beta1.Ingress{}
+	err := r.Get(ctx, req.NamespacedName, ingress)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Gslb controlled by the Ingress is garbage collected
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if owner := metav1.GetControllerOf(ingress); owner != nil && owner.Kind == "Gslb" {
+		// Ingress is generated from Gslb spec, not the other way around
+		return ctrl.Result{}, nil
+	}
+
+	gslb := &k8gbv1beta1.Gslb{}
+	err = r.Get(ctx, req.NamespacedName, gslb)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	gslbExists := err == nil
+	if gslbExists && !r.generatedFrom(gslb, ingress) {
+		r.Recorder.Event(ingress, corev1.EventTypeWarning, reasonGslbConflict,
+			fmt.Sprintf("Gslb %s is not generated from the Ingress, skipping synchronization", gslb.Name))
+		return ctrl.Result{}, nil
+	}
+
+	if _, annotated := ingress.Annotations[strategyAnnotation]; !annotated {
+		if !gslbExists {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Strategy annotation removed from Ingress, deleting Gslb")
+		err = r.Delete(ctx, gslb)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, reasonSyncFailed, fmt.Sprintf("Deleting Gslb: %s", err))
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(ingress, corev1.EventTypeNormal, reasonGslbDeleted, fmt.Sprintf("Deleted Gslb %s", gslb.Name))
+		return ctrl.Result{}, nil
+	}
+
+	strategy, err := depresolver.ResolveStrategyAnnotations(ingress.Annotations)
+	if err != nil {
+		r.Recorder.Event(ingress, corev1.EventTypeWarning, reasonInvalidAnnotation, err.Error())
+		// annotation has to be fixed by user, requeue wouldn't help
+		return ctrl.Result{}, nil
+	}
+
+	if !gslbExists {
+		gslb = &k8gbv1beta1.Gslb{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ingress.Namespace,
+				Name:      ingress.Name,
+			},
+		}
+	}
+	desired := gslb.DeepCopy()
+	desired.Annotations = copyAnnotations(ingress.Annotations)
+	// Gslb only references the Ingress, so it never competes with the user over the Ingress spec
+	desired.Spec.Ingress = v1beta1.IngressSpec{}
+	desired.Spec.IngressRef = &k8gbv1beta1.IngressRef{Name: ingress.Name}
+	desired.Spec.Strategy = strategy
+	err = controllerutil.SetControllerReference(ingress, desired, r.Scheme)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !gslbExists {
+		log.Info("Creating Gslb out of Ingress annotation", logging.GslbKey, desired.Name)
+		err = r.Create(ctx, desired)
+		if err != nil {
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, reasonSyncFailed, fmt.Sprintf("Creating Gslb: %s", err))
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(ingress, corev1.EventTypeNormal, reasonGslbCreated, fmt.Sprintf("Created Gslb %s", desired.Name))
+		return ctrl.Result{}, nil
+	}
+
+	if equality.Semantic.DeepEqual(gslb.ObjectMeta, desired.ObjectMeta) && equality.Semantic.DeepEqual(gslb.Spec, desired.Spec) {
+		return ctrl.Result{}, nil
+	}
+	log.Info("Updating Gslb out of Ingress annotation", logging.GslbKey, desired.Name)
+	err = r.Update(ctx, desired)
+	if err != nil {
+		r.Recorder.Event(ingress, corev1.EventTypeWarning, reasonSyncFailed, fmt.Sprintf("Updating Gslb: %s", err))
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Event(ingress, corev1.EventTypeNormal, reasonGslbUpdated, fmt.Sprintf("Updated Gslb %s", desired.Name))
+	return ctrl.Result{}, nil
+}
+
+// generatedFrom returns true when Gslb is controlled by the Ingress. Gslb without controller carrying the strategy
+// annotation was generated by earlier versions and is adopted
+func (r *IngressReconciler) generatedFrom(gslb *k8gbv1beta1.Gslb, ingress *v1beta1.Ingress) bool {
+	owner := metav1.GetControllerOf(gslb)
+	if owner == nil {
+		_, annotated := gslb.Annotations[strategyAnnotation]
+		return annotated
+	}
+	return owner.UID == ingress.UID
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	c := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		c[k] = v
+	}
+	return c
+}
+
+// SetupWithManager configures controller manager
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.Ingress{}).
+		Owns(&k8gbv1beta1.Gslb{}).
+		Complete(r)
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+)
+
+var annotatedIngressKey = types.NamespacedName{Namespace: "test-gslb", Name: "annotated"}
+
+func TestAnnotatedIngressCreatesControlledGslb(t *testing.T) {
+	// arrange
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: failoverStrategy, primaryGeoTagAnnotation: "eu"})
+	r, recorder := provideIngressReconciler(ingress)
+	// act
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, gslb))
+	assert.Equal(t, &k8gbv1beta1.IngressRef{Name: "annotated"}, gslb.Spec.IngressRef)
+	assert.Empty(t, gslb.Spec.Ingress.Rules)
+	assert.Equal(t, k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "eu", DNSTtlSeconds: 30,
+		SplitBrainThresholdSeconds: 300, HostnameTargets: depresolver.HostnameTargetsResolve}, gslb.Spec.Strategy)
+	assert.Equal(t, ingress.Annotations, gslb.Annotations)
+	assert.True(t, metav1.IsControlledBy(gslb, ingress), "Gslb is not controlled by Ingress")
+	assert.Equal(t, []string{"Normal GslbCreated Created Gslb annotated"}, events(recorder))
+}
+
+func TestAnnotatedIngressChangesAreSyncedToGslb(t *testing.T) {
+	// arrange
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	r, recorder := provideIngressReconciler(ingress)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	require.NoError(t, err)
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, gslb))
+	gslb.Spec.Strategy.DNSTtlSeconds = 60
+	gslb.Finalizers = []string{gslbFinalizer}
+	require.NoError(t, r.Update(context.TODO(), gslb))
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, ingress))
+	ingress.Annotations[strategyAnnotation] = geoStrategy
+	ingress.Annotations[depresolver.SplitBrainThresholdSecondsAnnotation] = "600"
+	ingress.Annotations[depresolver.HostnameTargetsAnnotation] = depresolver.HostnameTargetsCNAME
+	require.NoError(t, r.Update(context.TODO(), ingress))
+	// act
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, gslb))
+	// strategy edited on Gslb is overwritten by annotations
+	assert.Equal(t, k8gbv1beta1.Strategy{Type: geoStrategy, DNSTtlSeconds: 30, SplitBrainThresholdSeconds: 600,
+		HostnameTargets: depresolver.HostnameTargetsCNAME}, gslb.Spec.Strategy)
+	assert.Equal(t, []string{gslbFinalizer}, gslb.Finalizers)
+	assert.Equal(t, []string{"Normal GslbCreated Created Gslb annotated", "Normal GslbUpdated Updated Gslb annotated"}, events(recorder))
+}
+
+func TestUnchangedAnnotatedIngressDoesNotUpdateGslb(t *testing.T) {
+	// arrange
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	r, recorder := provideIngressReconciler(ingress)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	require.NoError(t, err)
+	// act
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Normal GslbCreated Created Gslb annotated"}, events(recorder))
+}
+
+func TestRemovedStrategyAnnotationDeletesGslb(t *testing.T) {
+	// arrange
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	r, recorder := provideIngressReconciler(ingress)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, ingress))
+	ingress.Annotations = nil
+	require.NoError(t, r.Update(context.TODO(), ingress))
+	// act
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	err = r.Get(context.TODO(), annotatedIngressKey, &k8gbv1beta1.Gslb{})
+	assert.True(t, errors.IsNotFound(err), "Gslb was not deleted: %v", err)
+	assert.Equal(t, []string{"Normal GslbCreated Created Gslb annotated", "Normal GslbDeleted Deleted Gslb annotated"}, events(recorder))
+}
+
+func TestInvalidStrategyAnnotationIsReportedAsEvent(t *testing.T) {
+	var tests = []struct {
+		name        string
+		annotations map[string]string
+		event       string
+	}{
+		{"unknown strategy", map[string]string{strategyAnnotation: "random"},
+			`Warning InvalidAnnotation "k8gb.io/strategy" must be one of [roundRobin failover geo latency], got "random"`},
+		{"failover without primary geoTag", map[string]string{strategyAnnotation: failoverStrategy},
+			"Warning InvalidAnnotation k8gb.io/primary-geotag is empty"},
+		{"ttl is not a number", map[string]string{strategyAnnotation: roundRobinStrategy, depresolver.DNSTTLSecondsAnnotation: "30s"},
+			`Warning InvalidAnnotation "k8gb.io/dns-ttl-seconds" must be a number, got "30s"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			r, recorder := provideIngressReconciler(annotatedIngress(test.annotations))
+			// act
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+			// assert
+			require.NoError(t, err)
+			err = r.Get(context.TODO(), annotatedIngressKey, &k8gbv1beta1.Gslb{})
+			assert.True(t, errors.IsNotFound(err), "Gslb was created: %v", err)
+			assert.Equal(t, []string{test.event}, events(recorder))
+		})
+	}
+}
+
+func TestGslbNotGeneratedFromIngressIsLeftUntouched(t *testing.T) {
+	// arrange
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	gslb := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Namespace: annotatedIngressKey.Namespace, Name: annotatedIngressKey.Name},
+		Spec:       k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "za"}},
+	}
+	r, recorder := provideIngressReconciler(ingress, gslb)
+	// act
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	found := &k8gbv1beta1.Gslb{}
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, found))
+	assert.Equal(t, gslb.Spec, found.Spec)
+	assert.Equal(t, []string{"Warning GslbConflict Gslb annotated is not generated from the Ingress, skipping synchronization"},
+		events(recorder))
+}
+
+func TestIngressControlledByGslbIsIgnored(t *testing.T) {
+	// arrange
+	gslb := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Namespace: annotatedIngressKey.Namespace, Name: annotatedIngressKey.Name, UID: "gslb-uid"},
+		Spec:       k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{Type: roundRobinStrategy}},
+	}
+	ingress := annotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	require.NoError(t, controllerutil.SetControllerReference(gslb, ingress, ingressReconcilerScheme()))
+	r, recorder := provideIngressReconciler(ingress, gslb)
+	// act
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: annotatedIngressKey})
+	// assert
+	require.NoError(t, err)
+	found := &k8gbv1beta1.Gslb{}
+	require.NoError(t, r.Get(context.TODO(), annotatedIngressKey, found))
+	assert.Equal(t, gslb.Spec, found.Spec)
+	assert.Empty(t, events(recorder))
+}
+
+func provideIngressReconciler(objs ...runtime.Object) (*IngressReconciler, *record.FakeRecorder) {
+	s := ingressReconcilerScheme()
+	recorder := record.NewFakeRecorder(10)
+	return &IngressReconciler{
+		Client:   fake.NewFakeClientWithScheme(s, objs...),
+		Scheme:   s,
+		Recorder: recorder,
+	}, recorder
+}
+
+func ingressReconcilerScheme() *runtime.Scheme {
+	s := scheme.Scheme
+	s.AddKnownTypes(k8gbv1beta1.GroupVersion, &k8gbv1beta1.Gslb{}, &k8gbv1beta1.GslbList{})
+	return s
+}
+
+func annotatedIngress(annotations map[string]string) *v1beta1.Ingress {
+	return &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   annotatedIngressKey.Namespace,
+			Name:        annotatedIngressKey.Name,
+			UID:         "ingress-uid",
+			Annotations: annotations,
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{Host: "annotated.cloud.example.com"}},
+		},
+	}
+}
+
+// events returns recorded events
+func events(recorder *record.FakeRecorder) (events []string) {
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getIngressRef returns the Ingress referenced by spec.ingressRef. The Ingress is owned by the user,
+// k8gb only reads it
+func (r *GslbReconciler) getIngressRef(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*v1beta1.Ingress, error) {
+	ingress := &v1beta1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Spec.IngressRef.Name}, ingress)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Info("Can't find gslb referenced Ingress", "ingress", gslb.Spec.IngressRef.Name)
+		}
+		return nil, err
+	}
+	return ingress, nil
+}
+
+// getGslbIngressRefTargets returns load balancer IPs and hostnames from referenced Ingress status for every
+// Ingress rule host. Backend Services of the Ingress are indexed, so their changes reconcile the Gslb
+func (r *GslbReconciler) getGslbIngressRefTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	ingress, err := r.getIngressRef(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	var references []reference
+	for _, service := range ingressRefBackendServices(ingress) {
+		references = append(references, reference{GroupKind: serviceGroupKind,
+			NamespacedName: types.NamespacedName{Namespace: gslb.Namespace, Name: service}})
+	}
+	r.references.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, references)
+	ingressTargets := loadBalancerTargets(ingress.Status.LoadBalancer.Ingress)
+	targets := make(map[string][]string)
+	for _, rule := range ingress.Spec.Rules {
+		targets[rule.Host] = ingressTargets
+	}
+	return targets, nil
+}
+
+// getIngressRefHealthStatus returns health of referenced Ingress hosts according to their backend Services
+func (r *GslbReconciler) getIngressRefHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	ingress, err := r.getIngressRef(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	return r.getIngressRulesHealth(ctx, gslb.Namespace, ingress.Spec.Rules)
+}
+
+// ingressRefBackendServices returns names of Services referenced by Ingress rule paths
+func ingressRefBackendServices(ingress *v1beta1.Ingress) (services []string) {
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			services = append(services, path.Backend.ServiceName)
+		}
+	}
+	return services
+}
+
+// ingressRefRequests maps changed Ingress to Gslbs referencing it by spec.ingressRef
+func (r *GslbReconciler) ingressRefRequests(a handler.MapObject) []reconcile.Request {
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.List(context.TODO(), gslbList, client.InNamespace(a.Meta.GetNamespace()))
+	if err != nil {
+		log.Error(err, "Can't fetch gslb objects", "ingress", a.Meta.GetName(), logging.NamespaceKey, a.Meta.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbList.Items {
+		if gslb.Spec.IngressRef != nil && gslb.Spec.IngressRef.Name == a.Meta.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      gslb.Name,
+				Namespace: gslb.Namespace,
+			}})
+		}
+	}
+	return requests
+}
@@ -1,19 +1,24 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"sort"
 
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
 	"github.com/lixiangzhong/dnsutil"
 )
 
-// Dig retrieves list of tuple <IP address, A record > from edge DNS server for specific FQDN
-func Dig(edgeDNSServer, fqdn string) ([]string, error) {
+// Dig retrieves list of IPv4 and IPv6 addresses from A and AAAA records of edge DNS server for specific FQDN
+func Dig(ctx context.Context, edgeDNSServer, fqdn string) (_ []string, err error) {
+	_, span := tracing.Start(ctx, "dns.Dig", tracing.HostKey.String(fqdn))
+	defer func() { tracing.End(span, err) }()
 	var dig dnsutil.Dig
 	if edgeDNSServer == "" {
 		return nil, fmt.Errorf("empty edgeDNSServer")
 	}
-	err := dig.SetDNS(edgeDNSServer)
+	err = dig.SetDNS(edgeDNSServer)
 	if err != nil {
 		err = fmt.Errorf("dig error: can't set query dns (%s) with error(%s)", edgeDNSServer, err)
 		return nil, err
@@ -23,10 +28,35 @@ func Dig(edgeDNSServer, fqdn string) ([]string, error) {
 		err = fmt.Errorf("dig error: can't dig fqdn(%s) with error(%s)", fqdn, err)
 		return nil, err
 	}
+	aaaa, err := dig.AAAA(fqdn)
+	if err != nil {
+		err = fmt.Errorf("dig error: can't dig AAAA fqdn(%s) with error(%s)", fqdn, err)
+		return nil, err
+	}
 	var IPs []string
 	for _, ip := range a {
 		IPs = append(IPs, fmt.Sprint(ip.A))
 	}
+	for _, ip := range aaaa {
+		IPs = append(IPs, fmt.Sprint(ip.AAAA))
+	}
 	sort.Strings(IPs)
 	return IPs, nil
 }
+
+// SplitByAddressFamily splits IPs into IPv4 and IPv6 addresses and keeps their order. IPv4-mapped IPv6
+// addresses are returned in IPv4 notation. Values which are not valid IP addresses are dropped
+func SplitByAddressFamily(IPs []string) (ipv4 []string, ipv6 []string) {
+	for _, ip := range IPs {
+		parsed := net.ParseIP(ip)
+		switch {
+		case parsed == nil:
+			continue
+		case parsed.To4() != nil:
+			ipv4 = append(ipv4, parsed.To4().String())
+		default:
+			ipv6 = append(ipv6, ip)
+		}
+	}
+	return
+}
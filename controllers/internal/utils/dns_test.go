@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -15,7 +16,7 @@ func TestValidDig(t *testing.T) {
 	edgeDNSServer := "8.8.8.8"
 	fqdn := "google.com"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(context.TODO(), edgeDNSServer, fqdn)
 	// assert
 	assert.NoError(t, err)
 	assert.NotEmpty(t, result)
@@ -30,7 +31,7 @@ func TestEmptyFQDNButValidEdgeDNS(t *testing.T) {
 	edgeDNSServer := "8.8.8.8"
 	fqdn := ""
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(context.TODO(), edgeDNSServer, fqdn)
 	// assert
 	assert.NoError(t, err)
 	assert.Nil(t, result)
@@ -41,7 +42,7 @@ func TestEmptyEdgeDNS(t *testing.T) {
 	edgeDNSServer := ""
 	fqdn := "whatever"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(context.TODO(), edgeDNSServer, fqdn)
 	// assert
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -52,7 +53,7 @@ func TestValidEdgeDNSButNonExistingFQDN(t *testing.T) {
 	edgeDNSServer := "localhost"
 	fqdn := "some-valid-ip-fqdn-123"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(context.TODO(), edgeDNSServer, fqdn)
 	// assert
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -65,3 +66,22 @@ func connected() (ok bool) {
 	}
 	return res.Body.Close() == nil
 }
+
+func TestSplitByAddressFamily(t *testing.T) {
+	// arrange
+	IPs := []string{"10.0.0.1", "2001:db8::1", "not-an-ip", "10.0.0.2", "::ffff:10.0.0.3", "fd00::2"}
+	// act
+	ipv4, ipv6 := SplitByAddressFamily(IPs)
+	// assert
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, ipv4)
+	assert.Equal(t, []string{"2001:db8::1", "fd00::2"}, ipv6)
+}
+
+func TestSplitByAddressFamilyWithEmptyInput(t *testing.T) {
+	// arrange
+	// act
+	ipv4, ipv6 := SplitByAddressFamily(nil)
+	// assert
+	assert.Nil(t, ipv4)
+	assert.Nil(t, ipv6)
+}
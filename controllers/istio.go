@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Istio types are read as unstructured objects, so k8gb doesn't depend on Istio CRDs
+const (
+	istioGroup              = "networking.istio.io"
+	istioGroupVersion       = istioGroup + "/v1beta1"
+	istioGatewayKind        = "Gateway"
+	istioVirtualServiceKind = "VirtualService"
+	// istioMeshGateway is reserved gateway name for sidecars, it has no ingress Service
+	istioMeshGateway = "mesh"
+)
+
+var (
+	istioGatewayGroupKind        = schema.GroupKind{Group: istioGroup, Kind: istioGatewayKind}
+	istioVirtualServiceGroupKind = schema.GroupKind{Group: istioGroup, Kind: istioVirtualServiceKind}
+)
+
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices;gateways,verbs=get;list;watch
+
+func (r *GslbReconciler) getVirtualService(ctx context.Context, gslb *k8gbv1beta1.Gslb) (*unstructured.Unstructured, error) {
+	vs := &unstructured.Unstructured{}
+	vs.SetAPIVersion(istioGroupVersion)
+	vs.SetKind(istioVirtualServiceKind)
+	err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Spec.VirtualService.Name}, vs)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Info("Can't find gslb VirtualService", "virtualService", gslb.Spec.VirtualService.Name)
+		}
+		return nil, err
+	}
+	return vs, nil
+}
+
+// getGslbVirtualServiceTargets returns load balancer IPs and hostnames of Istio ingress gateway Services
+// for every VirtualService host. Istio Gateways, ingress gateway Services and route destination Services
+// are indexed, so their changes reconcile the Gslb
+func (r *GslbReconciler) getGslbVirtualServiceTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	vs, err := r.getVirtualService(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	services, err := r.getIstioGatewayServices(ctx, gslb, vs)
+	if err != nil {
+		return nil, err
+	}
+	var references []reference
+	for _, service := range virtualServiceDestinations(vs) {
+		references = append(references, reference{GroupKind: serviceGroupKind, NamespacedName: service})
+	}
+	if gslb.Spec.VirtualService.GatewayService != "" {
+		references = append(references, reference{GroupKind: serviceGroupKind,
+			NamespacedName: namespacedNameOf(gslb.Spec.VirtualService.GatewayService, gslb.Namespace)})
+	}
+	for _, gateway := range virtualServiceGateways(vs) {
+		references = append(references, reference{GroupKind: istioGatewayGroupKind, NamespacedName: gateway})
+	}
+	var serviceTargets []string
+	for _, service := range services {
+		references = append(references, reference{GroupKind: serviceGroupKind,
+			NamespacedName: types.NamespacedName{Namespace: service.Namespace, Name: service.Name}})
+		serviceTargets = append(serviceTargets, loadBalancerTargets(service.Status.LoadBalancer.Ingress)...)
+	}
+	r.references.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, references)
+	targets := make(map[string][]string)
+	for _, host := range virtualServiceHosts(vs) {
+		targets[host] = serviceTargets
+	}
+	return targets, nil
+}
+
+// getVirtualServiceHealthStatus returns health of VirtualService hosts. Host is Healthy when at least one
+// of the route destination Services is Healthy
+func (r *GslbReconciler) getVirtualServiceHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	vs, err := r.getVirtualService(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	health, err := r.getServicesHealth(ctx, virtualServiceDestinations(vs))
+	if err != nil {
+		return nil, err
+	}
+	serviceHealth := make(map[string]string)
+	for _, host := range virtualServiceHosts(vs) {
+		serviceHealth[host] = health
+	}
+	return serviceHealth, nil
+}
+
+// getIstioGatewayServices returns ingress gateway Services type LoadBalancer. Explicit gatewayService
+// from Gslb spec takes precedence over discovery through Istio Gateway selectors
+func (r *GslbReconciler) getIstioGatewayServices(ctx context.Context, gslb *k8gbv1beta1.Gslb, vs *unstructured.Unstructured) ([]corev1.Service, error) {
+	if gslb.Spec.VirtualService.GatewayService != "" {
+		service := &corev1.Service{}
+		err := r.Get(ctx, namespacedNameOf(gslb.Spec.VirtualService.GatewayService, gslb.Namespace), service)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				logging.FromContext(ctx).Info("Can't find Istio ingress gateway Service", "service", gslb.Spec.VirtualService.GatewayService)
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []corev1.Service{*service}, nil
+	}
+
+	var services []corev1.Service
+	for _, g := range virtualServiceGateways(vs) {
+		gateway := &unstructured.Unstructured{}
+		gateway.SetAPIVersion(istioGroupVersion)
+		gateway.SetKind(istioGatewayKind)
+		err := r.Get(ctx, g, gateway)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				logging.FromContext(ctx).Info("Can't find Istio Gateway of VirtualService", "gateway", g.String(), "virtualService", vs.GetName())
+				continue
+			}
+			return nil, err
+		}
+		selector, _, _ := unstructured.NestedStringMap(gateway.Object, "spec", "selector")
+		if len(selector) == 0 {
+			continue
+		}
+		serviceList := &corev1.ServiceList{}
+		err = r.List(ctx, serviceList, client.InNamespace(gateway.GetNamespace()))
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range serviceList.Items {
+			if service.Spec.Type == corev1.ServiceTypeLoadBalancer && selectorMatches(selector, service.Spec.Selector) {
+				services = append(services, service)
+			}
+		}
+	}
+	return services, nil
+}
+
+// virtualServiceGateways returns Istio Gateways of VirtualService except the reserved mesh gateway
+func virtualServiceGateways(vs *unstructured.Unstructured) (gateways []types.NamespacedName) {
+	all, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "gateways")
+	for _, g := range all {
+		if g == istioMeshGateway {
+			continue
+		}
+		gateways = append(gateways, namespacedNameOf(g, vs.GetNamespace()))
+	}
+	return gateways
+}
+
+// virtualServiceRequests maps changed VirtualService to Gslbs referencing it by spec.virtualService
+func (r *GslbReconciler) virtualServiceRequests(a handler.MapObject) []reconcile.Request {
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.List(context.TODO(), gslbList, client.InNamespace(a.Meta.GetNamespace()))
+	if err != nil {
+		log.Error(err, "Can't fetch gslb objects", "virtualService", a.Meta.GetName(), logging.NamespaceKey, a.Meta.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbList.Items {
+		if gslb.Spec.VirtualService != nil && gslb.Spec.VirtualService.Name == a.Meta.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      gslb.Name,
+				Namespace: gslb.Namespace,
+			}})
+		}
+	}
+	return requests
+}
+
+// virtualServiceHosts returns VirtualService hosts which can be served by Gslb. Wildcards and mesh
+// internal short names are skipped
+func virtualServiceHosts(vs *unstructured.Unstructured) (hosts []string) {
+	all, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "hosts")
+	for _, host := range all {
+		if strings.HasPrefix(host, "*") || !strings.Contains(host, ".") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// virtualServiceDestinations returns Services used as route destinations of http, tls and tcp routes
+func virtualServiceDestinations(vs *unstructured.Unstructured) (services []types.NamespacedName) {
+	for _, protocol := range []string{"http", "tls", "tcp"} {
+		routes, _, _ := unstructured.NestedSlice(vs.Object, "spec", protocol)
+		for _, r := range routes {
+			route, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for _, d := range destinations {
+				destination, ok := d.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(destination, "destination", "host")
+				if host == "" {
+					continue
+				}
+				services = append(services, destinationService(host, vs.GetNamespace()))
+			}
+		}
+	}
+	return services
+}
+
+// destinationService converts Istio destination host; e.g. reviews, reviews.bookinfo or
+// reviews.bookinfo.svc.cluster.local into Service namespaced name
+func destinationService(host, defaultNamespace string) types.NamespacedName {
+	parts := strings.Split(host, ".")
+	nn := types.NamespacedName{Name: parts[0], Namespace: defaultNamespace}
+	if len(parts) > 1 {
+		nn.Namespace = parts[1]
+	}
+	return nn
+}
+
+// namespacedNameOf parses namespace/name reference, namespace is optional
+func namespacedNameOf(ref, defaultNamespace string) types.NamespacedName {
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return types.NamespacedName{Namespace: ref[:i], Name: ref[i+1:]}
+	}
+	return types.NamespacedName{Namespace: defaultNamespace, Name: ref}
+}
+
+// selectorMatches returns true if all gateway selector labels are part of Service selector
+func selectorMatches(gatewaySelector, serviceSelector map[string]string) bool {
+	for k, v := range gatewaySelector {
+		if serviceSelector[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/gslbdns"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	"github.com/miekg/dns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// latencyProbeTimeout limits TCP connect to latency probe target and DNS query to name server of the cluster
+	latencyProbeTimeout = 2 * time.Second
+	// latencyProbeInterval is period of measuring round-trip time to clusters in other locations
+	latencyProbeInterval = 10 * time.Second
+	// latencyPublishInterval is period of publishing measurements unless set of measured clusters changes; round-trip
+	// time differs on every probe, every write triggers external-dns synchronization
+	latencyPublishInterval = time.Minute
+	// latencyDNSEndpointName is name of DNSEndpoint in k8gb namespace publishing measurements of the cluster
+	latencyDNSEndpointName = "k8gb-latency"
+	// latencyTTLSeconds is TTL of latency TXT record
+	latencyTTLSeconds = 30
+)
+
+// latencyTracker holds the latest round-trip time to k8gb clusters in other locations by their geoTags.
+// Clusters are measured periodically by latency probe, reconciliation only reads the measurements
+type latencyTracker struct {
+	sync.Mutex
+	rtt map[string]time.Duration
+	// published are geoTags of measurements published at publishedAt
+	published   []string
+	publishedAt time.Time
+}
+
+// update stores round-trip time to cluster
+func (t *latencyTracker) update(geoTag string, rtt time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	if t.rtt == nil {
+		t.rtt = make(map[string]time.Duration)
+	}
+	t.rtt[geoTag] = rtt
+}
+
+// remove forgets measurement of unreachable cluster
+func (t *latencyTracker) remove(geoTag string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.rtt, geoTag)
+}
+
+// measurements returns copy of round-trip times by geoTags
+func (t *latencyTracker) measurements() map[string]time.Duration {
+	t.Lock()
+	defer t.Unlock()
+	measurements := make(map[string]time.Duration, len(t.rtt))
+	for geoTag, rtt := range t.rtt {
+		measurements[geoTag] = rtt
+	}
+	return measurements
+}
+
+// shouldPublish tells whether measurements should be published, because set of measured clusters changed or
+// latencyPublishInterval elapsed since they were published. Measurements are considered published
+func (t *latencyTracker) shouldPublish(now time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+	geoTags := make([]string, 0, len(t.rtt))
+	for geoTag := range t.rtt {
+		geoTags = append(geoTags, geoTag)
+	}
+	sort.Strings(geoTags)
+	if t.published != nil && strings.Join(geoTags, ",") == strings.Join(t.published, ",") &&
+		now.Sub(t.publishedAt) < latencyPublishInterval {
+		return false
+	}
+	t.published = geoTags
+	t.publishedAt = now
+	return true
+}
+
+// forgetPublished makes measurements published again by the next probe
+func (t *latencyTracker) forgetPublished() {
+	t.Lock()
+	defer t.Unlock()
+	t.published = nil
+}
+
+// probeTCPLatency returns TCP connect time to address in host:port format
+func probeTCPLatency(address string) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, latencyProbeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	_ = conn.Close()
+	return rtt, nil
+}
+
+// formatLatency rounds round-trip time for status and TXT record; e.g. 12.3ms
+func formatLatency(rtt time.Duration) string {
+	return rtt.Round(100 * time.Microsecond).String()
+}
+
+// latencyStatus returns measurements of clusters in other locations for Gslb status
+func (r *GslbReconciler) latencyStatus() map[string]string {
+	status := make(map[string]string)
+	for geoTag, rtt := range r.latencies.measurements() {
+		status[geoTag] = formatLatency(rtt)
+	}
+	return status
+}
+
+// probeDNSLatency returns round-trip time of SOA query for the Gslb zone to name server of the cluster
+func probeDNSLatency(ctx context.Context, zone, nameServer string) (time.Duration, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	_, rtt, err := (&dns.Client{Timeout: latencyProbeTimeout}).ExchangeContext(ctx, m, nameServer)
+	return rtt, err
+}
+
+// SetupLatencyProbe registers periodic measurement of round-trip time to clusters in other locations within
+// manager. Probes may wait for unreachable clusters, so they run out of reconciliation
+func (r *GslbReconciler) SetupLatencyProbe(mgr ctrl.Manager) error {
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-stop
+			cancel()
+		}()
+		ticker := time.NewTicker(latencyProbeInterval)
+		defer ticker.Stop()
+		for {
+			r.probeLatencies(ctx)
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}))
+}
+
+// latencyEndpoints returns TXT record latency-<geoTag> of the cluster in the Gslb zone, with geoTag=rtt value per
+// measured cluster. No record is returned when there are no measurements
+func (r *GslbReconciler) latencyEndpoints() []*externaldns.Endpoint {
+	var values []string
+	for geoTag, rtt := range r.latencies.measurements() {
+		values = append(values, fmt.Sprintf("%s=%s", geoTag, formatLatency(rtt)))
+	}
+	if len(values) == 0 {
+		return []*externaldns.Endpoint{}
+	}
+	sort.Strings(values)
+	return []*externaldns.Endpoint{{
+		DNSName:    gslbdns.LatencyTXTName(r.Config.ClusterGeoTag, r.Config),
+		RecordTTL:  latencyTTLSeconds,
+		RecordType: "TXT",
+		Targets:    externaldns.Targets{strings.Join(values, " ")},
+	}}
+}
+
+// publishLatencies publishes measurements of the cluster by its name server, so clusters in other locations and
+// operators can see them. The record is served by CoreDNS through local external-dns, or by embedded DNS server
+func (r *GslbReconciler) publishLatencies(ctx context.Context) error {
+	endpoints := r.latencyEndpoints()
+	dnsEndpoint := &externaldns.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        latencyDNSEndpointName,
+			Namespace:   r.Config.K8gbNamespace,
+			Annotations: map[string]string{"k8gb.absa.oss/dnstype": "local"},
+		},
+		Spec: externaldns.DNSEndpointSpec{
+			Endpoints: endpoints,
+		},
+	}
+	_, err := r.ensureDNSEndpoint(ctx, r.Config.K8gbNamespace, dnsEndpoint)
+	return err
+}
+
+// probeLatencies measures TCP connect time to probe target of every cluster in other location when configured,
+// round-trip time of DNS query to its name server otherwise. Clusters which can't be reached are not measured
+func (r *GslbReconciler) probeLatencies(ctx context.Context) {
+	for i, nameServer := range r.nsServerNameExt() {
+		geoTag := r.Config.ExtClustersGeoTags[i]
+		var rtt time.Duration
+		var err error
+		address, found := r.Config.LatencyProbeTargets[geoTag]
+		if found {
+			rtt, err = probeTCPLatency(address)
+		} else {
+			address = overrideWithFakeDNS(r.Config.Override, nameServer, geoTag)
+			rtt, err = probeDNSLatency(ctx, r.Config.DNSZone, address)
+		}
+		if err != nil {
+			log.Info("Can't probe latency of external cluster", logging.PeerKey, geoTag, "address", address, "error", err.Error())
+			r.latencies.remove(geoTag)
+			continue
+		}
+		r.latencies.update(geoTag, rtt)
+	}
+	if !r.latencies.shouldPublish(time.Now()) {
+		return
+	}
+	if err := r.publishLatencies(ctx); err != nil {
+		log.Error(err, "Can't publish latency measurements")
+		r.latencies.forgetPublished()
+	}
+}
@@ -0,0 +1,57 @@
+// Package logging creates operator logger and carries logger scoped to reconciled Gslb, host and peer cluster
+// in context, so every log line of reconciliation can be filtered by them
+package logging
+
+import (
+	"context"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	crzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// DebugLevel is verbosity of details logged per DNS record, peer answer and heartbeat; log.V(DebugLevel).Info()
+// is written only when level is debug
+const DebugLevel = 1
+
+// Keys of values logged by scoped loggers
+const (
+	GslbKey      = "gslb"
+	NamespaceKey = "namespace"
+	HostKey      = "host"
+	PeerKey      = "peer"
+)
+
+var levels = map[string]zapcore.Level{
+	"error": zapcore.ErrorLevel,
+	"info":  zapcore.InfoLevel,
+	"debug": zapcore.DebugLevel,
+}
+
+type contextKey struct{}
+
+// New returns logger writing to stderr at configured level, unknown level falls back to info. JSON format is
+// meant for production, console for humans
+func New(config depresolver.Log) logr.Logger {
+	opts := []crzap.Opts{crzap.Level(zap.NewAtomicLevelAt(levels[config.Level]))}
+	if config.Format == "console" {
+		opts = append(opts, crzap.Encoder(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())))
+	}
+	return crzap.New(opts...)
+}
+
+// IntoContext returns ctx carrying log
+func IntoContext(ctx context.Context, log logr.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns logger carried by ctx, or the root logger when ctx carries none
+func FromContext(ctx context.Context) logr.Logger {
+	if log, ok := ctx.Value(contextKey{}).(logr.Logger); ok {
+		return log
+	}
+	return logf.Log
+}
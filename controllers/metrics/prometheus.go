@@ -2,11 +2,15 @@ package metrics
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
 	crm "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
@@ -15,12 +19,39 @@ const (
 	HealthyStatus   = "Healthy"
 	UnhealthyStatus = "Unhealthy"
 	NotFoundStatus  = "NotFound"
+	// WriteApplied result of create or update of managed object
+	WriteApplied = "applied"
+	// WriteSkipped result of update skipped as managed object didn't change
+	WriteSkipped = "skipped"
+)
+
+// Reconciliation phases of Gslb
+const (
+	PhaseIngress        = "ingress"
+	PhaseDNSEndpoint    = "dns_endpoint"
+	PhaseZoneDelegation = "zone_delegation"
+	PhaseStatus         = "status"
 )
 
 type PrometheusMetrics struct {
 	healthyRecordsMetric        *prometheus.GaugeVec
 	ingressHostsPerStatusMetric *prometheus.GaugeVec
-	once                        sync.Once
+	peerLatencyMetric           *prometheus.GaugeVec
+	writesMetric                *prometheus.CounterVec
+	reconcileDurationMetric     *prometheus.HistogramVec
+	reconcileErrorsMetric       *prometheus.CounterVec
+	failoverMetric              *prometheus.CounterVec
+	activeGeoTagMetric          *prometheus.GaugeVec
+	peerHeartbeatAgeMetric      *prometheus.GaugeVec
+	peerDNSQueryDurationMetric  *prometheus.HistogramVec
+	peerUpMetric                *prometheus.GaugeVec
+	splitBrainExpiredMetric     *prometheus.CounterVec
+	// series labeled by Gslb and by peer, so series of deleted Gslbs and removed peers can be deleted
+	gslbs gslbSeries
+	peers peerSeries
+	// geoTags of all k8gb clusters, active geoTag metric is set for each of them
+	geoTags []string
+	once    sync.Once
 }
 
 // NewPrometheusMetrics creates new prometheus metrics instance
@@ -44,6 +75,99 @@ func NewPrometheusMetrics(config depresolver.Config) (metrics *PrometheusMetrics
 		},
 		[]string{"namespace", "name", "status"},
 	)
+	metrics.peerLatencyMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_latency_seconds",
+			Help:      "Round-trip time to K8GB clusters in other locations measured by latency strategy.",
+		},
+		[]string{"peer"},
+	)
+	metrics.writesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "writes_total",
+			Help:      "Number of applied and skipped writes of objects managed by K8GB.",
+		},
+		[]string{"namespace", "name", "kind", "result"},
+	)
+	metrics.reconcileDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of Gslb reconciliation phases.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"phase"},
+	)
+	metrics.reconcileErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "reconcile_errors_total",
+			Help:      "Number of failed Gslb reconciliation phases.",
+		},
+		[]string{"namespace", "name", "phase"},
+	)
+	metrics.failoverMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "failover_total",
+			Help:      "Number of Gslb host failovers between K8GB clusters.",
+		},
+		[]string{"namespace", "name", "from", "to"},
+	)
+	metrics.activeGeoTagMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "active_geotag",
+			Help:      "Number of Gslb hosts served by K8GB cluster of the geoTag.",
+		},
+		[]string{"namespace", "name", "geotag"},
+	)
+	metrics.peerHeartbeatAgeMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_heartbeat_age_seconds",
+			Help:      "Age of split brain TXT record of K8GB clusters in other locations.",
+		},
+		[]string{"namespace", "name", "peer"},
+	)
+	metrics.peerDNSQueryDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_dns_query_duration_seconds",
+			Help:      "Duration of DNS queries to K8GB clusters in other locations.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"peer"},
+	)
+	metrics.peerUpMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_up",
+			Help:      "Whether K8GB cluster in other location answered the last DNS query (1) or not (0).",
+		},
+		[]string{"peer"},
+	)
+	metrics.splitBrainExpiredMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "split_brain_expired_total",
+			Help:      "Number of split brain TXT records of K8GB clusters in other locations found older than threshold.",
+		},
+		[]string{"namespace", "name", "peer"},
+	)
+	metrics.geoTags = append([]string{config.ClusterGeoTag}, config.ExtClustersGeoTags...)
 	return
 }
 
@@ -59,12 +183,12 @@ func (m *PrometheusMetrics) UpdateIngressHostsPerStatusMetric(gslb *k8gbv1beta1.
 			notFoundHostsCount++
 		}
 	}
-	m.ingressHostsPerStatusMetric.With(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "status": HealthyStatus}).
-		Set(float64(healthyHostsCount))
-	m.ingressHostsPerStatusMetric.With(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "status": UnhealthyStatus}).
-		Set(float64(unhealthyHostsCount))
-	m.ingressHostsPerStatusMetric.With(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "status": NotFoundStatus}).
-		Set(float64(notFoundHostsCount))
+	for status, count := range map[string]int{HealthyStatus: healthyHostsCount, UnhealthyStatus: unhealthyHostsCount,
+		NotFoundStatus: notFoundHostsCount} {
+		labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "status": status}
+		m.gslbs.add(m.ingressHostsPerStatusMetric, labels)
+		m.ingressHostsPerStatusMetric.With(labels).Set(float64(count))
+	}
 	return nil
 }
 
@@ -73,10 +197,120 @@ func (m *PrometheusMetrics) UpdateHealthyRecordsMetric(gslb *k8gbv1beta1.Gslb, h
 	for _, hrs := range healthyRecords {
 		hrsCount += len(hrs)
 	}
-	m.healthyRecordsMetric.With(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name}).Set(float64(hrsCount))
+	labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name}
+	m.gslbs.add(m.healthyRecordsMetric, labels)
+	m.healthyRecordsMetric.With(labels).Set(float64(hrsCount))
 	return nil
 }
 
+// UpdatePeerLatencyMetric sets round-trip time of measured clusters. Clusters which are not measured anymore
+// are removed
+func (m *PrometheusMetrics) UpdatePeerLatencyMetric(latencies map[string]time.Duration) {
+	m.peerLatencyMetric.Reset()
+	for geoTag, rtt := range latencies {
+		m.peerLatencyMetric.With(prometheus.Labels{"peer": geoTag}).Set(rtt.Seconds())
+	}
+}
+
+// IncrementWritesMetric counts write of kind object owned by Gslb, result is WriteApplied or WriteSkipped
+func (m *PrometheusMetrics) IncrementWritesMetric(namespace, name, kind, result string) {
+	m.writesMetric.With(prometheus.Labels{"namespace": namespace, "name": name, "kind": kind, "result": result}).Inc()
+}
+
+// ObserveReconcileDuration records duration of reconciliation phase
+func (m *PrometheusMetrics) ObserveReconcileDuration(phase string, duration time.Duration) {
+	m.reconcileDurationMetric.With(prometheus.Labels{"phase": phase}).Observe(duration.Seconds())
+}
+
+// IncrementReconcileErrorsMetric counts failed reconciliation phase of Gslb
+func (m *PrometheusMetrics) IncrementReconcileErrorsMetric(gslb *k8gbv1beta1.Gslb, phase string) {
+	labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "phase": phase}
+	m.gslbs.add(m.reconcileErrorsMetric, labels)
+	m.reconcileErrorsMetric.With(labels).Inc()
+}
+
+// IncrementFailoverMetric counts Gslb host moved from clusters of some geoTags to others. Labels list geoTags
+// sorted and separated by comma when the host is served by several clusters
+func (m *PrometheusMetrics) IncrementFailoverMetric(gslb *k8gbv1beta1.Gslb, from, to []string) {
+	labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "from": joinSorted(from), "to": joinSorted(to)}
+	m.gslbs.add(m.failoverMetric, labels)
+	m.failoverMetric.With(labels).Inc()
+}
+
+// UpdateActiveGeoTagMetric sets number of Gslb hosts served by every cluster. Clusters serving no host are set to 0
+func (m *PrometheusMetrics) UpdateActiveGeoTagMetric(gslb *k8gbv1beta1.Gslb, servingGeoTags map[string][]string) {
+	hosts := make(map[string]int, len(m.geoTags))
+	for _, geoTags := range servingGeoTags {
+		for _, geoTag := range geoTags {
+			hosts[geoTag]++
+		}
+	}
+	for _, geoTag := range m.geoTags {
+		labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "geotag": geoTag}
+		m.gslbs.add(m.activeGeoTagMetric, labels)
+		m.activeGeoTagMetric.With(labels).Set(float64(hosts[geoTag]))
+	}
+}
+
+// UpdatePeerHeartbeatAgeMetric sets age of split brain TXT record of the peer cluster
+func (m *PrometheusMetrics) UpdatePeerHeartbeatAgeMetric(gslb *k8gbv1beta1.Gslb, peer string, age time.Duration) {
+	labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "peer": peer}
+	m.gslbs.add(m.peerHeartbeatAgeMetric, labels)
+	m.peerHeartbeatAgeMetric.With(labels).Set(age.Seconds())
+}
+
+// DeletePeerHeartbeatAgeMetric removes age of split brain TXT record which can't be found
+func (m *PrometheusMetrics) DeletePeerHeartbeatAgeMetric(gslb *k8gbv1beta1.Gslb, peer string) {
+	m.peerHeartbeatAgeMetric.Delete(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "peer": peer})
+}
+
+// IncrementSplitBrainExpiredMetric counts split brain TXT record of the peer cluster found older than threshold
+func (m *PrometheusMetrics) IncrementSplitBrainExpiredMetric(gslb *k8gbv1beta1.Gslb, peer string) {
+	labels := prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "peer": peer}
+	m.gslbs.add(m.splitBrainExpiredMetric, labels)
+	m.splitBrainExpiredMetric.With(labels).Inc()
+}
+
+// ObservePeerDNSQueryDuration records duration of DNS query to the peer cluster
+func (m *PrometheusMetrics) ObservePeerDNSQueryDuration(peer string, duration time.Duration) {
+	m.peers.add(peer)
+	m.peerDNSQueryDurationMetric.With(prometheus.Labels{"peer": peer}).Observe(duration.Seconds())
+}
+
+// UpdatePeerUpMetric sets whether the peer cluster answered DNS query
+func (m *PrometheusMetrics) UpdatePeerUpMetric(peer string, up bool) {
+	m.peers.add(peer)
+	value := 0.0
+	if up {
+		value = 1
+	}
+	m.peerUpMetric.With(prometheus.Labels{"peer": peer}).Set(value)
+}
+
+// DeleteGslbMetrics removes all series of deleted Gslb
+func (m *PrometheusMetrics) DeleteGslbMetrics(namespace, name string) {
+	m.gslbs.delete(types.NamespacedName{Namespace: namespace, Name: name})
+	for _, kind := range []string{"Ingress", "DNSEndpoint"} {
+		for _, result := range []string{WriteApplied, WriteSkipped} {
+			m.writesMetric.Delete(prometheus.Labels{"namespace": namespace, "name": name, "kind": kind, "result": result})
+		}
+	}
+}
+
+// Gslbs returns Gslbs having series, series of Gslbs which don't exist anymore are removed by DeleteGslbMetrics
+func (m *PrometheusMetrics) Gslbs() []types.NamespacedName {
+	return m.gslbs.list()
+}
+
+// RetainPeerMetrics removes series of peer clusters which are not configured anymore
+func (m *PrometheusMetrics) RetainPeerMetrics(peers []string) {
+	for _, peer := range m.peers.retain(peers) {
+		m.peerUpMetric.Delete(prometheus.Labels{"peer": peer})
+		m.peerDNSQueryDurationMetric.Delete(prometheus.Labels{"peer": peer})
+	}
+	m.gslbs.retain("peer", peers)
+}
+
 // Register prometheus metrics. Read register documentation, but shortly:
 // You can register metric with given name only once
 func (m *PrometheusMetrics) Register() (err error) {
@@ -87,6 +321,36 @@ func (m *PrometheusMetrics) Register() (err error) {
 		if err = crm.Registry.Register(m.ingressHostsPerStatusMetric); err != nil {
 			return
 		}
+		if err = crm.Registry.Register(m.peerLatencyMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.writesMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.reconcileDurationMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.reconcileErrorsMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.failoverMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.activeGeoTagMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.peerHeartbeatAgeMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.peerDNSQueryDurationMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.peerUpMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.splitBrainExpiredMetric); err != nil {
+			return
+		}
 	})
 	if err != nil {
 		return fmt.Errorf("can't register prometheus metrics: %s", err)
@@ -98,6 +362,16 @@ func (m *PrometheusMetrics) Register() (err error) {
 func (m *PrometheusMetrics) Unregister() {
 	crm.Registry.Unregister(m.healthyRecordsMetric)
 	crm.Registry.Unregister(m.ingressHostsPerStatusMetric)
+	crm.Registry.Unregister(m.peerLatencyMetric)
+	crm.Registry.Unregister(m.writesMetric)
+	crm.Registry.Unregister(m.reconcileDurationMetric)
+	crm.Registry.Unregister(m.reconcileErrorsMetric)
+	crm.Registry.Unregister(m.failoverMetric)
+	crm.Registry.Unregister(m.activeGeoTagMetric)
+	crm.Registry.Unregister(m.peerHeartbeatAgeMetric)
+	crm.Registry.Unregister(m.peerDNSQueryDurationMetric)
+	crm.Registry.Unregister(m.peerUpMetric)
+	crm.Registry.Unregister(m.splitBrainExpiredMetric)
 }
 
 // GetHealthyRecordsMetric retrieves actual copy of healthy record metric
@@ -111,3 +385,60 @@ func (m *PrometheusMetrics) GetHealthyRecordsMetric() prometheus.GaugeVec {
 func (m *PrometheusMetrics) GetIngressHostsPerStatusMetric() prometheus.GaugeVec {
 	return *m.ingressHostsPerStatusMetric
 }
+
+// GetPeerLatencyMetric retrieves actual copy of peer latency metric
+func (m *PrometheusMetrics) GetPeerLatencyMetric() prometheus.GaugeVec {
+	return *m.peerLatencyMetric
+}
+
+// GetWritesMetric retrieves actual copy of writes metric
+func (m *PrometheusMetrics) GetWritesMetric() prometheus.CounterVec {
+	return *m.writesMetric
+}
+
+// GetReconcileDurationMetric retrieves actual copy of reconcile duration metric
+func (m *PrometheusMetrics) GetReconcileDurationMetric() prometheus.HistogramVec {
+	return *m.reconcileDurationMetric
+}
+
+// GetReconcileErrorsMetric retrieves actual copy of reconcile errors metric
+func (m *PrometheusMetrics) GetReconcileErrorsMetric() prometheus.CounterVec {
+	return *m.reconcileErrorsMetric
+}
+
+// GetFailoverMetric retrieves actual copy of failover metric
+func (m *PrometheusMetrics) GetFailoverMetric() prometheus.CounterVec {
+	return *m.failoverMetric
+}
+
+// GetActiveGeoTagMetric retrieves actual copy of active geoTag metric
+func (m *PrometheusMetrics) GetActiveGeoTagMetric() prometheus.GaugeVec {
+	return *m.activeGeoTagMetric
+}
+
+// GetPeerHeartbeatAgeMetric retrieves actual copy of peer heartbeat age metric
+func (m *PrometheusMetrics) GetPeerHeartbeatAgeMetric() prometheus.GaugeVec {
+	return *m.peerHeartbeatAgeMetric
+}
+
+// GetPeerDNSQueryDurationMetric retrieves actual copy of peer DNS query duration metric
+func (m *PrometheusMetrics) GetPeerDNSQueryDurationMetric() prometheus.HistogramVec {
+	return *m.peerDNSQueryDurationMetric
+}
+
+// GetPeerUpMetric retrieves actual copy of peer up metric
+func (m *PrometheusMetrics) GetPeerUpMetric() prometheus.GaugeVec {
+	return *m.peerUpMetric
+}
+
+// GetSplitBrainExpiredMetric retrieves actual copy of split brain expired metric
+func (m *PrometheusMetrics) GetSplitBrainExpiredMetric() prometheus.CounterVec {
+	return *m.splitBrainExpiredMetric
+}
+
+// joinSorted returns geoTags sorted and separated by comma, so label value doesn't depend on their order
+func joinSorted(geoTags []string) string {
+	sorted := append([]string{}, geoTags...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Prometheus client can't delete series by partial labels, so label values of series are remembered and series
+// of deleted Gslbs and removed peers are deleted by their full label values
+
+// deleter is metric vector which can delete its series
+type deleter interface {
+	Delete(prometheus.Labels) bool
+}
+
+type labeledSeries struct {
+	vec    deleter
+	labels prometheus.Labels
+}
+
+// gslbSeries remembers series labeled by Gslb namespace and name
+type gslbSeries struct {
+	sync.Mutex
+	series map[types.NamespacedName]map[string]labeledSeries
+}
+
+// add remembers series of vec having labels
+func (s *gslbSeries) add(vec deleter, labels prometheus.Labels) {
+	s.Lock()
+	defer s.Unlock()
+	if s.series == nil {
+		s.series = make(map[types.NamespacedName]map[string]labeledSeries)
+	}
+	gslb := types.NamespacedName{Namespace: labels["namespace"], Name: labels["name"]}
+	if s.series[gslb] == nil {
+		s.series[gslb] = make(map[string]labeledSeries)
+	}
+	// labels map is printed sorted by keys
+	s.series[gslb][fmt.Sprintf("%p%v", vec, labels)] = labeledSeries{vec: vec, labels: labels}
+}
+
+// delete deletes all series of Gslb
+func (s *gslbSeries) delete(gslb types.NamespacedName) {
+	s.Lock()
+	defer s.Unlock()
+	for _, series := range s.series[gslb] {
+		series.vec.Delete(series.labels)
+	}
+	delete(s.series, gslb)
+}
+
+// retain deletes series of all Gslbs having label with value which is not listed in values
+func (s *gslbSeries) retain(label string, values []string) {
+	s.Lock()
+	defer s.Unlock()
+	retained := make(map[string]bool, len(values))
+	for _, value := range values {
+		retained[value] = true
+	}
+	for _, gslbSeries := range s.series {
+		for id, series := range gslbSeries {
+			if value, found := series.labels[label]; found && !retained[value] {
+				series.vec.Delete(series.labels)
+				delete(gslbSeries, id)
+			}
+		}
+	}
+}
+
+// list returns Gslbs having series
+func (s *gslbSeries) list() []types.NamespacedName {
+	s.Lock()
+	defer s.Unlock()
+	gslbs := make([]types.NamespacedName, 0, len(s.series))
+	for gslb := range s.series {
+		gslbs = append(gslbs, gslb)
+	}
+	return gslbs
+}
+
+// peerSeries remembers peers of series labeled by peer only
+type peerSeries struct {
+	sync.Mutex
+	peers map[string]bool
+}
+
+// add remembers peer
+func (s *peerSeries) add(peer string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.peers == nil {
+		s.peers = make(map[string]bool)
+	}
+	s.peers[peer] = true
+}
+
+// retain forgets peers which are not listed and returns them
+func (s *peerSeries) retain(peers []string) (removed []string) {
+	s.Lock()
+	defer s.Unlock()
+	retained := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		retained[peer] = true
+	}
+	for peer := range s.peers {
+		if !retained[peer] {
+			removed = append(removed, peer)
+			delete(s.peers, peer)
+		}
+	}
+	return removed
+}
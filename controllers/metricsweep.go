@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	types "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// metricsSweepInterval is period of removing metric series of Gslbs which don't exist anymore
+const metricsSweepInterval = 5 * time.Minute
+
+// SetupMetricsSweep registers periodic removal of metric series within manager. Series live in operator memory
+// only and are removed when deleted Gslb is reconciled, the sweep catches Gslbs whose delete event was missed
+func (r *GslbReconciler) SetupMetricsSweep(mgr ctrl.Manager) error {
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(metricsSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+				if err := r.sweepMetrics(); err != nil {
+					log.Error(err, "sweeping metrics of deleted Gslbs")
+				}
+			}
+		}
+	}))
+}
+
+// sweepMetrics deletes metric series of Gslbs which don't exist anymore
+func (r *GslbReconciler) sweepMetrics() error {
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.List(context.TODO(), gslbList)
+	if err != nil {
+		return err
+	}
+	existing := make(map[types.NamespacedName]bool, len(gslbList.Items))
+	for _, gslb := range gslbList.Items {
+		existing[types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}] = true
+	}
+	for _, gslb := range r.Metrics.Gslbs() {
+		if !existing[gslb] {
+			log.Info("Removing metrics of deleted Gslb", logging.GslbKey, gslb.Name, logging.NamespaceKey, gslb.Namespace)
+			r.deleteGslbMetrics(gslb)
+		}
+	}
+	return nil
+}
+
+// deleteGslbMetrics removes metric series of deleted Gslb together with state they are computed from and
+// load balancer hostnames resolved for it
+func (r *GslbReconciler) deleteGslbMetrics(gslb types.NamespacedName) {
+	r.Metrics.DeleteGslbMetrics(gslb.Namespace, gslb.Name)
+	r.serving.remove(gslb)
+	r.hostnames.remove(gslb)
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// serviceGroupKind identifies Services and their Endpoints in references
+var serviceGroupKind = schema.GroupKind{Kind: "Service"}
+
+// reference identifies object read by Gslb source
+type reference struct {
+	schema.GroupKind
+	types.NamespacedName
+}
+
+// referenceTracker indexes objects which Gslbs read through their ingressRef, HTTPRoute, Gateway or VirtualService
+// source; e.g. parent Gateways and backend Services. Sources are read by reconciliation only, unstructured ones even bypass
+// the cache, so watch handlers map changed objects to Gslbs by the index instead of reading the sources on every event
+type referenceTracker struct {
+	sync.RWMutex
+	references map[types.NamespacedName][]reference
+}
+
+// update replaces objects referenced by Gslb
+func (t *referenceTracker) update(gslb types.NamespacedName, references []reference) {
+	t.Lock()
+	defer t.Unlock()
+	if t.references == nil {
+		t.references = make(map[types.NamespacedName][]reference)
+	}
+	t.references[gslb] = references
+}
+
+// remove forgets objects referenced by deleted Gslb
+func (t *referenceTracker) remove(gslb types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.references, gslb)
+}
+
+// requests returns reconcile requests of Gslbs referencing the object
+func (t *referenceTracker) requests(ref reference) (requests []reconcile.Request) {
+	t.RLock()
+	defer t.RUnlock()
+	for gslb, references := range t.references {
+		for _, r := range references {
+			if r == ref {
+				requests = append(requests, reconcile.Request{NamespacedName: gslb})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// referenceRequests maps changed object of given kind to Gslbs referencing it
+func (r *GslbReconciler) referenceRequests(groupKind schema.GroupKind) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		return r.references.requests(reference{
+			GroupKind:      groupKind,
+			NamespacedName: types.NamespacedName{Namespace: a.Meta.GetNamespace(), Name: a.Meta.GetName()},
+		})
+	}
+}
+
+// kindInstalled returns true when API server serves the kind. Gateway API and Istio CRDs are optional, their
+// objects are watched only in clusters which have them installed
+func kindInstalled(mapper meta.RESTMapper, gvk schema.GroupVersionKind) bool {
+	_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	return err == nil
+}
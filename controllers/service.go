@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	types "k8s.io/apimachinery/pkg/types"
+)
+
+// getGslbServiceTargets returns load balancer IPs and hostnames of referenced Services per Gslb host.
+// Missing Service or Service without load balancer status results in host without targets
+func (r *GslbReconciler) getGslbServiceTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	targets := make(map[string][]string)
+	for _, ref := range gslb.Spec.Services {
+		service := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: gslb.Namespace, Name: ref.ServiceName}, service)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				logging.FromContext(ctx).Info("Can't find gslb Service", "service", ref.ServiceName)
+				continue
+			}
+			return nil, err
+		}
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			logging.FromContext(ctx).Info("Service is not type LoadBalancer, host has no targets", "service", ref.ServiceName, logging.HostKey, ref.Host)
+			continue
+		}
+		targets[ref.Host] = append(targets[ref.Host], loadBalancerTargets(service.Status.LoadBalancer.Ingress)...)
+	}
+	return targets, nil
+}
+
+// getServiceSourceHealthStatus returns health of Gslb hosts exposed via Services type LoadBalancer
+func (r *GslbReconciler) getServiceSourceHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	serviceHealth := make(map[string]string)
+	for _, ref := range gslb.Spec.Services {
+		health, err := r.getEndpointsHealth(ctx, gslb.Namespace, ref.ServiceName)
+		if err != nil {
+			return serviceHealth, err
+		}
+		serviceHealth[ref.Host] = health
+	}
+	return serviceHealth, nil
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	types "k8s.io/apimachinery/pkg/types"
+)
+
+// servingTracker remembers geoTags of clusters serving every Gslb host, so moves of the host between
+// clusters are counted as failovers
+type servingTracker struct {
+	sync.Mutex
+	geoTags map[types.NamespacedName]map[string][]string
+}
+
+// update stores geoTags serving Gslb hosts and returns previous geoTags of hosts which moved to other clusters.
+// Host moves when clusters serving it change, except when clusters are only added or no cluster serves it
+func (t *servingTracker) update(gslb types.NamespacedName, serving map[string][]string) (moved map[string][]string) {
+	t.Lock()
+	defer t.Unlock()
+	if t.geoTags == nil {
+		t.geoTags = make(map[types.NamespacedName]map[string][]string)
+	}
+	moved = make(map[string][]string)
+	for host, geoTags := range serving {
+		previous := t.geoTags[gslb][host]
+		if len(previous) > 0 && len(geoTags) > 0 && !containsAll(geoTags, previous) {
+			moved[host] = previous
+		}
+	}
+	t.geoTags[gslb] = serving
+	return moved
+}
+
+// remove forgets hosts of deleted Gslb
+func (t *servingTracker) remove(gslb types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.geoTags, gslb)
+}
+
+// containsAll returns true when list contains all items
+func containsAll(list []string, items []string) bool {
+	for _, item := range items {
+		if !contains(list, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateServing counts failovers of Gslb hosts and updates the geoTags serving them. Only failover and latency
+// strategies move hosts between clusters, the others serve them by all healthy clusters
+func (r *GslbReconciler) updateServing(ctx context.Context, gslb *k8gbv1beta1.Gslb, serving map[string][]string) {
+	if gslb.Spec.Strategy.Type != failoverStrategy && gslb.Spec.Strategy.Type != latencyStrategy {
+		return
+	}
+	moved := r.serving.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, serving)
+	for host, from := range moved {
+		logging.FromContext(ctx).Info("Host failed over", logging.HostKey, host, "from", from, "to", serving[host])
+		r.Metrics.IncrementFailoverMetric(gslb, from, serving[host])
+	}
+	r.Metrics.UpdateActiveGeoTagMetric(gslb, serving)
+}
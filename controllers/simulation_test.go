@@ -0,0 +1,448 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/fakedns"
+)
+
+// simulation runs several k8gb clusters in one process. Every cluster has its own fake client, geoTag and
+// fake DNS server serving its DNSEndpoint, as CoreDNS of the cluster would. Clusters query each other at those
+// servers and share fake Infoblox, which publishes heartbeats to edge DNS
+type simulation struct {
+	t        *testing.T
+	clusters []*simulatedCluster
+}
+
+// simulatedCluster is k8gb cluster of the simulation
+type simulatedCluster struct {
+	testSettings
+	geoTag string
+	dns    *fakedns.Server
+	// published record types of DNSEndpoint by name
+	published map[string][]uint16
+	// partitioned cluster neither reconciles nor answers DNS queries
+	partitioned bool
+}
+
+// simulatedHost is the healthy host of sample Gslb
+const simulatedHost = "roundrobin.cloud.example.com"
+
+const simulatedService = "frontend-podinfo"
+
+// newSimulation starts cluster per geoTag exposing ingress on ingressIPs[geoTag], and reconciles them until
+// they agree on DNS records and delegated zone. The healthy host uses strategy
+func newSimulation(t *testing.T, strategy k8gbv1beta1.Strategy, geoTags []string, ingressIPs map[string]string) *simulation {
+	t.Helper()
+	s := &simulation{t: t}
+	for _, geoTag := range geoTags {
+		server, err := fakedns.New()
+		require.NoError(t, err)
+		s.clusters = append(s.clusters, &simulatedCluster{geoTag: geoTag, dns: server, published: map[string][]uint16{}})
+	}
+	for _, c := range s.clusters {
+		config := predefinedConfig
+		config.ClusterGeoTag = c.geoTag
+		config.ExtClustersGeoTags = nil
+		config.Override.FakeDNSEnabled = true
+		config.Override.FakeDNSPeers = map[string]string{}
+		for _, peer := range s.clusters {
+			if peer != c {
+				config.ExtClustersGeoTags = append(config.ExtClustersGeoTags, peer.geoTag)
+				config.Override.FakeDNSPeers[peer.geoTag] = peer.dns.Address()
+			}
+		}
+		c.testSettings = provideSettings(t, config)
+		createHealthyService(t, &c.testSettings, simulatedService)
+		err := c.client.Get(context.TODO(), c.request.NamespacedName, c.ingress)
+		require.NoError(t, err, "Failed to get expected ingress")
+		c.ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ingressIPs[c.geoTag]}}
+		err = c.client.Status().Update(context.TODO(), c.ingress)
+		require.NoError(t, err, "Failed to update gslb Ingress Address")
+		err = c.client.Get(context.TODO(), c.request.NamespacedName, c.gslb)
+		require.NoError(t, err)
+		c.gslb.Spec.Strategy.Type = strategy.Type
+		c.gslb.Spec.Strategy.PrimaryGeoTag = strategy.PrimaryGeoTag
+		err = c.client.Update(context.TODO(), c.gslb)
+		require.NoError(t, err, "Can't update gslb")
+	}
+	s.converge()
+	return s
+}
+
+// close stops DNS servers of clusters
+func (s *simulation) close() {
+	for _, c := range s.clusters {
+		_ = c.dns.Close()
+	}
+}
+
+// cluster returns cluster by geoTag
+func (s *simulation) cluster(geoTag string) *simulatedCluster {
+	s.t.Helper()
+	for _, c := range s.clusters {
+		if c.geoTag == geoTag {
+			return c
+		}
+	}
+	s.t.Fatalf("unknown cluster %s", geoTag)
+	return nil
+}
+
+// converge reconciles clusters in rounds until DNS records and delegated zone don't change
+func (s *simulation) converge() {
+	s.t.Helper()
+	const maxRounds = 5
+	previous := s.state()
+	for round := 0; round < maxRounds; round++ {
+		for _, c := range s.clusters {
+			if !c.partitioned {
+				c.reconcile(s.t)
+			}
+		}
+		current := s.state()
+		if round > 0 && reflect.DeepEqual(previous, current) {
+			return
+		}
+		previous = current
+	}
+	s.t.Fatalf("clusters didn't converge in %d rounds", maxRounds)
+}
+
+// state returns DNS records of all clusters and servers of delegated zone
+func (s *simulation) state() map[string]interface{} {
+	state := map[string]interface{}{}
+	for _, c := range s.clusters {
+		state[c.geoTag] = c.endpoints(s.t)
+	}
+	if zone := fakeInfoblox.zoneDelegated(predefinedConfig.DNSZone); zone != nil {
+		state["zone"] = zone.DelegateTo
+	}
+	return state
+}
+
+// partition disconnects cluster; it stops reconciling and its DNS server fails. Heartbeat of the cluster
+// becomes older than split brain threshold
+func (s *simulation) partition(geoTag string) {
+	s.t.Helper()
+	c := s.cluster(geoTag)
+	c.partitioned = true
+	c.dns.SetFailure(dns.RcodeServerFailure)
+	threshold := time.Duration(c.gslb.Spec.Strategy.SplitBrainThresholdSeconds) * time.Second
+	s.ageHeartbeat(geoTag, 2*threshold)
+}
+
+// heal connects partitioned cluster again
+func (s *simulation) heal(geoTag string) {
+	c := s.cluster(geoTag)
+	c.partitioned = false
+	c.dns.SetFailure(dns.RcodeSuccess)
+}
+
+// remove finalizes Gslb of the cluster and drops the cluster from configuration of remaining clusters
+func (s *simulation) remove(geoTag string) {
+	s.t.Helper()
+	c := s.cluster(geoTag)
+	require.NoError(s.t, c.reconciler.finalizeGslb(context.TODO(), c.gslb))
+	_ = c.dns.Close()
+	var clusters []*simulatedCluster
+	for _, peer := range s.clusters {
+		if peer == c {
+			continue
+		}
+		var extGeoTags []string
+		for _, extGeoTag := range peer.reconciler.Config.ExtClustersGeoTags {
+			if extGeoTag != geoTag {
+				extGeoTags = append(extGeoTags, extGeoTag)
+			}
+		}
+		peer.reconciler.Config.ExtClustersGeoTags = extGeoTags
+		delete(peer.reconciler.Config.Override.FakeDNSPeers, geoTag)
+		clusters = append(clusters, peer)
+	}
+	s.clusters = clusters
+}
+
+// ageHeartbeat rewrites heartbeat of the cluster in Infoblox with timestamp from the past
+func (s *simulation) ageHeartbeat(geoTag string, age time.Duration) {
+	s.t.Helper()
+	c := s.cluster(geoTag)
+	name := fmt.Sprintf("%s-heartbeat-%s.%s", c.gslb.Name, geoTag, predefinedConfig.EdgeDNSZone)
+	var records []ibclient.RecordTXT
+	err := fakeInfoblox.GetObject(ibclient.NewRecordTXT(ibclient.RecordTXT{Name: name}), "", &records)
+	require.NoError(s.t, err)
+	require.Len(s.t, records, 1, "heartbeat %s not found", name)
+	timestamp := time.Now().Add(-age).UTC().Format("2006-01-02T15:04:05")
+	_, err = fakeInfoblox.UpdateObject(ibclient.NewRecordTXT(ibclient.RecordTXT{Text: timestamp}), records[0].Ref)
+	require.NoError(s.t, err)
+}
+
+// delegatedTo returns name servers of delegated zone
+func (s *simulation) delegatedTo() []ibclient.NameServer {
+	zone := fakeInfoblox.zoneDelegated(predefinedConfig.DNSZone)
+	if zone == nil {
+		return nil
+	}
+	return zone.DelegateTo
+}
+
+// setHealthy makes workload of the healthy host available or unavailable
+func (c *simulatedCluster) setHealthy(t *testing.T, healthy bool) {
+	t.Helper()
+	if healthy {
+		createHealthyService(t, &c.testSettings, simulatedService)
+		return
+	}
+	deleteHealthyService(t, &c.testSettings, simulatedService)
+}
+
+// reconcile reconciles Gslb of the cluster and publishes its DNSEndpoint to the cluster DNS server
+func (c *simulatedCluster) reconcile(t *testing.T) {
+	t.Helper()
+	_, err := c.reconciler.Reconcile(c.request)
+	require.NoError(t, err)
+	for name, rrTypes := range c.published {
+		for _, rrType := range rrTypes {
+			c.dns.Remove(name, rrType)
+		}
+	}
+	c.published = map[string][]uint16{}
+	for _, ep := range c.endpoints(t) {
+		rrType := dns.StringToType[ep.RecordType]
+		targets := ep.Targets
+		if rrType == dns.TypeCNAME {
+			targets = []string{dns.Fqdn(ep.Targets[0])}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		require.NoError(t, c.dns.Set(ep.DNSName, rrType, targets...))
+		c.published[ep.DNSName] = append(c.published[ep.DNSName], rrType)
+	}
+}
+
+// endpoints returns DNSEndpoint records of the cluster
+func (c *simulatedCluster) endpoints(t *testing.T) []*externaldns.Endpoint {
+	t.Helper()
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	err := c.client.Get(context.TODO(), c.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	return dnsEndpoint.Spec.Endpoints
+}
+
+// targets returns targets the cluster answers for host
+func (c *simulatedCluster) targets(t *testing.T, host string) (targets []string) {
+	t.Helper()
+	for _, ep := range c.endpoints(t) {
+		if ep.DNSName == host {
+			targets = append(targets, ep.Targets...)
+		}
+	}
+	return targets
+}
+
+func TestSimulationRoundRobinAnswersAllClusters(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	want := map[string][]string{
+		"eu": {"10.0.0.1", "10.1.0.1", "10.2.0.1"},
+		"us": {"10.0.0.1", "10.1.0.1", "10.2.0.1"},
+		"za": {"10.0.0.1", "10.1.0.1", "10.2.0.1"},
+	}
+	// act
+	got := map[string][]string{}
+	for _, c := range s.clusters {
+		got[c.geoTag] = c.targets(t, simulatedHost)
+	}
+	// assert
+	assert.Equal(t, want, got)
+	assert.Len(t, s.delegatedTo(), 3)
+}
+
+func TestSimulationFailoverAndFailback(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "eu"}, []string{"eu", "us"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1"})
+	defer s.close()
+	eu, us := s.cluster("eu"), s.cluster("us")
+	require.Equal(t, []string{"10.0.0.1"}, eu.targets(t, simulatedHost))
+	require.Equal(t, []string{"10.0.0.1"}, us.targets(t, simulatedHost))
+	// act
+	eu.setHealthy(t, false)
+	s.converge()
+	euFailover, usFailover := eu.targets(t, simulatedHost), us.targets(t, simulatedHost)
+	eu.setHealthy(t, true)
+	s.converge()
+	euFailback, usFailback := eu.targets(t, simulatedHost), us.targets(t, simulatedHost)
+	// assert
+	assert.Equal(t, []string{"10.1.0.1"}, euFailover)
+	assert.Equal(t, []string{"10.1.0.1"}, usFailover)
+	assert.Equal(t, []string{"10.0.0.1"}, euFailback)
+	assert.Equal(t, []string{"10.0.0.1"}, usFailback)
+}
+
+func TestSimulationSplitBrainFiltersOutPartitionedCluster(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	wantPartitioned := []ibclient.NameServer{
+		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.1.0.1", Name: "gslb-ns-cloud-example-com-us.example.com"},
+	}
+	// act
+	s.partition("za")
+	s.converge()
+	partitioned := s.delegatedTo()
+	euPartitioned := s.cluster("eu").targets(t, simulatedHost)
+	s.heal("za")
+	s.converge()
+	healed := s.delegatedTo()
+	euHealed := s.cluster("eu").targets(t, simulatedHost)
+	// assert
+	assert.ElementsMatch(t, wantPartitioned, partitioned)
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.1"}, euPartitioned)
+	assert.Len(t, healed, 3)
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.1", "10.2.0.1"}, euHealed)
+}
+
+func TestSimulationRemovedClusterIsNoLongerAnswered(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	want := []ibclient.NameServer{
+		{Address: "10.0.0.1", Name: "gslb-ns-cloud-example-com-eu.example.com"},
+		{Address: "10.1.0.1", Name: "gslb-ns-cloud-example-com-us.example.com"},
+	}
+	// act
+	s.remove("za")
+	s.converge()
+	// assert
+	assert.ElementsMatch(t, want, s.delegatedTo())
+	for _, c := range s.clusters {
+		assert.Equal(t, []string{"10.0.0.1", "10.1.0.1"}, c.targets(t, simulatedHost), "cluster %s", c.geoTag)
+	}
+	assert.Empty(t, fakeInfoblox.txtRecords("test-gslb-heartbeat-za.example.com"))
+}
+
+func TestSimulationFailoverIsCountedInMetrics(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "eu"}, []string{"eu", "us"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1"})
+	defer s.close()
+	eu, us := s.cluster("eu"), s.cluster("us")
+	labels := func(c *simulatedCluster, from, to string) prometheus.Labels {
+		return prometheus.Labels{"namespace": c.gslb.Namespace, "name": c.gslb.Name, "from": from, "to": to}
+	}
+	active := func(c *simulatedCluster, geoTag string) float64 {
+		metric := c.reconciler.Metrics.GetActiveGeoTagMetric()
+		return testutil.ToFloat64(metric.With(prometheus.Labels{"namespace": c.gslb.Namespace, "name": c.gslb.Name, "geotag": geoTag}))
+	}
+	// act
+	eu.setHealthy(t, false)
+	s.converge()
+	euActiveFailover, usActiveFailover := active(eu, "us"), active(us, "us")
+	eu.setHealthy(t, true)
+	s.converge()
+	// assert
+	for _, c := range []*simulatedCluster{eu, us} {
+		failoverMetric := c.reconciler.Metrics.GetFailoverMetric()
+		assert.Equal(t, 1.0, testutil.ToFloat64(failoverMetric.With(labels(c, "eu", "us"))), "cluster %s", c.geoTag)
+		assert.Equal(t, 1.0, testutil.ToFloat64(failoverMetric.With(labels(c, "us", "eu"))), "cluster %s", c.geoTag)
+		assert.Equal(t, 1.0, active(c, "eu"), "cluster %s", c.geoTag)
+		assert.Equal(t, 0.0, active(c, "us"), "cluster %s", c.geoTag)
+	}
+	assert.Equal(t, 1.0, euActiveFailover)
+	assert.Equal(t, 1.0, usActiveFailover)
+}
+
+func TestSimulationPeerMetricsFollowPartitionedCluster(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	eu := s.cluster("eu")
+	gslbLabels := func(peer string) prometheus.Labels {
+		return prometheus.Labels{"namespace": eu.gslb.Namespace, "name": eu.gslb.Name, "peer": peer}
+	}
+	peerUpMetric := eu.reconciler.Metrics.GetPeerUpMetric()
+	heartbeatAgeMetric := eu.reconciler.Metrics.GetPeerHeartbeatAgeMetric()
+	splitBrainExpiredMetric := eu.reconciler.Metrics.GetSplitBrainExpiredMetric()
+	threshold := time.Duration(eu.gslb.Spec.Strategy.SplitBrainThresholdSeconds) * time.Second
+	// act
+	s.partition("za")
+	s.converge()
+	zaPartitionedUp := testutil.ToFloat64(peerUpMetric.With(prometheus.Labels{"peer": "za"}))
+	zaPartitionedAge := testutil.ToFloat64(heartbeatAgeMetric.With(gslbLabels("za")))
+	s.heal("za")
+	s.converge()
+	// assert
+	assert.Equal(t, 0.0, zaPartitionedUp)
+	assert.Greater(t, zaPartitionedAge, threshold.Seconds())
+	assert.Equal(t, 1.0, testutil.ToFloat64(peerUpMetric.With(prometheus.Labels{"peer": "za"})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(peerUpMetric.With(prometheus.Labels{"peer": "us"})))
+	assert.Less(t, testutil.ToFloat64(heartbeatAgeMetric.With(gslbLabels("za"))), threshold.Seconds())
+	assert.Greater(t, testutil.ToFloat64(splitBrainExpiredMetric.With(gslbLabels("za"))), 0.0)
+	assert.Equal(t, 0.0, testutil.ToFloat64(splitBrainExpiredMetric.With(gslbLabels("us"))))
+	assert.Equal(t, 2, testutil.CollectAndCount(eu.reconciler.Metrics.GetPeerDNSQueryDurationMetric()))
+}
+
+func TestSimulationUnreachablePeerDoesNotHideOtherPeers(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	eu, za := s.cluster("eu"), s.cluster("za")
+	peerUpMetric := eu.reconciler.Metrics.GetPeerUpMetric()
+	// act
+	za.partitioned = true
+	require.NoError(t, za.dns.Close())
+	eu.reconcile(t)
+	// assert
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.1"}, eu.targets(t, simulatedHost))
+	assert.Equal(t, 0.0, testutil.ToFloat64(peerUpMetric.With(prometheus.Labels{"peer": "za"})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(peerUpMetric.With(prometheus.Labels{"peer": "us"})))
+}
+
+func TestSimulationRemovedClusterHasNoPeerMetrics(t *testing.T) {
+	// arrange
+	defer cleanup()
+	s := newSimulation(t, k8gbv1beta1.Strategy{Type: roundRobinStrategy}, []string{"eu", "us", "za"},
+		map[string]string{"eu": "10.0.0.1", "us": "10.1.0.1", "za": "10.2.0.1"})
+	defer s.close()
+	za := s.cluster("za")
+	// act
+	s.remove("za")
+	s.converge()
+	// assert
+	for _, c := range s.clusters {
+		assert.Equal(t, 1, testutil.CollectAndCount(c.reconciler.Metrics.GetPeerUpMetric()), "cluster %s", c.geoTag)
+		assert.Equal(t, 1, testutil.CollectAndCount(c.reconciler.Metrics.GetPeerDNSQueryDurationMetric()), "cluster %s", c.geoTag)
+		assert.Equal(t, 1, testutil.CollectAndCount(c.reconciler.Metrics.GetPeerHeartbeatAgeMetric()), "cluster %s", c.geoTag)
+	}
+	assert.Equal(t, 0, testutil.CollectAndCount(za.reconciler.Metrics.GetPeerHeartbeatAgeMetric()))
+}
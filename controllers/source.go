@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+)
+
+// gslbSourceType determines which kind of resource exposes Gslb hosts
+type gslbSourceType string
+
+const (
+	// ingressSource Gslb creates and owns the Ingress described by spec.ingress
+	ingressSource gslbSourceType = "ingress"
+	// ingressRefSource Gslb reads hosts and targets from existing Ingress referenced by spec.ingressRef
+	ingressRefSource gslbSourceType = "ingressRef"
+	// serviceSource Gslb reads hosts from spec.services and targets from Services type LoadBalancer
+	serviceSource gslbSourceType = "services"
+	// httpRouteSource Gslb reads hosts from Gateway API HTTPRoute and targets from its parent Gateways
+	httpRouteSource gslbSourceType = "httpRoute"
+	// gatewaySource Gslb reads hosts from Gateway API Gateway listeners and targets from the Gateway
+	gatewaySource gslbSourceType = "gateway"
+	// virtualServiceSource Gslb reads hosts from Istio VirtualService and targets from Istio ingress gateway Service
+	virtualServiceSource gslbSourceType = "virtualService"
+)
+
+// gslbSource returns the source configured in Gslb spec. Ingress is the default source
+// so Gslb without any source behaves as before. Multiple sources are rejected.
+func gslbSource(gslb *k8gbv1beta1.Gslb) (gslbSourceType, error) {
+	var sources []gslbSourceType
+	if len(gslb.Spec.Ingress.Rules) > 0 || gslb.Spec.Ingress.Backend != nil {
+		sources = append(sources, ingressSource)
+	}
+	if gslb.Spec.IngressRef != nil {
+		sources = append(sources, ingressRefSource)
+	}
+	if len(gslb.Spec.Services) > 0 {
+		sources = append(sources, serviceSource)
+	}
+	if gslb.Spec.HTTPRoute != nil {
+		sources = append(sources, httpRouteSource)
+	}
+	if gslb.Spec.Gateway != nil {
+		sources = append(sources, gatewaySource)
+	}
+	if gslb.Spec.VirtualService != nil {
+		sources = append(sources, virtualServiceSource)
+	}
+	switch len(sources) {
+	case 0:
+		return ingressSource, nil
+	case 1:
+		return sources[0], nil
+	}
+	return "", fmt.Errorf("gslb %s defines multiple sources %v, only one is allowed", gslb.Name, sources)
+}
+
+// getLocalTargets returns targets of local cluster per Gslb host. Target is either IP address or
+// hostname of load balancer which is not resolved yet
+func (r *GslbReconciler) getLocalTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+	source, err := gslbSource(gslb)
+	if err != nil {
+		return nil, err
+	}
+	switch source {
+	case ingressRefSource:
+		return r.getGslbIngressRefTargets(ctx, gslb)
+	case serviceSource:
+		return r.getGslbServiceTargets(ctx, gslb)
+	case httpRouteSource:
+		return r.getGslbHTTPRouteTargets(ctx, gslb)
+	case gatewaySource:
+		return r.getGslbGatewayTargets(ctx, gslb)
+	case virtualServiceSource:
+		return r.getGslbVirtualServiceTargets(ctx, gslb)
+	}
+	ingressTargets, err := r.getGslbIngressTargets(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string][]string)
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		targets[rule.Host] = ingressTargets
+	}
+	return targets, nil
+}
+
+// getLocalIPs returns all distinct IP addresses exposing Gslb in local cluster. Load balancer
+// hostnames are resolved
+func (r *GslbReconciler) getLocalIPs(ctx context.Context, gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	source, err := gslbSource(gslb)
+	if err != nil {
+		return nil, err
+	}
+	if source == ingressSource {
+		ingressTargets, err := r.getGslbIngressTargets(ctx, gslb)
+		if err != nil {
+			return nil, err
+		}
+		return r.resolveTargets(ctx, gslb, ingressTargets)
+	}
+	targets, err := r.getLocalTargets(ctx, gslb)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(targets))
+	for host := range targets {
+		hosts = append(hosts, host)
+	}
+	// keep the order stable between reconciliations
+	sort.Strings(hosts)
+	var localTargets []string
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		for _, target := range targets[host] {
+			if !seen[target] {
+				seen[target] = true
+				localTargets = append(localTargets, target)
+			}
+		}
+	}
+	return r.resolveTargets(ctx, gslb, localTargets)
+}
@@ -6,16 +6,17 @@ import (
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	types "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 )
 
-func (r *GslbReconciler) updateGslbStatus(gslb *k8gbv1beta1.Gslb) error {
+func (r *GslbReconciler) updateGslbStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) error {
 	var err error
 
-	gslb.Status.ServiceHealth, err = r.getServiceHealthStatus(gslb)
+	gslb.Status.ServiceHealth, err = r.getServiceHealthStatus(ctx, gslb)
 	if err != nil {
 		return err
 	}
@@ -25,66 +26,121 @@ func (r *GslbReconciler) updateGslbStatus(gslb *k8gbv1beta1.Gslb) error {
 		return err
 	}
 
-	gslb.Status.HealthyRecords, err = r.getHealthyRecords(gslb)
+	gslb.Status.HealthyRecords, err = r.getHealthyRecords(ctx, gslb)
 	if err != nil {
 		return err
 	}
 
 	gslb.Status.GeoTag = r.Config.ClusterGeoTag
+	r.Metrics.RetainPeerMetrics(r.Config.ExtClustersGeoTags)
+
+	if gslb.Spec.Strategy.Type == latencyStrategy {
+		gslb.Status.Latency = r.latencyStatus()
+		r.Metrics.UpdatePeerLatencyMetric(r.latencies.measurements())
+	}
 
 	err = r.Metrics.UpdateHealthyRecordsMetric(gslb, gslb.Status.HealthyRecords)
 	if err != nil {
 		return err
 	}
 
-	err = r.Status().Update(context.TODO(), gslb)
+	err = r.Status().Update(ctx, gslb)
 	return err
 }
 
-func (r *GslbReconciler) getServiceHealthStatus(gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+func (r *GslbReconciler) getServiceHealthStatus(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
+	source, err := gslbSource(gslb)
+	if err != nil {
+		return nil, err
+	}
+	switch source {
+	case ingressRefSource:
+		return r.getIngressRefHealthStatus(ctx, gslb)
+	case serviceSource:
+		return r.getServiceSourceHealthStatus(ctx, gslb)
+	case httpRouteSource:
+		return r.getHTTPRouteHealthStatus(ctx, gslb)
+	case gatewaySource:
+		return r.getGatewayHealthStatus(ctx, gslb)
+	case virtualServiceSource:
+		return r.getVirtualServiceHealthStatus(ctx, gslb)
+	}
+	return r.getIngressRulesHealth(ctx, gslb.Namespace, gslb.Spec.Ingress.Rules)
+}
+
+// getIngressRulesHealth returns health of Ingress rule hosts according to the backend Services of their paths
+func (r *GslbReconciler) getIngressRulesHealth(ctx context.Context, namespace string, rules []v1beta1.IngressRule) (map[string]string, error) {
 	serviceHealth := make(map[string]string)
-	for _, rule := range gslb.Spec.Ingress.Rules {
+	for _, rule := range rules {
+		if rule.HTTP == nil {
+			continue
+		}
 		for _, path := range rule.HTTP.Paths {
-			service := &corev1.Service{}
-			finder := client.ObjectKey{
-				Namespace: gslb.Namespace,
-				Name:      path.Backend.ServiceName,
-			}
-			err := r.Get(context.TODO(), finder, service)
+			health, err := r.getEndpointsHealth(ctx, namespace, path.Backend.ServiceName)
 			if err != nil {
-				if errors.IsNotFound(err) {
-					serviceHealth[rule.Host] = "NotFound"
-					continue
-				}
 				return serviceHealth, err
 			}
+			serviceHealth[rule.Host] = health
+		}
+	}
+	return serviceHealth, nil
+}
+
+// getEndpointsHealth returns health of the Service according to its Endpoints
+func (r *GslbReconciler) getEndpointsHealth(ctx context.Context, namespace, serviceName string) (string, error) {
+	service := &corev1.Service{}
+	finder := client.ObjectKey{
+		Namespace: namespace,
+		Name:      serviceName,
+	}
+	err := r.Get(ctx, finder, service)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "NotFound", nil
+		}
+		return "", err
+	}
 
-			endpoints := &corev1.Endpoints{}
+	endpoints := &corev1.Endpoints{}
 
-			nn := types.NamespacedName{
-				Name:      path.Backend.ServiceName,
-				Namespace: gslb.Namespace,
-			}
+	nn := types.NamespacedName{
+		Name:      serviceName,
+		Namespace: namespace,
+	}
 
-			err = r.Get(context.TODO(), nn, endpoints)
-			if err != nil {
-				return serviceHealth, err
-			}
+	err = r.Get(ctx, nn, endpoints)
+	if err != nil {
+		return "", err
+	}
 
-			serviceHealth[rule.Host] = "Unhealthy"
-			if len(endpoints.Subsets) > 0 {
-				for _, subset := range endpoints.Subsets {
-					if len(subset.Addresses) > 0 {
-						serviceHealth[rule.Host] = "Healthy"
-					}
-				}
-			}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return "Healthy", nil
 		}
 	}
-	return serviceHealth, nil
+	return "Unhealthy", nil
+}
+
+// getServicesHealth returns Healthy if any of services is Healthy, Unhealthy if any of services exists
+// and NotFound otherwise
+func (r *GslbReconciler) getServicesHealth(ctx context.Context, services []types.NamespacedName) (string, error) {
+	health := "NotFound"
+	for _, nn := range services {
+		serviceHealth, err := r.getEndpointsHealth(ctx, nn.Namespace, nn.Name)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case serviceHealth == "Healthy":
+			return serviceHealth, nil
+		case serviceHealth == "Unhealthy":
+			health = serviceHealth
+		}
+	}
+	return health, nil
 }
 
-func (r *GslbReconciler) getHealthyRecords(gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
+func (r *GslbReconciler) getHealthyRecords(ctx context.Context, gslb *k8gbv1beta1.Gslb) (map[string][]string, error) {
 
 	dnsEndpoint := &externaldns.DNSEndpoint{}
 
@@ -93,7 +149,7 @@ func (r *GslbReconciler) getHealthyRecords(gslb *k8gbv1beta1.Gslb) (map[string][
 		Namespace: gslb.Namespace,
 	}
 
-	err := r.Get(context.TODO(), nn, dnsEndpoint)
+	err := r.Get(ctx, nn, dnsEndpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +159,9 @@ func (r *GslbReconciler) getHealthyRecords(gslb *k8gbv1beta1.Gslb) (map[string][
 	serviceRegex := regexp.MustCompile("^localtargets")
 	for _, endpoint := range dnsEndpoint.Spec.Endpoints {
 		local := serviceRegex.Match([]byte(endpoint.DNSName))
-		if !local && endpoint.RecordType == "A" {
+		if !local && (endpoint.RecordType == "A" || endpoint.RecordType == "AAAA" || endpoint.RecordType == "CNAME") {
 			if len(endpoint.Targets) > 0 {
-				healthyRecords[endpoint.DNSName] = endpoint.Targets
+				healthyRecords[endpoint.DNSName] = append(healthyRecords[endpoint.DNSName], endpoint.Targets...)
 			}
 		}
 	}
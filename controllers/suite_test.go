@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	crscheme "sigs.k8s.io/controller-runtime/pkg/scheme"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/metrics"
+)
+
+// envtest timing; reconciliation is triggered by watches, so it happens well within the timeout
+const (
+	envtestTimeout = 20 * time.Second
+	envtestTick    = 100 * time.Millisecond
+)
+
+// TestManagerWithAPIServer runs controller manager against etcd and kube-apiserver started by envtest. Unlike the rest
+// of the tests, watches, owner references, finalizers and status subresource are handled by real API server. Test
+// is skipped unless KUBEBUILDER_ASSETS points to the binaries; see `make test-envtest`
+func TestManagerWithAPIServer(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skipping envtest suite")
+	}
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "chart", "k8gb", "crds")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	require.NoError(t, err, "starting envtest")
+	defer func() {
+		assert.NoError(t, env.Stop(), "stopping envtest")
+	}()
+
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	schemeBuilder := &crscheme.Builder{GroupVersion: schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}}
+	schemeBuilder.Register(&externaldns.DNSEndpoint{}, &externaldns.DNSEndpointList{})
+	require.NoError(t, schemeBuilder.AddToScheme(s))
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: s, MetricsBindAddress: "0"})
+	require.NoError(t, err, "creating manager")
+	config := &depresolver.Config{
+		ReconcileRequeueSeconds: 30,
+		ClusterGeoTag:           "eu",
+		EdgeDNSType:             depresolver.DNSTypeNoEdgeDNS,
+		EdgeDNSServer:           "8.8.8.8",
+		EdgeDNSZone:             "example.com",
+		DNSZone:                 "cloud.example.com",
+		K8gbNamespace:           "k8gb",
+	}
+	r := &GslbReconciler{
+		Client:  mgr.GetClient(),
+		Log:     ctrl.Log.WithName("controllers").WithName("Gslb"),
+		Scheme:  mgr.GetScheme(),
+		Config:  config,
+		Metrics: metrics.NewPrometheusMetrics(*config),
+	}
+	r.DepResolver = depresolver.NewDependencyResolver(r.Client)
+	require.NoError(t, r.SetupWithManager(mgr), "setting up controller")
+	require.NoError(t, (&IngressReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("k8gb"),
+	}).SetupWithManager(mgr), "setting up ingress controller")
+	stop := make(chan struct{})
+	managerDone := make(chan error)
+	go func() {
+		managerDone <- mgr.Start(stop)
+	}()
+	defer func() {
+		close(stop)
+		assert.NoError(t, <-managerDone, "running manager")
+	}()
+
+	// assertions read API server directly, not the cache of manager
+	c, err := client.New(cfg, client.Options{Scheme: s})
+	require.NoError(t, err, "creating client")
+
+	t.Run("GslbCreatesOwnedIngressAndDNSEndpoint", func(t *testing.T) {
+		gslb := createEnvtestGslb(t, c, "gslb-owned")
+		ingress := &v1beta1.Ingress{}
+		dnsEndpoint := &externaldns.DNSEndpoint{}
+		key := types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}
+
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, ingress) == nil && c.Get(context.TODO(), key, dnsEndpoint) == nil
+		}, envtestTimeout, envtestTick, "Ingress and DNSEndpoint were not created")
+		require.NoError(t, c.Get(context.TODO(), key, gslb))
+
+		assert.Equal(t, gslb.Spec.Ingress.Rules, ingress.Spec.Rules)
+		assert.True(t, metav1.IsControlledBy(ingress, gslb), "Ingress is not controlled by Gslb")
+		assert.True(t, metav1.IsControlledBy(dnsEndpoint, gslb), "DNSEndpoint is not controlled by Gslb")
+		assert.Contains(t, gslb.Finalizers, gslbFinalizer)
+	})
+
+	t.Run("UnchangedIngressIsNotRewritten", func(t *testing.T) {
+		gslb := createEnvtestGslb(t, c, "gslb-unchanged")
+		key := types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}
+		ingress := &v1beta1.Ingress{}
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, ingress) == nil
+		}, envtestTimeout, envtestTick, "Ingress was not created")
+		writes := r.Metrics.GetWritesMetric()
+		skipped := writes.With(prometheus.Labels{"namespace": gslb.Namespace, "name": gslb.Name, "kind": "Ingress",
+			"result": metrics.WriteSkipped})
+		skippedBefore := testutil.ToFloat64(skipped)
+
+		// act
+		require.NoError(t, c.Get(context.TODO(), key, gslb))
+		gslb.Annotations = map[string]string{"reconcile": "again"}
+		require.NoError(t, c.Update(context.TODO(), gslb))
+
+		// assert
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(skipped) > skippedBefore
+		}, envtestTimeout, envtestTick, "Ingress write was not skipped")
+		found := &v1beta1.Ingress{}
+		require.NoError(t, c.Get(context.TODO(), key, found))
+		assert.Equal(t, ingress.ResourceVersion, found.ResourceVersion, "Ingress defaulted by API server was rewritten")
+	})
+
+	t.Run("StatusFollowsServiceEndpointsAndIngressAddress", func(t *testing.T) {
+		gslb := createEnvtestGslb(t, c, "gslb-status")
+		key := types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}
+		hasHealth := func(health string) func() bool {
+			return func() bool {
+				return c.Get(context.TODO(), key, gslb) == nil &&
+					gslb.Status.ServiceHealth["roundrobin.cloud.example.com"] == health
+			}
+		}
+		require.Eventually(t, hasHealth("NotFound"), envtestTimeout, envtestTick)
+
+		// act
+		createEnvtestService(t, c, gslb.Namespace, "frontend-podinfo", "10.244.0.1")
+		require.Eventually(t, hasHealth("Healthy"), envtestTimeout, envtestTick, "Service watch didn't reconcile Gslb")
+		ingress := &v1beta1.Ingress{}
+		require.NoError(t, c.Get(context.TODO(), key, ingress))
+		ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+		require.NoError(t, c.Status().Update(context.TODO(), ingress))
+
+		// assert
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, gslb) == nil &&
+				assert.ObjectsAreEqual([]string{"10.0.0.1"}, gslb.Status.HealthyRecords["roundrobin.cloud.example.com"])
+		}, envtestTimeout, envtestTick, "Ingress watch didn't reconcile Gslb")
+		dnsEndpoint := &externaldns.DNSEndpoint{}
+		require.NoError(t, c.Get(context.TODO(), key, dnsEndpoint))
+		assert.Contains(t, dnsEndpoint.Spec.Endpoints, &externaldns.Endpoint{
+			DNSName:    "localtargets-roundrobin.cloud.example.com",
+			RecordTTL:  30,
+			RecordType: "A",
+			Targets:    externaldns.Targets{"10.0.0.1"},
+		})
+		assert.Equal(t, "eu", gslb.Status.GeoTag)
+
+		// act
+		endpoints := &corev1.Endpoints{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: gslb.Namespace, Name: "frontend-podinfo"}, endpoints))
+		endpoints.Subsets = nil
+		require.NoError(t, c.Update(context.TODO(), endpoints))
+
+		// assert
+		require.Eventually(t, hasHealth("Unhealthy"), envtestTimeout, envtestTick, "Endpoints watch didn't reconcile Gslb")
+	})
+
+	t.Run("AnnotatedIngressCreatesGslb", func(t *testing.T) {
+		namespace := createEnvtestNamespace(t, c, "annotated-ingress")
+		ingress := &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "annotated",
+				Annotations: map[string]string{
+					strategyAnnotation:      failoverStrategy,
+					primaryGeoTagAnnotation: "eu",
+				},
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{{
+					Host: "annotated.cloud.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Path:    "/",
+							Backend: v1beta1.IngressBackend{ServiceName: "annotated", ServicePort: intstr.FromString("http")},
+						}},
+					}},
+				}},
+			},
+		}
+		require.NoError(t, c.Create(context.TODO(), ingress))
+		gslb := &k8gbv1beta1.Gslb{}
+
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "annotated"}, gslb) == nil
+		}, envtestTimeout, envtestTick, "Gslb was not created from annotated Ingress")
+
+		assert.Equal(t, &k8gbv1beta1.IngressRef{Name: "annotated"}, gslb.Spec.IngressRef)
+		assert.Equal(t, failoverStrategy, gslb.Spec.Strategy.Type)
+		assert.Equal(t, "eu", gslb.Spec.Strategy.PrimaryGeoTag)
+		assert.True(t, metav1.IsControlledBy(gslb, ingress), "Gslb is not controlled by Ingress")
+
+		// referenced Ingress is read, but never written by Gslb
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "annotated"}, gslb) == nil &&
+				gslb.Status.ServiceHealth["annotated.cloud.example.com"] == "NotFound"
+		}, envtestTimeout, envtestTick, "Gslb status was not updated from referenced Ingress")
+		found := &v1beta1.Ingress{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "annotated"}, found))
+		assert.Equal(t, ingress.ResourceVersion, found.ResourceVersion)
+		assert.Empty(t, found.OwnerReferences)
+	})
+
+	t.Run("AnnotatedIngressChangesAreSyncedToGslb", func(t *testing.T) {
+		namespace := createEnvtestNamespace(t, c, "synced-ingress")
+		key := types.NamespacedName{Namespace: namespace, Name: "synced"}
+		ingress := &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        "synced",
+				Annotations: map[string]string{strategyAnnotation: roundRobinStrategy},
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{{Host: "synced.cloud.example.com"}},
+			},
+		}
+		require.NoError(t, c.Create(context.TODO(), ingress))
+		gslb := &k8gbv1beta1.Gslb{}
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, gslb) == nil
+		}, envtestTimeout, envtestTick, "Gslb was not created from annotated Ingress")
+
+		// act
+		require.NoError(t, c.Get(context.TODO(), key, ingress))
+		ingress.Annotations[strategyAnnotation] = geoStrategy
+		ingress.Annotations[depresolver.DNSTTLSecondsAnnotation] = "60"
+		require.NoError(t, c.Update(context.TODO(), ingress))
+
+		// assert
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, gslb) == nil && gslb.Spec.Strategy.Type == geoStrategy &&
+				gslb.Spec.Strategy.DNSTtlSeconds == 60
+		}, envtestTimeout, envtestTick, "Ingress annotations were not synced to Gslb")
+
+		// act
+		require.NoError(t, c.Get(context.TODO(), key, ingress))
+		delete(ingress.Annotations, strategyAnnotation)
+		require.NoError(t, c.Update(context.TODO(), ingress))
+
+		// assert
+		require.Eventually(t, func() bool {
+			return errors.IsNotFound(c.Get(context.TODO(), key, &k8gbv1beta1.Gslb{}))
+		}, envtestTimeout, envtestTick, "Gslb was not deleted when annotation was removed")
+	})
+
+	t.Run("DeletedGslbIsFinalized", func(t *testing.T) {
+		gslb := createEnvtestGslb(t, c, "gslb-finalized")
+		key := types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}
+		require.Eventually(t, func() bool {
+			return c.Get(context.TODO(), key, gslb) == nil && contains(gslb.Finalizers, gslbFinalizer)
+		}, envtestTimeout, envtestTick, "finalizer was not added")
+
+		// act
+		require.NoError(t, c.Delete(context.TODO(), gslb))
+
+		// assert
+		require.Eventually(t, func() bool {
+			return errors.IsNotFound(c.Get(context.TODO(), key, &k8gbv1beta1.Gslb{}))
+		}, envtestTimeout, envtestTick, "finalizer was not removed")
+	})
+}
+
+// createEnvtestNamespace creates namespace prefixed by name, so subtests don't share objects
+func createEnvtestNamespace(t *testing.T, c client.Client, name string) string {
+	t.Helper()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: name + "-"}}
+	require.NoError(t, c.Create(context.TODO(), namespace))
+	return namespace.Name
+}
+
+// createEnvtestGslb creates sample Gslb in its own namespace
+func createEnvtestGslb(t *testing.T, c client.Client, name string) *k8gbv1beta1.Gslb {
+	t.Helper()
+	gslbYaml, err := ioutil.ReadFile(crSampleYaml)
+	require.NoError(t, err, "Can't open example CR file: %s", crSampleYaml)
+	gslb, err := utils.YamlToGslb(gslbYaml)
+	require.NoError(t, err)
+	gslb.Namespace = createEnvtestNamespace(t, c, name)
+	require.NoError(t, c.Create(context.TODO(), gslb))
+	return gslb
+}
+
+// createEnvtestService creates Service with Endpoints pointing to address
+func createEnvtestService(t *testing.T, c client.Client, namespace, name, address string) {
+	t.Helper()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+	require.NoError(t, c.Create(context.TODO(), service))
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: address}},
+			Ports:     []corev1.EndpointPort{{Name: "http", Port: 80}},
+		}},
+	}
+	require.NoError(t, c.Create(context.TODO(), endpoints))
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
+	corev1 "k8s.io/api/core/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// hostnameTracker remembers IP addresses of load balancer hostnames resolved during previous reconciliation
+// of every Gslb, so the changes made by cloud provider are visible in the log
+type hostnameTracker struct {
+	sync.Mutex
+	resolved map[types.NamespacedName]map[string][]string
+}
+
+// update stores IPs of Gslb hostname and returns previous IPs if they differ
+func (t *hostnameTracker) update(gslb types.NamespacedName, hostname string, IPs []string) (previous []string, changed bool) {
+	t.Lock()
+	defer t.Unlock()
+	if t.resolved == nil {
+		t.resolved = make(map[types.NamespacedName]map[string][]string)
+	}
+	if t.resolved[gslb] == nil {
+		t.resolved[gslb] = make(map[string][]string)
+	}
+	previous, found := t.resolved[gslb][hostname]
+	t.resolved[gslb][hostname] = IPs
+	return previous, found && !equalTargets(previous, IPs)
+}
+
+// remove forgets hostnames of deleted Gslb
+func (t *hostnameTracker) remove(gslb types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.resolved, gslb)
+}
+
+// loadBalancerTargets returns IPs and hostnames of load balancer status entries. Hostnames are kept
+// unresolved; see resolveTargets
+func loadBalancerTargets(lbIngress []corev1.LoadBalancerIngress) (targets []string) {
+	for _, ip := range lbIngress {
+		if len(ip.IP) > 0 {
+			targets = append(targets, ip.IP)
+		}
+		if len(ip.Hostname) > 0 {
+			targets = append(targets, ip.Hostname)
+		}
+	}
+	return targets
+}
+
+// resolveTargets replaces hostname targets with their IPs resolved via EdgeDNS. IP targets are kept as they are
+func (r *GslbReconciler) resolveTargets(ctx context.Context, gslb *k8gbv1beta1.Gslb, targets []string) ([]string, error) {
+	var IPs []string
+	for _, target := range targets {
+		if net.ParseIP(target) != nil {
+			IPs = append(IPs, target)
+			continue
+		}
+		resolved, err := utils.Dig(ctx, r.Config.EdgeDNSServer, target)
+		if err != nil {
+			logging.FromContext(ctx).Info("Can't resolve load balancer hostname", "hostname", target, "error", err.Error())
+			return nil, err
+		}
+		if previous, changed := r.hostnames.update(types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}, target, resolved); changed {
+			logging.FromContext(ctx).Info("Load balancer IPs changed", "hostname", target, "previous", previous, "current", resolved)
+		}
+		IPs = append(IPs, resolved...)
+	}
+	return IPs, nil
+}
+
+// targetEndpoints creates DNS records for targets. In cname mode a single load balancer hostname is published
+// as CNAME record. CNAME can't coexist with other records of the same name, so multiple hostnames or hostnames
+// mixed with IPs (e.g. roundRobin across clusters with different load balancers) are resolved and published
+// as A/AAAA records
+func (r *GslbReconciler) targetEndpoints(ctx context.Context, gslb *k8gbv1beta1.Gslb, name string, ttl externaldns.TTL, targets []string, alwaysA bool) ([]*externaldns.Endpoint, error) {
+	if gslb.Spec.Strategy.HostnameTargets == depresolver.HostnameTargetsCNAME {
+		if hostname, ok := cnameTarget(targets); ok {
+			return []*externaldns.Endpoint{{
+				DNSName:    name,
+				RecordTTL:  ttl,
+				RecordType: "CNAME",
+				Targets:    externaldns.Targets{hostname},
+			}}, nil
+		}
+	}
+	IPs, err := r.resolveTargets(ctx, gslb, targets)
+	if err != nil {
+		return nil, err
+	}
+	return addressEndpoints(name, ttl, IPs, alwaysA), nil
+}
+
+// cnameTarget returns the hostname if all targets are the same hostname
+func cnameTarget(targets []string) (hostname string, ok bool) {
+	for _, target := range targets {
+		if net.ParseIP(target) != nil {
+			return "", false
+		}
+		target = strings.TrimSuffix(target, ".")
+		if hostname != "" && hostname != target {
+			return "", false
+		}
+		hostname = target
+	}
+	return hostname, hostname != ""
+}
+
+func equalTargets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"reflect"
+
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Kubernetes API call attribute keys
+const (
+	kindKey      = label.Key("k8s.kind")
+	namespaceKey = label.Key("k8s.namespace")
+	nameKey      = label.Key("k8s.name")
+)
+
+// NewClient returns client recording span of every Kubernetes API call
+func NewClient(c client.Client) client.Client {
+	return &tracedClient{Client: c}
+}
+
+type tracedClient struct {
+	client.Client
+}
+
+func (c *tracedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) (err error) {
+	ctx, span := Start(ctx, "k8s.Get", kindKey.String(kind(obj)), namespaceKey.String(key.Namespace), nameKey.String(key.Name))
+	defer func() { End(span, err) }()
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *tracedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) (err error) {
+	ctx, span := Start(ctx, "k8s.List", kindKey.String(kind(list)))
+	defer func() { End(span, err) }()
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *tracedClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) (err error) {
+	ctx, span := start(ctx, "k8s.Create", obj)
+	defer func() { End(span, err) }()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *tracedClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) (err error) {
+	ctx, span := start(ctx, "k8s.Delete", obj)
+	defer func() { End(span, err) }()
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *tracedClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) (err error) {
+	ctx, span := start(ctx, "k8s.Update", obj)
+	defer func() { End(span, err) }()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *tracedClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) (err error) {
+	ctx, span := start(ctx, "k8s.Patch", obj)
+	defer func() { End(span, err) }()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *tracedClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) (err error) {
+	ctx, span := Start(ctx, "k8s.DeleteAllOf", kindKey.String(kind(obj)))
+	defer func() { End(span, err) }()
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *tracedClient) Status() client.StatusWriter {
+	return &tracedStatusWriter{StatusWriter: c.Client.Status()}
+}
+
+type tracedStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w *tracedStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) (err error) {
+	ctx, span := start(ctx, "k8s.UpdateStatus", obj)
+	defer func() { End(span, err) }()
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *tracedStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) (err error) {
+	ctx, span := start(ctx, "k8s.PatchStatus", obj)
+	defer func() { End(span, err) }()
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// start starts span of API call with the object identity
+func start(ctx context.Context, name string, obj runtime.Object) (context.Context, trace.Span) {
+	attributes := []label.KeyValue{kindKey.String(kind(obj))}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		attributes = append(attributes, namespaceKey.String(accessor.GetNamespace()), nameKey.String(accessor.GetName()))
+	}
+	return Start(ctx, name, attributes...)
+}
+
+// kind returns object kind; typed objects usually come with empty TypeMeta, so the Go type name is used instead
+func kind(obj runtime.Object) string {
+	if k := obj.GetObjectKind().GroupVersionKind().Kind; k != "" {
+		return k
+	}
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
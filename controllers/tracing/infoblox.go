@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client"
+	"go.opentelemetry.io/otel/label"
+)
+
+// Infoblox WAPI call attribute keys
+const (
+	objectTypeKey = label.Key("infoblox.object.type")
+	refKey        = label.Key("infoblox.ref")
+)
+
+// NewIBConnector returns Infoblox connector recording span of every WAPI call as a child of span carried by ctx
+func NewIBConnector(ctx context.Context, connector ibclient.IBConnector) ibclient.IBConnector {
+	return &tracedIBConnector{ctx: ctx, connector: connector}
+}
+
+type tracedIBConnector struct {
+	// ctx is held because IBConnector calls don't accept context
+	ctx       context.Context
+	connector ibclient.IBConnector
+}
+
+func (c *tracedIBConnector) CreateObject(obj ibclient.IBObject) (ref string, err error) {
+	_, span := Start(c.ctx, "infoblox.CreateObject", objectTypeKey.String(obj.ObjectType()))
+	defer func() { End(span, err) }()
+	return c.connector.CreateObject(obj)
+}
+
+func (c *tracedIBConnector) GetObject(obj ibclient.IBObject, ref string, res interface{}) (err error) {
+	_, span := Start(c.ctx, "infoblox.GetObject", objectTypeKey.String(obj.ObjectType()), refKey.String(ref))
+	defer func() { End(span, err) }()
+	return c.connector.GetObject(obj, ref, res)
+}
+
+func (c *tracedIBConnector) DeleteObject(ref string) (refRes string, err error) {
+	_, span := Start(c.ctx, "infoblox.DeleteObject", refKey.String(ref))
+	defer func() { End(span, err) }()
+	return c.connector.DeleteObject(ref)
+}
+
+func (c *tracedIBConnector) UpdateObject(obj ibclient.IBObject, ref string) (refRes string, err error) {
+	_, span := Start(c.ctx, "infoblox.UpdateObject", objectTypeKey.String(obj.ObjectType()), refKey.String(ref))
+	defer func() { End(span, err) }()
+	return c.connector.UpdateObject(obj, ref)
+}
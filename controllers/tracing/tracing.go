@@ -0,0 +1,71 @@
+// Package tracing records OpenTelemetry spans of Gslb reconciliation. Spans are dropped by the default no-op
+// tracer provider unless Setup enables export to OTLP collector
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/label"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/AbsaOSS/k8gb"
+
+// Span attribute keys
+const (
+	// GslbKey is namespace/name of reconciled Gslb
+	GslbKey = label.Key("k8gb.gslb")
+	// HostKey is FQDN balanced by Gslb
+	HostKey = label.Key("k8gb.host")
+	// PeerKey is geoTag of external cluster
+	PeerKey = label.Key("k8gb.peer.geotag")
+	// GeoTagKey is geoTag of the cluster running the operator
+	GeoTagKey = label.Key("k8gb.geotag")
+)
+
+// Gslb returns attribute of Gslb identified by namespace and name
+func Gslb(namespace, name string) label.KeyValue {
+	return GslbKey.String(namespace + "/" + name)
+}
+
+// Setup registers global tracer provider exporting sampled spans to OTLP collector at endpoint. Returned
+// shutdown exports remaining spans and closes the connection to collector
+func Setup(ctx context.Context, endpoint string, samplingPercent int, geoTag string) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(
+		otlpgrpc.WithInsecure(),
+		otlpgrpc.WithEndpoint(endpoint),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{
+			DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(samplingPercent) / 100)),
+		}),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(semconv.ServiceNameKey.String("k8gb"), GeoTagKey.String(geoTag))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts span as a child of span carried by ctx
+func Start(ctx context.Context, name string, attributes ...label.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends span; err marks the span as failed
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
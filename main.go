@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
 
@@ -28,12 +30,13 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers"
+	"github.com/AbsaOSS/k8gb/controllers/logging"
 	"github.com/AbsaOSS/k8gb/controllers/metrics"
+	"github.com/AbsaOSS/k8gb/controllers/tracing"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 	// +kubebuilder:scaffold:imports
 )
@@ -59,8 +62,6 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
-
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             runtimescheme,
 		MetricsBindAddress: metricsAddr,
@@ -69,10 +70,25 @@ func main() {
 		LeaderElectionID:   "8020e9ff.absa.oss",
 	})
 	if err != nil {
+		// operator configuration isn't read yet, so the error is logged at default level and format
+		ctrl.SetLogger(logging.New(depresolver.Log{}))
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	reconciler := &controllers.GslbReconciler{
+		Client: tracing.NewClient(mgr.GetClient()),
+		Log:    ctrl.Log.WithName("controllers").WithName("Gslb"),
+		Scheme: mgr.GetScheme(),
+	}
+	reconciler.DepResolver = depresolver.NewDependencyResolver(reconciler.Client)
+	reconciler.Config, err = reconciler.DepResolver.ResolveOperatorConfig()
+	// messages logged before the logger is set are dropped
+	ctrl.SetLogger(logging.New(reconciler.Config.Log))
+	if err != nil {
+		setupLog.Error(err, "reading config env variables")
+	}
+
 	setupLog.Info("Registering Components.")
 
 	// Add external-dns DNSEndpoints resource
@@ -84,15 +100,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	reconciler := &controllers.GslbReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Gslb"),
-		Scheme: mgr.GetScheme(),
+	if reconciler.Config.Override.FakeDNSEnabled {
+		setupLog.Info("fake DNS override enabled, edge DNS and external clusters are queried at fake DNS servers",
+			"address", reconciler.Config.Override.FakeDNSAddress, "peers", reconciler.Config.Override.FakeDNSPeers)
 	}
-	reconciler.DepResolver = depresolver.NewDependencyResolver(reconciler.Client)
-	reconciler.Config, err = reconciler.DepResolver.ResolveOperatorConfig()
-	if err != nil {
-		setupLog.Error(err, "reading config env variables")
+	shutdownTracing := func(context.Context) error { return nil }
+	if reconciler.Config.Tracing.Enabled {
+		setupLog.Info("starting tracing", "endpoint", reconciler.Config.Tracing.Endpoint)
+		shutdownTracing, err = tracing.Setup(context.Background(), reconciler.Config.Tracing.Endpoint,
+			reconciler.Config.Tracing.SamplingPercent, reconciler.Config.ClusterGeoTag)
+		if err != nil {
+			setupLog.Error(err, "unable to create tracing exporter")
+			os.Exit(1)
+		}
 	}
 	setupLog.Info("starting metrics")
 	reconciler.Metrics = metrics.NewPrometheusMetrics(*reconciler.Config)
@@ -101,10 +121,33 @@ func main() {
 		setupLog.Error(err, "register metrics error")
 		os.Exit(1)
 	}
+	if reconciler.Config.EmbeddedDNS.Enabled {
+		setupLog.Info("starting embedded DNS server")
+		if err = reconciler.SetupEmbeddedDNS(mgr); err != nil {
+			setupLog.Error(err, "unable to create embedded DNS server")
+			os.Exit(1)
+		}
+	}
+	if err = reconciler.SetupLatencyProbe(mgr); err != nil {
+		setupLog.Error(err, "unable to create latency probe")
+		os.Exit(1)
+	}
+	if err = reconciler.SetupMetricsSweep(mgr); err != nil {
+		setupLog.Error(err, "unable to create metrics sweep")
+		os.Exit(1)
+	}
 	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Gslb")
 		os.Exit(1)
 	}
+	if err = (&controllers.IngressReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("k8gb"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -112,4 +155,9 @@ func main() {
 		os.Exit(1)
 	}
 	reconciler.Metrics.Unregister()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		setupLog.Error(err, "flushing traces")
+	}
 }